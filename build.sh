@@ -1,5 +1,6 @@
 #!/bin/bash
 set -e
+VERSION=$(git describe --tags --always 2>/dev/null || echo dev)
 cd ./src
-go build -o ./../Renderer
-cd ..
\ No newline at end of file
+go build -ldflags "-X quehl.xyz/Renderer/renderer.Version=${VERSION}" -o ./../Renderer
+cd ..
@@ -2,4 +2,8 @@ module quehl.xyz/Renderer
 
 go 1.16
 
-require github.com/gomarkdown/markdown v0.0.0-20210514010506-3b9f47219fe7
+require (
+	github.com/gomarkdown/markdown v0.0.0-20210514010506-3b9f47219fe7
+	golang.org/x/net v0.0.0-20210510120150-4163338589ed
+	golang.org/x/text v0.3.6
+)
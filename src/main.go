@@ -3,236 +3,351 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
-	"strings"
-	"text/template"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
-	"github.com/gomarkdown/markdown"
+	"quehl.xyz/Renderer/renderer"
 )
 
-const ENVIRONMENTAL_VARIABLE = "CONFIG"
-const META_BLOCK_START = "```json\n"
-const META_BLOCK_END = "```\n"
-const MARKDOWN_FILE_ENDING = ".md"
+const CHECK_CONFIG_COMMAND = "check-config"
+const CHECK_COMMAND = "check"
+const VERIFY_COMMAND = "verify"
+const INIT_COMMAND = "init"
+const SERVE_COMMAND = "serve"
 
-type Configuration struct {
-	Input         string
-	Output        string
-	TemplatePage  string
-	TemplateIndex string
-}
-type Author struct {
-	Name         string
-	Mail         string
-	Organization string
-	ORCID        string
-}
-type MetaBlock struct {
-	Title   string
-	Date    time.Time
-	Authors []Author
-}
-type Page struct {
-	Title   string
-	Date    string
-	Authors []Author
-	Content string
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "  %s %s\n    \tvalidate the configuration and print it with resolved paths, without building\n", os.Args[0], CHECK_CONFIG_COMMAND)
+	fmt.Fprintf(flag.CommandLine.Output(), "  %s %s [-format text|json]\n    \tcheck every page, its links, outline and HTML without writing any output\n", os.Args[0], CHECK_COMMAND)
+	fmt.Fprintf(flag.CommandLine.Output(), "  %s %s [-golden directory] [-update]\n    \tbuild in memory and compare every file with the output or golden directory, printing a diff per change\n", os.Args[0], VERIFY_COMMAND)
+	fmt.Fprintf(flag.CommandLine.Output(), "  %s %s [-config file] [-input directory] [-output directory] [-title title] [-base-url url] [-yes] [-force]\n    \tset up a new site with a config file, the default templates and a sample post\n", os.Args[0], INIT_COMMAND)
+	fmt.Fprintf(flag.CommandLine.Output(), "  %s %s [-address host:port] [-interval duration]\n    \tbuild, serve the output and rebuild when the content or templates change, showing build errors over the pages\n", os.Args[0], SERVE_COMMAND)
+	flag.PrintDefaults()
 }
 
-type Link struct {
-	Title string
-	Date  string
-	Url   string
-}
+func main() {
+	dryRun := flag.Bool("dry-run", false, "render everything but only print the files that would be written")
+	diffManifest := flag.Bool("diff-manifest", false, "print the output paths added, changed or removed since the previous build")
+	clean := flag.Bool("clean", false, "remove the files the previous build wrote that this build no longer writes; with -dry-run only print them")
+	confirmClean := flag.Bool("yes", false, "let -clean remove more than CleanLimit percent of the previous output")
+	debug := flag.Bool("debug", false, "log the progress of every asset copy")
+	orderedLogs := flag.Bool("ordered-logs", false, "print the log lines of each page in source order instead of the order the pages finish rendering")
+	noCache := flag.Bool("no-cache", false, "ignore the build cache, render every page and do not update the cache")
+	version := flag.Bool("version", false, "print the generator name and version and exit")
+	check := flag.Bool("check", false, "validate every rendered page, e.g. report duplicate id attributes")
+	validate := flag.Bool("validate", false, "validate the structure of every written HTML page and report missing alt attributes")
+	validateChanged := flag.Bool("validate-changed", false, "like -validate, but only for pages rendered again, keeping unchanged pages from the build cache")
+	outputOverride := flag.String("output", "", "write to this output directory or archive instead of the configured Output, with its own build cache")
+	baseURLOverride := flag.String("base-url", "", "use this base URL instead of the configured BaseURL")
+	flag.Usage = usage
+	flag.Parse()
 
-type Index struct {
-	Links []Link
-}
+	if *version {
+		fmt.Println(renderer.Generator())
+		return
+	}
+	if flag.Arg(0) == INIT_COMMAND {
+		os.Exit(initSite(flag.Args()[1:]))
+	}
+	if flag.Arg(0) == CHECK_CONFIG_COMMAND {
+		os.Exit(checkConfig())
+	}
+	if flag.Arg(0) == CHECK_COMMAND {
+		os.Exit(checkSite(flag.Args()[1:], *outputOverride, *baseURLOverride))
+	}
+	if flag.Arg(0) == VERIFY_COMMAND {
+		os.Exit(verifySite(flag.Args()[1:], *outputOverride, *baseURLOverride))
+	}
+	if flag.Arg(0) == SERVE_COMMAND {
+		os.Exit(serveSite(flag.Args()[1:], *outputOverride, *baseURLOverride))
+	}
 
-func loadConfig() (Configuration, error) {
-	var configuration Configuration
-	var err error
-	path := os.Getenv(ENVIRONMENTAL_VARIABLE)
-	if len(path) > 0 {
-		var data []byte
-		data, err = ioutil.ReadFile(path)
-		if err == nil {
-			err = json.Unmarshal([]byte(data), &configuration)
-		}
+	configuration, err := renderer.LoadConfig()
+	if err != nil {
+		log.Fatal("configuration file path: ", err)
+		os.Exit(1)
 	} else {
-		err_msg := fmt.Sprintf("missing environmental variable '%s'", ENVIRONMENTAL_VARIABLE)
-		err = errors.New(err_msg)
+		log.Print("configuration was loaded")
 	}
-	return configuration, err
-}
-
-func checkPathError(path string) error {
-	_, err := os.Stat(path)
-	return err
-}
-
-func getMetaBlock(text string) (MetaBlock, int, error) {
-	var metaBlock MetaBlock
-	var contentStart int
-	var err error
-	if strings.HasPrefix(text, META_BLOCK_START) {
-		index := strings.Index(text, META_BLOCK_END)
-		if index != -1 {
-			metaBlockText := text[len(META_BLOCK_START):index]
-			contentStart = index + len(META_BLOCK_END)
-			err = json.Unmarshal([]byte(metaBlockText), &metaBlock)
-		} else {
-			err = errors.New("missing meta code block end")
-		}
+	configuration = renderer.OverrideOutput(configuration, *outputOverride, *baseURLOverride)
+	configuration.Inject, err = renderer.LoadInject(configuration.Inject)
+	if err != nil {
+		log.Fatal("inject snippet error: ", err)
+	}
+	err = renderer.Preflight(configuration)
+	if err != nil {
+		log.Fatal("configuration error: ", err)
+	}
+	if renderer.CheckPathError(configuration.Input) != nil {
+		log.Fatal("input directory error: ", err)
+		os.Exit(2)
 	} else {
-		err = errors.New("missing meta code block start")
+		log.Print("input directory found")
+	}
+	outputDirectory := configuration.Output
+	if renderer.IsArchivePath(outputDirectory) {
+		outputDirectory = filepath.Dir(outputDirectory)
+	}
+	if renderer.CheckPathError(outputDirectory) != nil {
+		log.Fatal("output directory error: ", err)
+		os.Exit(3)
+	} else {
+		log.Print("output directory found")
 	}
-	return metaBlock, contentStart, err
-}
 
-func renderMarkdown(text string) string {
-	md := []byte(text)
-	data := markdown.ToHTML(md, nil, nil)
-	html := string(data)
-	return html
+	builder := renderer.NewBuilder(configuration)
+	if *dryRun {
+		builder.Output = renderer.NewDryRunOutput(configuration.Output)
+		builder.DryRun = true
+	}
+	builder.NoCache = *noCache
+	builder.Debug = *debug
+	builder.OrderedLogs = *orderedLogs
+	builder.Clean = *clean
+	builder.ConfirmClean = *confirmClean
+	builder.Check = *check
+	builder.Validate = *validate || *validateChanged
+	builder.ValidateAll = *validate
+	builder.Only = flag.Args()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		received := <-signals
+		builder.Unlock()
+		log.Fatal("render interrupted: ", received)
+	}()
+	err = builder.Build()
+	var buildErr *renderer.BuildError
+	if errors.As(err, &buildErr) {
+		for _, failure := range buildErr.Errors {
+			log.Print("render error: ", failure)
+		}
+		os.Exit(1)
+	} else if err != nil {
+		log.Fatal("render error: ", err)
+	}
+	if *diffManifest {
+		diff := builder.ManifestDiff()
+		for _, path := range diff.Added {
+			fmt.Println("added", path)
+		}
+		for _, path := range diff.Changed {
+			fmt.Println("changed", path)
+		}
+		for _, path := range diff.Removed {
+			fmt.Println("removed", path)
+		}
+	}
 }
 
-func renderFile(path string) (Page, error) {
-	var page Page
-	data, err := ioutil.ReadFile(path)
+func checkConfig() int {
+	configuration, problems := renderer.CheckConfig()
+	data, err := json.MarshalIndent(configuration, "", "    ")
 	if err == nil {
-		text := string(data)
-		if len(text) > 0 {
-			var contentStart int
-			var metaBlock MetaBlock
-			metaBlock, contentStart, err = getMetaBlock(text)
-			if err == nil {
-				text = text[contentStart:]
-				text = renderMarkdown(text)
-				page = Page{
-					metaBlock.Title,
-					metaBlock.Date.Format("2006-01-02"),
-					metaBlock.Authors,
-					text,
-				}
-			} else {
-				msg := fmt.Sprintf("meta block error: %s", err)
-				err = errors.New(msg)
-			}
-		} else {
-			err = errors.New("file is empty")
-		}
+		fmt.Println(string(data))
 	}
-	return page, err
+	for _, problem := range problems {
+		log.Print("configuration error: ", problem)
+	}
+	if err != nil || len(problems) > 0 {
+		return 1
+	}
+	return 0
 }
 
-func doTemplating(outputPath string, templatePath string, page Page) error {
-	var file *os.File
-	var templateObj *template.Template
-	var err error
-
-	file, err = os.Create(outputPath)
+func loadCheckedConfig(outputOverride string, baseURLOverride string) (renderer.Configuration, error) {
+	configuration, err := renderer.LoadConfig()
 	if err == nil {
-		defer file.Close()
-		templateObj, err = template.ParseFiles(templatePath)
-		if err == nil {
-			err = templateObj.Execute(file, page)
-		}
+		configuration = renderer.OverrideOutput(configuration, outputOverride, baseURLOverride)
+		configuration.Inject, err = renderer.LoadInject(configuration.Inject)
+	}
+	if err == nil {
+		err = renderer.Preflight(configuration)
 	}
-	return err
+	if err == nil {
+		err = renderer.CheckPathError(configuration.Input)
+	}
+	return configuration, err
 }
 
-func doIndex(outputPath string, templatePath string, index Index) error {
-
-	var file *os.File
-	var templateObj *template.Template
-	var err error
-
-	file, err = os.Create(outputPath)
-	if err == nil {
-		defer file.Close()
-		templateObj, err = template.ParseFiles(templatePath)
-		if err == nil {
-			err = templateObj.Execute(file, index)
+func checkSite(arguments []string, outputOverride string, baseURLOverride string) int {
+	options := flag.NewFlagSet(CHECK_COMMAND, flag.ContinueOnError)
+	format := options.String("format", renderer.FINDING_FORMAT_TEXT, "print the findings as text or json")
+	err := options.Parse(arguments)
+	if err != nil {
+		return 2
+	}
+	if *format != renderer.FINDING_FORMAT_TEXT && *format != renderer.FINDING_FORMAT_JSON {
+		log.Printf("check: -format must be %s or %s, not '%s'", renderer.FINDING_FORMAT_TEXT, renderer.FINDING_FORMAT_JSON, *format)
+		return 2
+	}
+	var findings []renderer.Finding
+	configuration, err := loadCheckedConfig(outputOverride, baseURLOverride)
+	if err != nil {
+		findings = append(findings, renderer.Finding{Severity: renderer.POLICY_ERROR, Condition: "configuration", Message: err.Error()})
+	} else {
+		log.SetOutput(ioutil.Discard)
+		builder := renderer.NewCheckBuilder(configuration)
+		err = builder.Build()
+		log.SetOutput(os.Stderr)
+		findings = builder.Findings()
+	}
+	if *format == renderer.FINDING_FORMAT_JSON {
+		if findings == nil {
+			findings = []renderer.Finding{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(findings)
+	} else {
+		for _, finding := range findings {
+			fmt.Println(finding)
 		}
 	}
-	return err
+	if err != nil {
+		return 1
+	}
+	return 0
 }
 
-func renderFiles(inputPath string, outputPath string, templatePath string, templateIndex string) error {
-	var content Index
-	inputFiles, err := ioutil.ReadDir(inputPath)
-	count := len(inputFiles)
-	for index := 0; index < count; index++ {
-		inputFile := inputFiles[index]
-		fileName := inputFile.Name()
-		if !inputFile.IsDir() && strings.HasSuffix(fileName, MARKDOWN_FILE_ENDING) {
-			inputFilePath := fmt.Sprintf("%s/%s", inputPath, fileName)
-			log.Print("processing: ", inputFilePath)
-			var page Page
-			page, err = renderFile(inputFilePath)
-			if err == nil {
-				htmlFileName := strings.ReplaceAll(fileName, MARKDOWN_FILE_ENDING, ".html")
-				outputFilePath := fmt.Sprintf("%s/%s", outputPath, htmlFileName)
-				err = doTemplating(outputFilePath, templatePath, page)
-				if err == nil {
-					link := Link{
-						Title: page.Title,
-						Date:  page.Date,
-						Url:   fmt.Sprintf("/%s", htmlFileName),
-					}
-					content.Links = append(content.Links, link)
-				}
-			}
-			if err != nil {
-				log.Fatal("page render error: ", err)
+func verifySite(arguments []string, outputOverride string, baseURLOverride string) int {
+	options := flag.NewFlagSet(VERIFY_COMMAND, flag.ContinueOnError)
+	golden := options.String("golden", "", "compare with this directory instead of the configured Output")
+	update := options.Bool("update", false, "write the build into the golden directory instead of comparing")
+	err := options.Parse(arguments)
+	if err != nil {
+		return 2
+	}
+	configuration, err := loadCheckedConfig(outputOverride, baseURLOverride)
+	if err != nil {
+		log.Print("configuration error: ", err)
+		return 1
+	}
+	if len(*golden) == 0 {
+		*golden = configuration.Output
+	}
+	if len(*golden) == 0 || renderer.IsArchivePath(*golden) {
+		log.Print("verify: -golden must name a directory when Output is not one")
+		return 2
+	}
+	builder, output := renderer.NewVerifyBuilder(configuration)
+	log.SetOutput(ioutil.Discard)
+	err = builder.Build()
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Print("render error: ", err)
+		return 1
+	}
+	if *update {
+		err = renderer.UpdateGolden(configuration, output, *golden)
+		if err != nil {
+			log.Print("verify: ", err)
+			return 1
+		}
+		log.Printf("verify: updated %s", *golden)
+		return 0
+	}
+	differences, err := renderer.VerifyOutput(configuration, output, *golden)
+	if err != nil {
+		log.Print("verify: ", err)
+		return 1
+	}
+	for _, difference := range differences {
+		fmt.Println(difference.Change, difference.Path)
+		if len(difference.Detail) > 0 {
+			fmt.Print(difference.Detail)
+			if difference.Change == "changed" && difference.Detail[len(difference.Detail)-1] != '\n' {
+				fmt.Println()
 			}
 		}
 	}
-	indexHtmlPath := fmt.Sprintf("%s/index.html", outputPath)
-	err2 := doIndex(
-		indexHtmlPath,
-		templateIndex,
-		content,
-	)
-	if err2 != nil {
-		log.Fatal("index render error: ", err2)
+	if len(differences) > 0 {
+		log.Printf("verify: the build differs from %s in %d files", *golden, len(differences))
+		return 1
 	}
-	return err
+	log.Printf("verify: the build matches %s", *golden)
+	return 0
 }
 
-func main() {
-	configuration, err := loadConfig()
+func serveSite(arguments []string, outputOverride string, baseURLOverride string) int {
+	options := flag.NewFlagSet(SERVE_COMMAND, flag.ContinueOnError)
+	address := options.String("address", renderer.SERVE_ADDRESS, "listen on this host:port")
+	interval := options.Duration("interval", renderer.WATCH_INTERVAL, "look for changed content and templates this often")
+	err := options.Parse(arguments)
 	if err != nil {
-		log.Fatal("configuration file path: ", err)
-		os.Exit(1)
-	} else {
-		log.Print("configuration was loaded")
+		return 2
 	}
-	if checkPathError(configuration.Input) != nil {
-		log.Fatal("input directory error: ", err)
-		os.Exit(2)
-	} else {
-		log.Print("input directory found")
+	if *interval <= 0 {
+		log.Printf("serve: -interval must be positive, not %s", *interval)
+		return 2
 	}
-	if checkPathError(configuration.Output) != nil {
-		log.Fatal("output directory error: ", err)
-		os.Exit(3)
-	} else {
-		log.Print("output directory found")
+	configuration, err := loadCheckedConfig(outputOverride, baseURLOverride)
+	if err != nil {
+		log.Print("configuration error: ", err)
+		return 1
+	}
+	if len(configuration.Output) == 0 || renderer.IsArchivePath(configuration.Output) {
+		log.Print("serve: Output must be a directory")
+		return 2
 	}
+	err = os.MkdirAll(configuration.Output, 0755)
+	if err != nil {
+		log.Print("output directory error: ", err)
+		return 1
+	}
+	server := renderer.NewServer(renderer.NewBuilder(configuration))
+	server.Rebuild()
+	go server.Watch(*interval, nil)
+	log.Printf("serve: http://%s/", *address)
+	err = http.ListenAndServe(*address, server)
+	log.Print("serve: ", err)
+	return 1
+}
 
-	err = renderFiles(
-		configuration.Input,
-		configuration.Output,
-		configuration.TemplatePage,
-		configuration.TemplateIndex,
-	)
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func initSite(arguments []string) int {
+	options := flag.NewFlagSet(INIT_COMMAND, flag.ContinueOnError)
+	configFile := os.Getenv(renderer.ENVIRONMENTAL_VARIABLE)
+	if len(configFile) == 0 {
+		configFile = renderer.DEFAULT_CONFIG_FILE_NAME
+	}
+	config := options.String("config", configFile, "write the config file here, the other files go next to it")
+	input := options.String("input", renderer.DEFAULT_INIT_INPUT, "the input directory for markdown pages")
+	output := options.String("output", renderer.DEFAULT_INIT_OUTPUT, "the output directory for the built site")
+	title := options.String("title", renderer.DEFAULT_INIT_TITLE, "the site title shown by the default templates")
+	baseURL := options.String("base-url", "", "the URL the site is published at, e.g. https://example.com/")
+	yes := options.Bool("yes", false, "take the flag values without asking, as when not run in a terminal")
+	force := options.Bool("force", false, "overwrite an existing config file, templates and sample post")
+	err := options.Parse(arguments)
 	if err != nil {
-		log.Fatal("render error: ", err)
+		return 2
+	}
+	err = renderer.RunInit(renderer.InitOptions{
+		Config:      *config,
+		Input:       *input,
+		Output:      *output,
+		Title:       *title,
+		BaseURL:     *baseURL,
+		Interactive: !*yes && isTerminal(os.Stdin),
+		Force:       *force,
+		Now:         time.Now(),
+	}, os.Stdin, os.Stdout)
+	if err != nil {
+		log.Print("init: ", err)
+		return 1
 	}
+	return 0
 }
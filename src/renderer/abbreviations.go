@@ -0,0 +1,186 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+const ABBREVIATION_START = "*["
+const ABBREVIATION_SEPARATOR = "]:"
+
+func parseAbbreviation(line string) (string, string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, ABBREVIATION_START) {
+		return "", "", false
+	}
+	end := strings.Index(trimmed, ABBREVIATION_SEPARATOR)
+	if end == -1 {
+		return "", "", false
+	}
+	term := trimmed[len(ABBREVIATION_START):end]
+	if len(strings.TrimSpace(term)) == 0 || strings.ContainsAny(term, "[]") {
+		return "", "", false
+	}
+	return term, strings.TrimSpace(trimmed[end+len(ABBREVIATION_SEPARATOR):]), true
+}
+
+func stripAbbreviations(md []byte) ([]byte, map[string]string) {
+	abbreviations := map[string]string{}
+	if !strings.Contains(string(md), ABBREVIATION_START) {
+		return md, abbreviations
+	}
+	var result strings.Builder
+	fence := ""
+	for _, line := range strings.SplitAfter(string(md), "\n") {
+		marker := fenceMarker(line)
+		if len(fence) > 0 {
+			if strings.HasPrefix(marker, fence) && len(strings.TrimSpace(line)) == len(marker) {
+				fence = ""
+			}
+		} else if len(marker) > 0 {
+			fence = marker
+		} else if term, title, found := parseAbbreviation(strings.TrimRight(line, "\r\n")); found {
+			abbreviations[term] = title
+			line = line[len(strings.TrimRight(line, "\r\n")):]
+		}
+		result.WriteString(line)
+	}
+	return []byte(result.String()), abbreviations
+}
+
+func loadAbbreviations(path string) (map[string]string, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		msg := fmt.Sprintf("abbreviations file: %s", err)
+		return nil, errors.New(msg)
+	}
+	abbreviations := map[string]string{}
+	for number, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		term, title, found := parseAbbreviation(line)
+		if !found {
+			msg := fmt.Sprintf("abbreviations file %s:%d: expected a definition like *[HTML]: HyperText Markup Language", path, number+1)
+			return nil, errors.New(msg)
+		}
+		abbreviations[term] = title
+	}
+	return abbreviations, nil
+}
+
+func checkAbbreviations(configuration Configuration) error {
+	_, err := loadAbbreviations(configuration.Abbreviations)
+	return err
+}
+
+func isWordCharacter(character rune) bool {
+	return unicode.IsLetter(character) || unicode.IsDigit(character) || character == '_'
+}
+
+func matchAbbreviation(text string, position int, terms []string) string {
+	if position > 0 {
+		previous, _ := utf8.DecodeLastRuneInString(text[:position])
+		if isWordCharacter(previous) {
+			return ""
+		}
+	}
+	for _, term := range terms {
+		if !strings.HasPrefix(text[position:], term) {
+			continue
+		}
+		next, _ := utf8.DecodeRuneInString(text[position+len(term):])
+		if position+len(term) == len(text) || !isWordCharacter(next) {
+			return term
+		}
+	}
+	return ""
+}
+
+func abbreviationNodes(text string, abbreviations map[string]string, terms []string) []ast.Node {
+	var nodes []ast.Node
+	start := 0
+	for position := 0; position < len(text); {
+		term := matchAbbreviation(text, position, terms)
+		if len(term) == 0 {
+			_, size := utf8.DecodeRuneInString(text[position:])
+			position += size
+			continue
+		}
+		if position > start {
+			nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text[start:position])}})
+		}
+		opening := fmt.Sprintf(`<abbr title="%s">`, html.EscapeString(abbreviations[term]))
+		nodes = append(nodes,
+			&ast.HTMLSpan{Leaf: ast.Leaf{Literal: []byte(opening)}},
+			&ast.Text{Leaf: ast.Leaf{Literal: []byte(term)}},
+			&ast.HTMLSpan{Leaf: ast.Leaf{Literal: []byte("</abbr>")}},
+		)
+		position += len(term)
+		start = position
+	}
+	if len(nodes) > 0 && start < len(text) {
+		nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text[start:])}})
+	}
+	return nodes
+}
+
+func insideImage(node ast.Node) bool {
+	for parent := node.GetParent(); parent != nil; parent = parent.GetParent() {
+		if _, isImage := parent.(*ast.Image); isImage {
+			return true
+		}
+	}
+	return false
+}
+
+func expandAbbreviations(document ast.Node, abbreviations map[string]string) {
+	var terms []string
+	for term := range abbreviations {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	var texts []*ast.Text
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		if text, isText := node.(*ast.Text); isText && entering && !insideImage(text) {
+			texts = append(texts, text)
+		}
+		return ast.GoToNext
+	})
+	for _, text := range texts {
+		nodes := abbreviationNodes(string(text.Literal), abbreviations, terms)
+		if len(nodes) == 0 {
+			continue
+		}
+		parent := text.Parent
+		var children []ast.Node
+		for _, child := range parent.GetChildren() {
+			if child != ast.Node(text) {
+				children = append(children, child)
+				continue
+			}
+			for _, node := range nodes {
+				node.SetParent(parent)
+				children = append(children, node)
+			}
+		}
+		parent.SetChildren(children)
+	}
+}
@@ -0,0 +1,48 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSiteAbbreviations(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		err   string
+		want  string
+		index string
+	}{
+		{"expanded", "*[HTML]: HyperText Markup Language\n", "", `<abbr title="HyperText Markup Language">HTML</abbr> pages`, `<abbr title="HyperText Markup Language">HTML</abbr> index`},
+		{"page definition wins", "*[CSS]: Site Styles\n", "", `<abbr title="Cascading Style Sheets">CSS</abbr>`, ""},
+		{"missing file", "", "abbreviations file: open ", "", ""},
+		{"malformed", "*[HTML]: HyperText Markup Language\nnot a definition\n", "abbreviations.txt:2: expected a definition", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Abbreviations = site.path("abbreviations.txt")
+			if len(test.file) > 0 {
+				site.writeFile(site.Configuration.Abbreviations, test.file)
+			}
+			site.writeFile(site.Configuration.TemplateIndex, `{{markdownify "HTML index"}}`+"\n")
+			site.page("a.md", `{"Title": "A"}`, "*[CSS]: Cascading Style Sheets\n\nHTML pages with CSS\n")
+			err := site.build()
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) || !strings.Contains(err.Error(), site.Configuration.Abbreviations) {
+					t.Fatalf("build = %v, want %q naming %s", err, test.err, site.Configuration.Abbreviations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page := site.read("a.html"); !strings.Contains(page, test.want) {
+				t.Errorf("a.html = %q, want %q", page, test.want)
+			}
+			if index := site.read(INDEX_FILE_NAME); !strings.Contains(index, test.index) {
+				t.Errorf("index = %q, want %q", index, test.index)
+			}
+		})
+	}
+}
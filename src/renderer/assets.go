@@ -0,0 +1,183 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DEFAULT_ASSET_WORKERS = 4
+const ASSET_BUFFER_SIZE = 256 * 1024
+const ASSET_PROGRESS_STEP = 16 * 1024 * 1024
+
+type assetFile struct {
+	name   string
+	output string
+}
+
+type copyProgress struct {
+	path    string
+	size    int64
+	copied  int64
+	logged  int64
+	started time.Time
+}
+
+type assetCopies struct {
+	group  sync.WaitGroup
+	mutex  sync.Mutex
+	errors map[string]error
+}
+
+func listAssets(configuration Configuration, directory string) ([]string, error) {
+	var names []string
+	inputFiles, err := ioutil.ReadDir(filepath.Join(configuration.Input, filepath.FromSlash(directory)))
+	for index := 0; err == nil && index < len(inputFiles); index++ {
+		inputFile := inputFiles[index]
+		name := path.Join(directory, inputFile.Name())
+		if inputFile.IsDir() {
+			if !configuration.Recursive || isExcluded(configuration, name, true) || isBundleDirectory(configuration, name) {
+				continue
+			}
+			var nested []string
+			nested, err = listAssets(configuration, name)
+			names = append(names, nested...)
+		} else if inputFile.Mode().IsRegular() && !strings.HasSuffix(name, MARKDOWN_FILE_ENDING) && !matchAny(configuration.Exclude, name, false) {
+			names = append(names, name)
+		}
+	}
+	return names, err
+}
+
+func assetWorkers(configuration Configuration) int {
+	if configuration.AssetWorkers <= 0 {
+		return DEFAULT_ASSET_WORKERS
+	}
+	return configuration.AssetWorkers
+}
+
+func hashFile(filePath string) (ManifestEntry, error) {
+	var entry ManifestEntry
+	file, err := os.Open(filePath)
+	if err != nil {
+		return entry, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	entry.Size, err = io.Copy(hash, file)
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return entry, err
+}
+
+func (progress *copyProgress) Write(data []byte) (int, error) {
+	progress.copied += int64(len(data))
+	if progress.copied-progress.logged >= ASSET_PROGRESS_STEP {
+		progress.logged = progress.copied
+		logPrintf("debug: copying %s: %d of %d bytes", progress.path, progress.copied, progress.size)
+	}
+	return len(data), nil
+}
+
+func streamAsset(destination io.Writer, source io.Reader) (int64, error) {
+	return io.CopyBuffer(destination, source, make([]byte, ASSET_BUFFER_SIZE))
+}
+
+func copyAsset(builder *Builder, output *manifestOutput, asset assetFile, preserve bool) error {
+	configuration := builder.Configuration
+	sourcePath := filepath.Join(configuration.Input, filepath.FromSlash(asset.name))
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	outputName := asset.output
+	destinationPath := filepath.Join(configuration.Output, filepath.FromSlash(outputName))
+	if preserve {
+		existing, statErr := os.Stat(destinationPath)
+		if statErr == nil && existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+			entry, found := builder.PreviousManifest.Files[outputName]
+			if !found || entry.Size != info.Size() {
+				entry, err = hashFile(destinationPath)
+			}
+			if err == nil {
+				output.add(outputName, entry)
+			}
+			return err
+		}
+	}
+	logPrint("copying: ", sourcePath)
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := output.Create(outputName)
+	if err == nil {
+		var writer io.Writer = destination
+		var progress *copyProgress
+		if builder.Debug {
+			progress = &copyProgress{path: sourcePath, size: info.Size(), started: time.Now()}
+			writer = io.MultiWriter(destination, progress)
+		}
+		_, err = streamAsset(writer, source)
+		if err == nil && progress != nil {
+			logPrintf("debug: copied %s: %d bytes in %s", sourcePath, progress.copied, time.Since(progress.started))
+		}
+		closeErr := destination.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	if err == nil && preserve {
+		err = os.Chtimes(destinationPath, info.ModTime(), info.ModTime())
+	}
+	return err
+}
+
+func startAssetCopies(builder *Builder, output *manifestOutput, outputs map[string]bool, bundled []assetFile, preserve bool) (*assetCopies, error) {
+	copies := &assetCopies{errors: map[string]error{}}
+	var assets []assetFile
+	if builder.Configuration.CopyAssets {
+		names, err := listAssets(builder.Configuration, "")
+		if err != nil {
+			return copies, err
+		}
+		for _, name := range names {
+			assets = append(assets, assetFile{name: name, output: normalizeName(name)})
+		}
+	}
+	assets = append(assets, bundled...)
+	workers := make(chan bool, assetWorkers(builder.Configuration))
+	for _, asset := range assets {
+		if outputs[asset.output] {
+			builder.warn(WARNING_SHADOWED_ASSET, asset.name, "asset %s is not copied, the site writes its own %s", asset.name, asset.output)
+			continue
+		}
+		copies.group.Add(1)
+		go func(asset assetFile) {
+			defer copies.group.Done()
+			workers <- true
+			err := copyAsset(builder, output, asset, preserve)
+			<-workers
+			if err != nil {
+				copies.mutex.Lock()
+				copies.errors[asset.name] = err
+				copies.mutex.Unlock()
+			}
+		}(asset)
+	}
+	return copies, nil
+}
+
+func (copies *assetCopies) wait(builder *Builder) {
+	copies.group.Wait()
+	for name, err := range copies.errors {
+		builder.fail(FAILURE_ASSET, filepath.Join(builder.Configuration.Input, filepath.FromSlash(name)), err)
+	}
+}
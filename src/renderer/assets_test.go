@@ -0,0 +1,142 @@
+package renderer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const TEST_ASSET_SIZE = 8 * 1024 * 1024
+
+type patternReader struct {
+	offset int64
+}
+
+type chunkReader struct {
+	reader  io.Reader
+	reads   int
+	largest int
+}
+
+func (reader *patternReader) Read(data []byte) (int, error) {
+	for index := range data {
+		data[index] = byte(reader.offset % 251)
+		reader.offset++
+	}
+	return len(data), nil
+}
+
+func (reader *chunkReader) Read(data []byte) (int, error) {
+	reader.reads++
+	if len(data) > reader.largest {
+		reader.largest = len(data)
+	}
+	return reader.reader.Read(data)
+}
+
+func writeTestAsset(t *testing.T, path string) string {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), io.LimitReader(&patternReader{}, TEST_ASSET_SIZE))
+	closeErr := file.Close()
+	if err != nil || closeErr != nil {
+		t.Fatal(err, closeErr)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func TestStreamAssetReadsInChunks(t *testing.T) {
+	reader := &chunkReader{reader: io.LimitReader(&patternReader{}, TEST_ASSET_SIZE)}
+	hash := sha256.New()
+	copied, err := streamAsset(hash, reader)
+	if err != nil || copied != TEST_ASSET_SIZE {
+		t.Fatalf("streamAsset = %d, %v, want %d bytes", copied, err, TEST_ASSET_SIZE)
+	}
+	if reader.largest > ASSET_BUFFER_SIZE {
+		t.Errorf("largest read asked for %d bytes, want at most %d", reader.largest, ASSET_BUFFER_SIZE)
+	}
+	if reader.reads < TEST_ASSET_SIZE/ASSET_BUFFER_SIZE {
+		t.Errorf("%d reads for %d bytes, want chunks of at most %d bytes", reader.reads, TEST_ASSET_SIZE, ASSET_BUFFER_SIZE)
+	}
+	want := sha256.New()
+	io.Copy(want, io.LimitReader(&patternReader{}, TEST_ASSET_SIZE))
+	if !bytes.Equal(hash.Sum(nil), want.Sum(nil)) {
+		t.Error("the streamed content differs from the source")
+	}
+}
+
+func TestCopyLargeAsset(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.CopyAssets = true
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	sourcePath := site.path("content/video.bin")
+	site.write("video.bin", "")
+	hash := writeTestAsset(t, sourcePath)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	site.mustBuild()
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > TEST_ASSET_SIZE/2 {
+		t.Errorf("the build allocated %d bytes for a %d byte asset", allocated, TEST_ASSET_SIZE)
+	}
+	var manifest Manifest
+	err := json.Unmarshal([]byte(site.read(MANIFEST_FILE_NAME)), &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := manifest.Files["video.bin"]; entry.SHA256 != hash || entry.Size != TEST_ASSET_SIZE {
+		t.Errorf("manifest entry = %+v, want %s and %d bytes", entry, hash, TEST_ASSET_SIZE)
+	}
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := os.Stat(site.path("public/video.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !copied.ModTime().Equal(sourceInfo.ModTime()) || copied.Size() != TEST_ASSET_SIZE {
+		t.Errorf("copy has %s and %d bytes, want %s and %d bytes", copied.ModTime(), copied.Size(), sourceInfo.ModTime(), TEST_ASSET_SIZE)
+	}
+	site.mustBuild()
+	again, err := os.Stat(site.path("public/video.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(copied, again) {
+		t.Error("the unchanged asset was copied again")
+	}
+}
+
+func TestAssetCopyDebugLog(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		site := newTestSite(t)
+		site.Configuration.CopyAssets = true
+		site.page("a.md", `{"Title": "A"}`, "body\n")
+		site.write("style.css", "body {}\n")
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		builder := NewBuilder(site.Configuration)
+		builder.Debug = debug
+		err := builder.Build()
+		log.SetOutput(ioutil.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found := strings.Contains(logged.String(), "debug: copied "+site.path("content/style.css")+": 8 bytes in "); found != debug {
+			t.Errorf("debug = %v, copy progress logged = %v: %q", debug, found, logged.String())
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const DEFAULT_AUTHOR_CONJUNCTION = "and"
+const DEFAULT_AUTHOR_ET_AL = "et al."
+
+type AuthorDisplay struct {
+	Conjunction string
+	EtAl        int
+	EtAlText    string
+}
+
+type AuthorReference struct {
+	Id     string
+	Author Author
+}
+
+type AuthorList []AuthorReference
+
+type authorError struct {
+	detail string
+}
+
+var AUTHOR_WITH_MAIL = regexp.MustCompile(`^\s*(.*?)\s*<([^<>\s]+@[^<>\s]+)>\s*$`)
+
+func (err authorError) Error() string {
+	return err.detail
+}
+
+func jsonKind(text string) string {
+	switch {
+	case strings.HasPrefix(text, "["):
+		return "array"
+	case text == "true" || text == "false":
+		return "boolean"
+	case text == "null":
+		return "null"
+	}
+	return "number"
+}
+
+func (reference *AuthorReference) UnmarshalJSON(data []byte) error {
+	var err error
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "\"") {
+		err = json.Unmarshal(data, &reference.Id)
+		match := AUTHOR_WITH_MAIL.FindStringSubmatch(reference.Id)
+		if err == nil && match != nil {
+			reference.Id = ""
+			reference.Author = Author{Name: match[1], Mail: match[2]}
+		}
+	} else if strings.HasPrefix(text, "{") {
+		err = json.Unmarshal(data, &reference.Author)
+		if typed, isType := err.(*json.UnmarshalTypeError); isType {
+			msg := fmt.Sprintf("author %s must be a %s, not a JSON %s", typed.Field, typed.Type, typed.Value)
+			err = authorError{msg}
+		}
+	} else {
+		msg := fmt.Sprintf("author must be a string or an object, not a JSON %s", jsonKind(text))
+		err = authorError{msg}
+	}
+	return err
+}
+
+func skipJsonSeparators(text []byte, offset int) int {
+	for offset < len(text) && strings.IndexByte(" \t\r\n:,", text[offset]) != -1 {
+		offset++
+	}
+	return offset
+}
+
+func authorsOffset(metaBlockText []byte) int {
+	decoder := json.NewDecoder(bytes.NewReader(metaBlockText))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		return 0
+	}
+	for decoder.More() {
+		token, err = decoder.Token()
+		key, isKey := token.(string)
+		if err != nil || !isKey {
+			return 0
+		}
+		offset := skipJsonSeparators(metaBlockText, int(decoder.InputOffset()))
+		if !strings.EqualFold(key, "Authors") {
+			var value json.RawMessage
+			if decoder.Decode(&value) != nil {
+				return 0
+			}
+			continue
+		}
+		if offset >= len(metaBlockText) || metaBlockText[offset] != '[' {
+			return offset
+		}
+		decoder.Token()
+		for decoder.More() {
+			start := skipJsonSeparators(metaBlockText, int(decoder.InputOffset()))
+			var value json.RawMessage
+			if decoder.Decode(&value) != nil || (value[0] != '"' && value[0] != '{') {
+				return start
+			}
+		}
+		return offset
+	}
+	return 0
+}
+
+func (reference AuthorReference) MarshalJSON() ([]byte, error) {
+	if len(reference.Id) > 0 {
+		return json.Marshal(reference.Id)
+	}
+	return json.Marshal(reference.Author)
+}
+
+func (list *AuthorList) UnmarshalJSON(data []byte) error {
+	var err error
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "[") || text == "null" {
+		var references []AuthorReference
+		err = json.Unmarshal(data, &references)
+		*list = references
+	} else {
+		var reference AuthorReference
+		err = json.Unmarshal(data, &reference)
+		*list = AuthorList{reference}
+	}
+	return err
+}
+
+func normalizeAuthor(author Author) Author {
+	return Author{
+		Name:         strings.TrimSpace(author.Name),
+		Mail:         strings.ToLower(strings.TrimSpace(author.Mail)),
+		Organization: strings.TrimSpace(author.Organization),
+		ORCID:        strings.TrimSpace(author.ORCID),
+	}
+}
+
+func authorKey(author Author) string {
+	author = normalizeAuthor(author)
+	if len(author.Mail) > 0 {
+		return "mail:" + author.Mail
+	}
+	return "name:" + strings.ToLower(author.Name)
+}
+
+func resolveAuthors(references AuthorList, registry map[string]Author) ([]Author, error) {
+	var authors []Author
+	var err error
+	for _, reference := range references {
+		if len(reference.Id) > 0 {
+			author, found := registry[reference.Id]
+			if found {
+				authors = append(authors, normalizeAuthor(author))
+			} else if len(registry) == 0 {
+				authors = append(authors, normalizeAuthor(Author{Name: reference.Id}))
+			} else {
+				msg := fmt.Sprintf("unknown author id '%s', it is not in the Authors of the configuration", reference.Id)
+				err = errors.New(msg)
+				break
+			}
+		} else {
+			authors = append(authors, normalizeAuthor(reference.Author))
+		}
+	}
+	return authors, err
+}
+
+func sortAuthors(authors []Author, collator *titleCollator) {
+	names := collator.order()
+	sort.SliceStable(authors, func(i, j int) bool {
+		return names.compare(authors[i].Name, authors[j].Name) < 0
+	})
+}
+
+func authorDisplay(configuration Configuration) AuthorDisplay {
+	if configuration.AuthorDisplay == nil {
+		return AuthorDisplay{}
+	}
+	return *configuration.AuthorDisplay
+}
+
+func (display AuthorDisplay) withDefaults() AuthorDisplay {
+	if len(display.Conjunction) == 0 {
+		display.Conjunction = DEFAULT_AUTHOR_CONJUNCTION
+	}
+	if len(display.EtAlText) == 0 {
+		display.EtAlText = DEFAULT_AUTHOR_ET_AL
+	}
+	return display
+}
+
+func checkAuthorDisplay(configuration Configuration) error {
+	if configuration.AuthorDisplay != nil && configuration.AuthorDisplay.EtAl < 0 {
+		msg := fmt.Sprintf("AuthorDisplay EtAl must not be negative, not %d", configuration.AuthorDisplay.EtAl)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func formatAuthors(authors []Author, display AuthorDisplay) string {
+	display = display.withDefaults()
+	var names []string
+	for _, author := range authors {
+		if len(author.Name) > 0 {
+			names = append(names, author.Name)
+		}
+	}
+	if display.EtAl > 0 && len(names) > display.EtAl {
+		return names[0] + " " + display.EtAlText
+	}
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " " + display.Conjunction + " " + names[1]
+	}
+	last := len(names) - 1
+	return strings.Join(names[:last], ", ") + ", " + display.Conjunction + " " + names[last]
+}
+
+func (page Page) FormattedAuthors() string {
+	return formatAuthors(page.Authors, page.authorDisplay)
+}
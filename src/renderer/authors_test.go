@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetaAuthorShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		authors string
+		want    []Author
+		err     string
+	}{
+		{"plain name", `"Jane Smith"`, []Author{{Name: "Jane Smith"}}, ""},
+		{"name and mail", `"Jane Smith <Jane@Example.com>"`, []Author{{Name: "Jane Smith", Mail: "jane@example.com"}}, ""},
+		{"single object", `{"Name": "Jane Smith", "Mail": "jane@example.com"}`, []Author{{Name: "Jane Smith", Mail: "jane@example.com"}}, ""},
+		{"array of both", `["Jane Smith", {"Name": "John Doe"}]`, []Author{{Name: "Jane Smith"}, {Name: "John Doe"}}, ""},
+		{"empty array", `[]`, nil, ""},
+		{"number", `42`, nil, "4:16: author must be a string or an object, not a JSON number"},
+		{"number in an array", `["Jane Smith", 42]`, nil, "4:31: author must be a string or an object, not a JSON number"},
+		{"boolean", `true`, nil, "4:16: author must be a string or an object, not a JSON boolean"},
+		{"nested array", `[["Jane Smith"]]`, nil, "4:17: author must be a string or an object, not a JSON array"},
+		{"number as a name", `{"Name": 42}`, nil, "4:16: author Name must be a string, not a JSON number"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := META_BLOCK_START + "{\n    \"Title\": \"A\",\n    \"Authors\": " + test.authors + "\n}\n" + META_BLOCK_END + "body\n"
+			meta, _, err := getMetaBlock([]byte(text), Configuration{})
+			if len(test.err) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+					t.Fatalf("error = %v, want %q", err, test.err)
+				}
+				if strings.Contains(err.Error(), "renderer.") {
+					t.Errorf("error = %q names a Go type", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			authors, err := resolveAuthors(meta.Authors, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(authors, test.want) {
+				t.Errorf("authors = %+v, want %+v", authors, test.want)
+			}
+		})
+	}
+}
+
+func TestStrictMetaAuthors(t *testing.T) {
+	registry := map[string]Author{"jane": {Name: "Jane Smith"}}
+	tests := []struct {
+		name    string
+		authors string
+		want    []Author
+		err     string
+	}{
+		{"array of ids", `["jane"]`, []Author{{Name: "Jane Smith"}}, ""},
+		{"single id", `"jane"`, nil, "Authors must be an array"},
+		{"unknown id", `["john"]`, nil, "unknown author id 'john'"},
+	}
+	configuration := Configuration{DisallowUnknownFields: true}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := META_BLOCK_START + "{\"Title\": \"A\", \"Authors\": " + test.authors + "}\n" + META_BLOCK_END
+			meta, _, err := getMetaBlock([]byte(text), configuration)
+			var authors []Author
+			if err == nil {
+				authors, err = resolveAuthors(meta.Authors, registry)
+			}
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(authors, test.want) {
+				t.Errorf("authors = %+v, %v, want %+v", authors, err, test.want)
+			}
+		})
+	}
+}
+
+func TestAuthorRegistryIds(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry map[string]Author
+		authors  string
+		want     string
+		err      string
+	}{
+		{"known id", map[string]Author{"jane": {Name: "Jane Smith"}}, `["jane"]`, "Jane Smith", ""},
+		{"unknown id", map[string]Author{"jane": {Name: "Jane Smith"}}, `["jnae"]`, "", "unknown author id 'jnae'"},
+		{"name with a registry", map[string]Author{"jane": {Name: "Jane Smith"}}, `"John Doe"`, "", "unknown author id 'John Doe'"},
+		{"inline object with a registry", map[string]Author{"jane": {Name: "Jane Smith"}}, `{"Name": "John Doe"}`, "John Doe", ""},
+		{"name and mail with a registry", map[string]Author{"jane": {Name: "Jane Smith"}}, `"John Doe <john@example.com>"`, "John Doe", ""},
+		{"name without a registry", nil, `"John Doe"`, "John Doe", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Authors = test.registry
+			site.writeFile(site.Configuration.TemplatePage, `{{range .Authors}}{{.Name}}{{end}}`+"\n")
+			site.page("a.md", `{"Title": "A", "Authors": `+test.authors+`}`, "body\n")
+			err := site.build()
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) || !strings.Contains(err.Error(), site.path("content/a.md")) {
+					t.Fatalf("build = %v, want %q naming content/a.md", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page := site.read("a.html"); strings.TrimSpace(page) != test.want {
+				t.Errorf("a.html = %q, want %q", page, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatAuthors(t *testing.T) {
+	var twenty []Author
+	for index := 1; index <= 20; index++ {
+		twenty = append(twenty, Author{Name: fmt.Sprintf("Author %d", index)})
+	}
+	three := []Author{{Name: "Ann"}, {Name: "Ben"}, {Name: "Cy"}}
+	tests := []struct {
+		name    string
+		authors []Author
+		display AuthorDisplay
+		want    string
+	}{
+		{"none", nil, AuthorDisplay{}, ""},
+		{"one", three[:1], AuthorDisplay{}, "Ann"},
+		{"two", three[:2], AuthorDisplay{}, "Ann and Ben"},
+		{"three", three, AuthorDisplay{}, "Ann, Ben, and Cy"},
+		{"twenty", twenty, AuthorDisplay{}, strings.Join(namesOf(twenty[:19]), ", ") + ", and Author 20"},
+		{"own conjunction", three, AuthorDisplay{Conjunction: "und"}, "Ann, Ben, und Cy"},
+		{"at the et al cutoff", three, AuthorDisplay{EtAl: 3}, "Ann, Ben, and Cy"},
+		{"over the et al cutoff", three, AuthorDisplay{EtAl: 2}, "Ann et al."},
+		{"twenty over the cutoff", twenty, AuthorDisplay{EtAl: 3, EtAlText: "u. a."}, "Author 1 u. a."},
+		{"one under the cutoff", three[:1], AuthorDisplay{EtAl: 1}, "Ann"},
+		{"nameless authors are skipped", []Author{{Name: "Ann"}, {Mail: "x@example.com"}, {Name: "Ben"}}, AuthorDisplay{EtAl: 2}, "Ann and Ben"},
+	}
+	for _, test := range tests {
+		if got := formatAuthors(test.authors, test.display); got != test.want {
+			t.Errorf("%s: formatAuthors = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func namesOf(authors []Author) []string {
+	var names []string
+	for _, author := range authors {
+		names = append(names, author.Name)
+	}
+	return names
+}
@@ -0,0 +1,246 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+const DEFAULT_AZ_OUTPUT = "a-z.html"
+const AZ_NON_LATIN_OTHER = "other"
+const AZ_NON_LATIN_SCRIPT = "script"
+const AZ_OTHER_LETTER = "#"
+const AZ_OTHER_SLUG = "other"
+const AZ_ANCHOR_PREFIX = "letter-"
+
+type AZIndex struct {
+	Output    string
+	PerLetter bool
+	NonLatin  string
+}
+
+type AZLetter struct {
+	Letter string
+	Slug   string
+	Anchor string
+	Url    string
+	Links  []Link
+
+	rank int
+}
+
+func azIndex(configuration Configuration) AZIndex {
+	options := *configuration.AZ
+	if len(options.Output) == 0 {
+		options.Output = DEFAULT_AZ_OUTPUT
+	}
+	if len(options.NonLatin) == 0 {
+		options.NonLatin = AZ_NON_LATIN_OTHER
+	}
+	return options
+}
+
+func checkAZ(configuration Configuration) error {
+	if configuration.AZ == nil {
+		return nil
+	}
+	if configuration.OutputMode == OUTPUT_MODE_BOOK {
+		return errors.New("the A-Z listing is not written in book output mode")
+	}
+	options := azIndex(configuration)
+	if options.NonLatin != AZ_NON_LATIN_OTHER && options.NonLatin != AZ_NON_LATIN_SCRIPT {
+		msg := fmt.Sprintf("AZ NonLatin must be '%s' or '%s', not '%s'", AZ_NON_LATIN_OTHER, AZ_NON_LATIN_SCRIPT, options.NonLatin)
+		return errors.New(msg)
+	}
+	_, err := metaOutputPath(options.Output)
+	if err != nil {
+		msg := fmt.Sprintf("AZ Output: %s", err)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func letterScript(character rune) string {
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, character) {
+			return name
+		}
+	}
+	return ""
+}
+
+func initialLetter(title string, collator *titleCollator, loose *collate.Collator, nonLatin string) (string, string, int) {
+	title = strings.TrimSpace(collator.sortTitle(title))
+	if len(title) == 0 {
+		return AZ_OTHER_LETTER, AZ_OTHER_SLUG, 2
+	}
+	var character rune
+	for _, first := range title {
+		character = first
+		break
+	}
+	if !unicode.IsLetter(character) {
+		return AZ_OTHER_LETTER, AZ_OTHER_SLUG, 2
+	}
+	if unicode.Is(unicode.Latin, character) {
+		letter := string(unicode.ToUpper(character))
+		for _, base := range norm.NFD.String(letter) {
+			if string(base) != letter && loose.CompareString(letter, string(base)) == 0 {
+				letter = string(base)
+			}
+			break
+		}
+		return letter, strings.ToLower(letter), 0
+	}
+	script := letterScript(character)
+	if nonLatin == AZ_NON_LATIN_SCRIPT && len(script) > 0 {
+		return script, strings.ToLower(script), 1
+	}
+	return AZ_OTHER_LETTER, AZ_OTHER_SLUG, 2
+}
+
+func azLetterPath(options AZIndex, slug string) string {
+	base := strings.TrimSuffix(options.Output, ".html")
+	if path.Base(options.Output) == INDEX_FILE_NAME {
+		base = path.Dir(options.Output)
+	}
+	return path.Join(base, slug+".html")
+}
+
+func initialBuckets(configuration Configuration, links []Link, nonLatin string) []AZLetter {
+	collator := collatorFor(configuration)
+	locale, err := sortLocale(configuration)
+	if err != nil {
+		locale = language.Und
+	}
+	loose := collate.New(locale, collate.Loose)
+	positions := map[string]int{}
+	var letters []AZLetter
+	for _, link := range links {
+		letter, slug, rank := initialLetter(link.Title, collator, loose, nonLatin)
+		position, found := positions[letter]
+		if !found {
+			position = len(letters)
+			positions[letter] = position
+			letters = append(letters, AZLetter{Letter: letter, Slug: slug, Anchor: AZ_ANCHOR_PREFIX + slug, rank: rank})
+		}
+		letters[position].Links = append(letters[position].Links, link)
+	}
+	order := collator.order()
+	sort.SliceStable(letters, func(i, j int) bool {
+		if letters[i].rank != letters[j].rank {
+			return letters[i].rank < letters[j].rank
+		}
+		if letters[i].rank == 0 {
+			return order.compare(letters[i].Letter, letters[j].Letter) < 0
+		}
+		return letters[i].Letter < letters[j].Letter
+	})
+	return letters
+}
+
+func azLetters(configuration Configuration, links []Link) []AZLetter {
+	if configuration.AZ == nil {
+		return nil
+	}
+	options := azIndex(configuration)
+	collator := collatorFor(configuration)
+	letters := initialBuckets(configuration, links, options.NonLatin)
+	for index := range letters {
+		letters[index].Links, _ = sortLinks("Title", SORT_ASCENDING, letters[index].Links, collator)
+		if options.PerLetter {
+			letters[index].Url = outputUrl(azLetterPath(options, letters[index].Slug))
+		} else {
+			letters[index].Url = outputUrl(options.Output) + "#" + letters[index].Anchor
+		}
+	}
+	return letters
+}
+
+func azPages(configuration Configuration, letters []AZLetter) []GeneratedPage {
+	if configuration.AZ == nil {
+		return nil
+	}
+	options := azIndex(configuration)
+	var all []Link
+	for _, letter := range letters {
+		all = append(all, letter.Links...)
+	}
+	_, newest := dateRange(all)
+	pages := []GeneratedPage{{
+		Path:    options.Output,
+		Url:     outputUrl(options.Output),
+		Owner:   "the A-Z listing",
+		Date:    newest,
+		Sitemap: true,
+	}}
+	if options.PerLetter {
+		for _, letter := range letters {
+			_, newest := dateRange(letter.Links)
+			letterPath := azLetterPath(options, letter.Slug)
+			pages = append(pages, GeneratedPage{
+				Path:    letterPath,
+				Url:     outputUrl(letterPath),
+				Owner:   fmt.Sprintf("the A-Z listing of '%s'", letter.Letter),
+				Date:    newest,
+				Sitemap: true,
+			})
+		}
+	}
+	return pages
+}
+
+func azListing(configuration Configuration, site *Site, url string, links []Link) Index {
+	oldest, newest := dateRange(links)
+	return Index{
+		Links:   links,
+		Groups:  groupLinks(links, configuration.GroupOrder, configuration.DefaultGroup),
+		Site:    site,
+		Menu:    resolveMenu(configuration.Menu, url, site),
+		Recent:  recentLinks(links, configuration.RecentCount),
+		Total:   len(links),
+		Oldest:  oldest,
+		Newest:  newest,
+		Letters: site.letters,
+	}
+}
+
+func renderAZ(configuration Configuration, output OutputWriter, site *Site) error {
+	if configuration.AZ == nil {
+		return nil
+	}
+	options := azIndex(configuration)
+	templatePath := configuration.TemplateAZ
+	if len(templatePath) == 0 {
+		templatePath = configuration.TemplateIndex
+	}
+	var all []Link
+	for _, letter := range site.letters {
+		all = append(all, letter.Links...)
+	}
+	err := doIndex(output, options.Output, templatePath, azListing(configuration, site, outputUrl(options.Output), all), configuration)
+	if err != nil {
+		msg := fmt.Sprintf("A-Z listing: %s", err)
+		return errors.New(msg)
+	}
+	if !options.PerLetter {
+		return nil
+	}
+	for _, letter := range site.letters {
+		listing := azListing(configuration, site, letter.Url, letter.Links)
+		listing.Letter = letter.Letter
+		err = doIndex(output, azLetterPath(options, letter.Slug), templatePath, listing, configuration)
+		if err != nil {
+			msg := fmt.Sprintf("A-Z listing of '%s': %s", letter.Letter, err)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+const BENCH_SEED = 42
+const BENCH_PAGE_TEMPLATE = "<html><head><title>{{.Title}}</title></head><body><h1>{{.Title}}</h1><p>{{.Date}}</p>{{range .Authors}}<p>{{.Name}}</p>{{end}}{{.Content}}</body></html>\n"
+const BENCH_INDEX_TEMPLATE = "<html><body>{{range .Links}}<a href=\"{{.Url}}\">{{.Title}} {{.Date}}</a>{{end}}</body></html>\n"
+
+var BENCH_WORDS = strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua diam vel quam elementum pulvinar")
+var BENCH_CATEGORIES = []string{"Tutorials", "Releases", "Notes", ""}
+
+func benchSentence(random *rand.Rand, words int) string {
+	parts := make([]string, words)
+	for index := range parts {
+		parts[index] = BENCH_WORDS[random.Intn(len(BENCH_WORDS))]
+	}
+	sentence := strings.Join(parts, " ")
+	return strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+}
+
+func benchDocument(random *rand.Rand, number int, paragraphs int) string {
+	var builder strings.Builder
+	date := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, number)
+	fmt.Fprintf(&builder, "%s{\n", META_BLOCK_START)
+	fmt.Fprintf(&builder, "    \"Title\": \"%s\",\n", strings.TrimSuffix(benchSentence(random, 6), "."))
+	fmt.Fprintf(&builder, "    \"Date\": \"%s\",\n", date.Format(time.RFC3339))
+	fmt.Fprintf(&builder, "    \"Category\": \"%s\",\n", BENCH_CATEGORIES[number%len(BENCH_CATEGORIES)])
+	fmt.Fprintf(&builder, "    \"Authors\": [{\"Name\": \"Author %d\", \"Mail\": \"author%d@domain.com\"}]\n", number%7, number%7)
+	fmt.Fprintf(&builder, "}\n%s", META_BLOCK_END)
+	fmt.Fprintf(&builder, "**Abstract**\n\n*%s*\n\n", benchSentence(random, 40))
+	for index := 0; index < paragraphs; index++ {
+		switch index % 5 {
+		case 0:
+			fmt.Fprintf(&builder, "## %s\n\n", strings.TrimSuffix(benchSentence(random, 3), "."))
+		case 2:
+			fmt.Fprintf(&builder, "- %s\n- %s\n- [%s](https://domain.com/%d)\n\n", benchSentence(random, 5), benchSentence(random, 5), benchSentence(random, 2), index)
+		case 4:
+			fmt.Fprintf(&builder, "```go\nfunc item%d() int {\n\treturn %d\n}\n```\n\n", index, index)
+		}
+		fmt.Fprintf(&builder, "%s\n\n", benchSentence(random, 60+random.Intn(60)))
+	}
+	return builder.String()
+}
+
+func benchChangelog(random *rand.Rand, size int) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s{\n    \"Title\": \"Changelog\",\n    \"Date\": \"2016-01-01T00:00:00Z\"\n}\n%s", META_BLOCK_START, META_BLOCK_END)
+	for release := 0; builder.Len() < size; release++ {
+		fmt.Fprintf(&builder, "## Release 1.%d.0\n\n", release)
+		fmt.Fprintf(&builder, "%s\n\n", benchSentence(random, 80))
+		fmt.Fprintf(&builder, "```\n$ upgrade --to 1.%d.0\n```\n\n", release)
+		fmt.Fprintf(&builder, "%s\n\n", benchSentence(random, 120))
+	}
+	return builder.String()
+}
+
+func generateSite(path string, count int, paragraphs int) error {
+	var err error
+	random := rand.New(rand.NewSource(BENCH_SEED))
+	err = os.MkdirAll(path, 0755)
+	for number := 0; err == nil && number < count; number++ {
+		fileName := fmt.Sprintf("%05d_post%s", number, MARKDOWN_FILE_ENDING)
+		document := benchDocument(random, number, paragraphs)
+		err = ioutil.WriteFile(filepath.Join(path, fileName), []byte(document), 0644)
+	}
+	return err
+}
+
+func benchSite(b *testing.B, count int, paragraphs int) (Configuration, func()) {
+	directory, err := ioutil.TempDir("", "renderer-bench-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	configuration := Configuration{
+		Input:         filepath.Join(directory, "content"),
+		Output:        filepath.Join(directory, "public"),
+		TemplatePage:  filepath.Join(directory, "page.html"),
+		TemplateIndex: filepath.Join(directory, "index.html"),
+	}
+	err = generateSite(configuration.Input, count, paragraphs)
+	if err == nil {
+		err = os.MkdirAll(configuration.Output, 0755)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(configuration.TemplatePage, []byte(BENCH_PAGE_TEMPLATE), 0644)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(configuration.TemplateIndex, []byte(BENCH_INDEX_TEMPLATE), 0644)
+	}
+	if err != nil {
+		os.RemoveAll(directory)
+		b.Fatal(err)
+	}
+	return configuration, func() { os.RemoveAll(directory) }
+}
+
+func BenchmarkRenderFiles1000(b *testing.B) {
+	configuration, cleanup := benchSite(b, 1000, 20)
+	defer cleanup()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for run := 0; run < b.N; run++ {
+		err := NewBuilder(configuration).Build()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderMarkdownLargeDoc(b *testing.B) {
+	random := rand.New(rand.NewSource(BENCH_SEED))
+	document := benchDocument(random, 0, 400)
+	_, contentStart, err := getMetaBlock([]byte(document), Configuration{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	body := []byte(document[contentStart:])
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for run := 0; run < b.N; run++ {
+		renderMarkdown(body, Configuration{}, nil, "")
+	}
+}
+
+func BenchmarkRenderFileLarge(b *testing.B) {
+	random := rand.New(rand.NewSource(BENCH_SEED))
+	file, err := ioutil.TempFile("", "renderer-bench-*.md")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	document := benchChangelog(random, 8<<20)
+	_, err = file.WriteString(document)
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(document)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for run := 0; run < b.N; run++ {
+		_, err = renderFile(file.Name(), Configuration{}, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTemplateExecute(b *testing.B) {
+	random := rand.New(rand.NewSource(BENCH_SEED))
+	templateObj := template.Must(template.New("page").Parse(BENCH_PAGE_TEMPLATE))
+	page := Page{
+		Title:   "Benchmark",
+		Date:    "2016-01-01",
+		Authors: []Author{{Name: "Author"}},
+		Content: renderMarkdown([]byte(benchDocument(random, 0, 50)), Configuration{}, nil, ""),
+	}
+	var buffer bytes.Buffer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for run := 0; run < b.N; run++ {
+		buffer.Reset()
+		err := templateObj.Execute(&buffer, page)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package renderer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const OUTPUT_MODE_SITE = "site"
+const OUTPUT_MODE_BOOK = "book"
+const BOOK_FILE_NAME = "book.html"
+const BOOK_TOC_CLASS = "book-toc"
+
+type BookSection struct {
+	Page
+	Anchor string
+}
+
+type Book struct {
+	Sections []BookSection
+	Toc      htmlTemplate.HTML
+	Site     *Site
+}
+
+func checkOutputMode(configuration Configuration) error {
+	switch configuration.OutputMode {
+	case "", OUTPUT_MODE_SITE:
+		return nil
+	case OUTPUT_MODE_BOOK:
+		if len(configuration.TemplateBook) > 0 {
+			return CheckPathError(configuration.TemplateBook)
+		}
+		return nil
+	}
+	msg := fmt.Sprintf("output mode must be '%s' or '%s', not '%s'", OUTPUT_MODE_SITE, OUTPUT_MODE_BOOK, configuration.OutputMode)
+	return errors.New(msg)
+}
+
+func bookAnchors(sources []Source) map[string]string {
+	anchors := map[string]string{}
+	registry := newIdRegistry()
+	for _, source := range sources {
+		if source.Expired {
+			continue
+		}
+		anchor := Slugify(strings.TrimSuffix(source.Name, MARKDOWN_FILE_ENDING))
+		if len(anchor) == 0 {
+			anchor = "section"
+		}
+		anchor = registry.allocate(anchor)
+		anchors[source.OutputPath] = anchor
+		anchors[source.Name] = anchor
+	}
+	return anchors
+}
+
+func bookPageAnchor(anchors map[string]string, target string) (string, bool) {
+	if len(path.Ext(target)) == 0 {
+		anchor, found := anchors[target+".html"]
+		if !found {
+			anchor, found = anchors[path.Join(target, INDEX_FILE_NAME)]
+		}
+		return anchor, found
+	}
+	anchor, found := anchors[target]
+	return anchor, found
+}
+
+func inlineImage(configuration Configuration, target string) (string, bool) {
+	for _, root := range []string{configuration.Input, configuration.Output} {
+		data, err := ioutil.ReadFile(diskPath(root, target))
+		if err == nil {
+			mediaType := mime.TypeByExtension(path.Ext(target))
+			if len(mediaType) == 0 {
+				mediaType = "application/octet-stream"
+			}
+			return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+		}
+	}
+	return "", false
+}
+
+func bookDestination(configuration Configuration, anchors map[string]string, source Source) func(string, bool) string {
+	prefix := anchors[source.OutputPath] + ":"
+	return func(destination string, image bool) string {
+		if strings.HasPrefix(destination, "#") {
+			return "#" + prefix + destination[1:]
+		}
+		target, internal := referenceTarget(source.OutputPath, destination)
+		if !internal || strings.HasPrefix(target, "../") {
+			return destination
+		}
+		if !image && isPageTarget(target) {
+			anchor, found := bookPageAnchor(anchors, target)
+			if !found {
+				return destination
+			}
+			parsed, _ := url.Parse(destination)
+			if len(parsed.Fragment) > 0 {
+				return "#" + anchor + ":" + parsed.Fragment
+			}
+			return "#" + anchor
+		}
+		if image && configuration.BookInlineImages {
+			inlined, found := inlineImage(configuration, target)
+			if found {
+				return inlined
+			}
+		}
+		return target
+	}
+}
+
+func bookToc(sections []BookSection) htmlTemplate.HTML {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "<nav class=\"%s\">\n<ol>\n", BOOK_TOC_CLASS)
+	for _, section := range sections {
+		fmt.Fprintf(&builder, "<li><a href=\"#%s\">%s</a></li>\n", section.Anchor, section.TitleHTML)
+	}
+	builder.WriteString("</ol>\n</nav>\n")
+	return htmlTemplate.HTML(builder.String())
+}
+
+func renderBookSection(builder *Builder, anchors map[string]string, source Source, site *Site) (BookSection, error) {
+	configuration := builder.Configuration
+	page := source.Page
+	text, line, err := sourceText(source, configuration)
+	anchor := anchors[source.OutputPath]
+	if err == nil && !isPrerendered(source) {
+		engine := newMarkdownEngine(configuration, site.abbreviations)
+		engine.idPrefix = anchor + ":"
+		engine.destination = bookDestination(configuration, anchors, source)
+		page.Content, _, err = engine.renderPage(text, configuration, anchor, line)
+	}
+	if err == nil {
+		if configuration.IncludeRawSource {
+			page.RawMarkdown = string(text)
+		}
+		page.Site = site
+		err = builder.runAfterRender(&page)
+	}
+	return BookSection{Page: page, Anchor: anchor}, err
+}
+
+func renderBook(builder *Builder, output OutputWriter, sources []Source, site *Site) error {
+	var sections []BookSection
+	configuration := builder.Configuration
+	anchors := bookAnchors(sources)
+	for _, source := range sources {
+		if source.Expired {
+			continue
+		}
+		log.Print("processing: ", source.Path)
+		section, err := renderBookSection(builder, anchors, source, site)
+		if err != nil {
+			msg := fmt.Sprintf("page render error: %s: %s", source.Path, err)
+			return errors.New(msg)
+		}
+		sections = append(sections, section)
+		builder.Summary.Rendered++
+	}
+	book := Book{Sections: sections, Toc: bookToc(sections), Site: site}
+	var err error
+	if len(configuration.TemplateBook) > 0 {
+		err = writeHtml(output, BOOK_FILE_NAME, configuration.TemplateBook, book, site, configuration)
+	} else {
+		buffer := getBuffer()
+		defer putBuffer(buffer)
+		buffer.WriteString(string(book.Toc))
+		for _, section := range sections {
+			fmt.Fprintf(buffer, "<section id=\"%s\">\n", section.Anchor)
+			err = executeTemplate(buffer, BOOK_FILE_NAME, configuration.TemplatePage, section.Page, site, configuration)
+			if err != nil {
+				break
+			}
+			buffer.WriteString("\n</section>\n")
+		}
+		if err == nil {
+			html := buffer.Bytes()
+			if configuration.GeneratorComment {
+				html = generatorComment(html, site)
+			}
+			err = writeFile(output, BOOK_FILE_NAME, html)
+		}
+	}
+	if err != nil {
+		msg := fmt.Sprintf("book render error: %s", err)
+		err = errors.New(msg)
+	}
+	return err
+}
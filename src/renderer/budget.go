@@ -0,0 +1,153 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+const DEFAULT_MAX_IN_FLIGHT_BYTES = 256 << 20
+const DEFAULT_PAGE_WORKERS = 1
+
+type inFlightBudget interface {
+	acquire(size int64) int64
+	release(size int64)
+}
+
+type byteBudget struct {
+	mutex    sync.Mutex
+	turn     *sync.Cond
+	capacity int64
+	used     int64
+	next     int64
+	serving  int64
+}
+
+type pageJob struct {
+	index int
+	size  int64
+}
+
+type pageRenders struct {
+	render   func(index int)
+	jobs     []pageJob
+	done     map[int]chan bool
+	finished chan int
+}
+
+func maxInFlightBytes(configuration Configuration) int64 {
+	if configuration.MaxInFlightBytes <= 0 {
+		return DEFAULT_MAX_IN_FLIGHT_BYTES
+	}
+	return configuration.MaxInFlightBytes
+}
+
+func validationWorkers(configuration Configuration) int {
+	if configuration.ValidationWorkers <= 0 {
+		return runtime.NumCPU()
+	}
+	return configuration.ValidationWorkers
+}
+
+func pageWorkers(configuration Configuration) int {
+	if configuration.PageWorkers <= 0 {
+		return DEFAULT_PAGE_WORKERS
+	}
+	return configuration.PageWorkers
+}
+
+func checkInFlight(configuration Configuration) error {
+	if configuration.MaxInFlightBytes < 0 {
+		msg := fmt.Sprintf("MaxInFlightBytes must not be negative, not %d", configuration.MaxInFlightBytes)
+		return errors.New(msg)
+	}
+	if configuration.ValidationWorkers < 0 {
+		msg := fmt.Sprintf("ValidationWorkers must not be negative, not %d", configuration.ValidationWorkers)
+		return errors.New(msg)
+	}
+	if configuration.PageWorkers < 0 {
+		msg := fmt.Sprintf("PageWorkers must not be negative, not %d", configuration.PageWorkers)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func newByteBudget(capacity int64) *byteBudget {
+	budget := &byteBudget{capacity: capacity}
+	budget.turn = sync.NewCond(&budget.mutex)
+	return budget
+}
+
+func (budget *byteBudget) acquire(size int64) int64 {
+	if size > budget.capacity {
+		size = budget.capacity
+	}
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	ticket := budget.next
+	budget.next++
+	for ticket != budget.serving || budget.used+size > budget.capacity {
+		budget.turn.Wait()
+	}
+	budget.serving++
+	budget.used += size
+	budget.turn.Broadcast()
+	return size
+}
+
+func (budget *byteBudget) release(size int64) {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	budget.used -= size
+	budget.turn.Broadcast()
+}
+
+func startPageRenders(workers int, budget inFlightBudget, jobs []pageJob, render func(index int)) *pageRenders {
+	renders := &pageRenders{jobs: jobs, done: map[int]chan bool{}, finished: make(chan int, len(jobs))}
+	for _, job := range jobs {
+		renders.done[job.index] = make(chan bool)
+	}
+	if workers <= 1 {
+		renders.render = render
+		return renders
+	}
+	slots := make(chan bool, workers)
+	go func() {
+		for _, job := range jobs {
+			slots <- true
+			reserved := budget.acquire(job.size)
+			go func(job pageJob) {
+				render(job.index)
+				budget.release(reserved)
+				<-slots
+				close(renders.done[job.index])
+				renders.finished <- job.index
+			}(job)
+		}
+	}()
+	return renders
+}
+
+func (renders *pageRenders) wait(index int) {
+	if renders.render != nil {
+		select {
+		case <-renders.done[index]:
+		default:
+			renders.render(index)
+			close(renders.done[index])
+		}
+		return
+	}
+	<-renders.done[index]
+}
+
+func (renders *pageRenders) next() int {
+	if renders.render == nil {
+		return <-renders.finished
+	}
+	job := renders.jobs[0]
+	renders.jobs = renders.jobs[1:]
+	renders.wait(job.index)
+	return job.index
+}
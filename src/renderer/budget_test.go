@@ -0,0 +1,220 @@
+package renderer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingBudget struct {
+	budget *byteBudget
+	mutex  sync.Mutex
+	events []string
+}
+
+func (budget *recordingBudget) record(format string, v ...interface{}) {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	budget.events = append(budget.events, fmt.Sprintf(format, v...))
+}
+
+func (budget *recordingBudget) acquire(size int64) int64 {
+	budget.record("wait %d", size)
+	reserved := budget.budget.acquire(size)
+	budget.record("hold %d", reserved)
+	return reserved
+}
+
+func (budget *recordingBudget) release(size int64) {
+	budget.record("free %d", size)
+	budget.budget.release(size)
+}
+
+func (budget *recordingBudget) log() []string {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	return append([]string(nil), budget.events...)
+}
+
+func (budget *recordingBudget) waitFor(t *testing.T, event string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, logged := range budget.log() {
+			if logged == event {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("budget events = %v, want %q", budget.log(), event)
+}
+
+func TestPageRendersWaitForBudget(t *testing.T) {
+	budget := &recordingBudget{budget: newByteBudget(100)}
+	jobs := []pageJob{{0, 30}, {1, 30}, {2, 1000}, {3, 30}}
+	started := make(chan int, len(jobs))
+	gates := make([]chan bool, len(jobs))
+	for index := range gates {
+		gates[index] = make(chan bool)
+	}
+	renders := startPageRenders(4, budget, jobs, func(index int) {
+		started <- index
+		<-gates[index]
+	})
+	first, second := <-started, <-started
+	if first+second != 1 || first*second != 0 {
+		t.Fatalf("pages %d and %d started first, want the two small pages 0 and 1", first, second)
+	}
+	budget.waitFor(t, "wait 1000")
+	close(gates[0])
+	renders.wait(0)
+	budget.waitFor(t, "free 30")
+	select {
+	case index := <-started:
+		t.Fatalf("page %d started while page 1 still holds 30 of the 100 bytes", index)
+	default:
+	}
+	close(gates[1])
+	renders.wait(1)
+	if index := <-started; index != 2 {
+		t.Fatalf("page %d started after the small pages, want the large page 2", index)
+	}
+	budget.waitFor(t, "wait 30")
+	select {
+	case index := <-started:
+		t.Fatalf("page %d started while the large page holds the whole budget", index)
+	default:
+	}
+	close(gates[2])
+	renders.wait(2)
+	if index := <-started; index != 3 {
+		t.Fatalf("page %d started last, want page 3", index)
+	}
+	close(gates[3])
+	renders.wait(3)
+	want := []string{"wait 30", "hold 30", "wait 30", "hold 30", "wait 1000", "free 30", "free 30", "hold 100", "wait 30", "free 100", "hold 30", "free 30"}
+	if got := budget.log(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("budget events = %v, want %v", got, want)
+	}
+}
+
+func TestPageRendersWithOneWorker(t *testing.T) {
+	budget := &recordingBudget{budget: newByteBudget(100)}
+	var order []int
+	renders := startPageRenders(1, budget, []pageJob{{0, 500}, {2, 10}}, func(index int) {
+		order = append(order, index)
+	})
+	if len(order) != 0 {
+		t.Fatalf("pages %v rendered before they were waited for", order)
+	}
+	renders.wait(0)
+	renders.wait(2)
+	if fmt.Sprint(order) != "[0 2]" || len(budget.log()) != 0 {
+		t.Errorf("rendered %v with budget events %v, want [0 2] in the calling goroutine", order, budget.log())
+	}
+}
+
+func TestPageRendersInCompletionOrder(t *testing.T) {
+	gates := []chan bool{make(chan bool), make(chan bool), make(chan bool)}
+	renders := startPageRenders(3, newByteBudget(100), []pageJob{{0, 10}, {1, 10}, {2, 10}}, func(index int) {
+		<-gates[index]
+	})
+	var order []int
+	for _, index := range []int{2, 0, 1} {
+		close(gates[index])
+		order = append(order, renders.next())
+	}
+	if fmt.Sprint(order) != "[2 0 1]" {
+		t.Errorf("pages finished in %v, want [2 0 1]", order)
+	}
+	inline := startPageRenders(1, newByteBudget(100), []pageJob{{0, 10}, {3, 10}}, func(index int) {
+		order = append(order, index)
+	})
+	order = nil
+	first := inline.next()
+	inline.wait(first)
+	if second := inline.next(); first != 0 || second != 3 || fmt.Sprint(order) != "[0 3]" {
+		t.Errorf("one worker finished %d then %d, rendering %v, want 0 then 3 rendered once each", first, second, order)
+	}
+}
+
+func TestParallelBuildMatchesSequential(t *testing.T) {
+	outputs := map[int]map[string]string{}
+	for _, workers := range []int{1, 4} {
+		site := newTestSite(t)
+		site.Configuration.PageWorkers = workers
+		site.Configuration.MaxInFlightBytes = 200
+		site.Configuration.Shortcodes = site.path("shortcodes")
+		site.writeFile(filepath.Join(site.Configuration.Shortcodes, "embed.html"), TEST_EMBED_SHORTCODE)
+		site.page("shared.md", `{"Title": "Shared"}`, "shared text\n")
+		site.page("large.md", `{"Title": "Large"}`, strings.Repeat("a long line of text\n", 100))
+		for index := 0; index < 12; index++ {
+			name := fmt.Sprintf("page%02d.md", index)
+			site.page(name, fmt.Sprintf(`{"Title": "Page %d"}`, index), "page includes {{< embed \"shared.md\" >}}\n")
+		}
+		site.mustBuild()
+		files := map[string]string{}
+		infos, err := ioutil.ReadDir(site.Configuration.Output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, info := range infos {
+			if info.Name() != CACHE_FILE_NAME {
+				files[info.Name()] = site.read(info.Name())
+			}
+		}
+		if !strings.Contains(files["page07.html"], "shared text") {
+			t.Errorf("%d workers: page07.html = %q, want the embedded page", workers, files["page07.html"])
+		}
+		outputs[workers] = files
+	}
+	if len(outputs[1]) != len(outputs[4]) {
+		t.Errorf("1 worker wrote %d files, 4 workers wrote %d", len(outputs[1]), len(outputs[4]))
+	}
+	for name, content := range outputs[1] {
+		if outputs[4][name] != content {
+			t.Errorf("%s differs between 1 and 4 workers:\n%s\n%s", name, content, outputs[4][name])
+		}
+	}
+}
+
+func TestParallelEmbedChains(t *testing.T) {
+	site := &Site{embeds: newEmbedTable(nil, Configuration{})}
+	first, second := site.worker(), site.worker()
+	if err := first.embeds.enter("a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.embeds.enter("a.md"); err != nil {
+		t.Errorf("a second worker rendering a.md: %v", err)
+	}
+	if err := first.embeds.enter("a.md"); err == nil || !strings.Contains(err.Error(), "a.md -> a.md") {
+		t.Errorf("a.md embedding itself = %v, want a cycle error", err)
+	}
+	first.embeds.use("a.md", "b.md")
+	if uses := second.embeds.dependencies(Source{Name: "a.md"}); len(uses) != 1 {
+		t.Errorf("dependencies seen by another worker = %v, want b.md", uses)
+	}
+}
+
+func TestCheckInFlight(t *testing.T) {
+	tests := []struct {
+		configuration Configuration
+		err           string
+	}{
+		{Configuration{}, ""},
+		{Configuration{MaxInFlightBytes: 1, ValidationWorkers: 2, PageWorkers: 3}, ""},
+		{Configuration{MaxInFlightBytes: -1}, "MaxInFlightBytes must not be negative"},
+		{Configuration{ValidationWorkers: -1}, "ValidationWorkers must not be negative"},
+		{Configuration{PageWorkers: -1}, "PageWorkers must not be negative"},
+	}
+	for _, test := range tests {
+		err := checkInFlight(test.configuration)
+		if (err == nil) != (len(test.err) == 0) || (err != nil && !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("checkInFlight(%+v) = %v, want %q", test.configuration, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrSkipPage = errors.New("skip this page")
+
+type BeforePageHook func(path string, meta *MetaBlock) error
+type AfterRenderHook func(page *Page) error
+type BeforeIndexHook func(index *Index) error
+
+type Builder struct {
+	Configuration Configuration
+	Output        OutputSink
+	Only          []string
+	DryRun        bool
+	NoCache       bool
+	Clean         bool
+	ConfirmClean  bool
+	Check         bool
+	Validate      bool
+	ValidateAll   bool
+	OrderedLogs   bool
+	Debug         bool
+	Summary       Summary
+	Now           func() time.Time
+
+	PreviousManifest Manifest
+	Manifest         Manifest
+
+	beforePage  []BeforePageHook
+	afterRender []AfterRenderHook
+	beforeIndex []BeforeIndexHook
+	lock        buildLock
+	mutex       sync.Mutex
+	hooks       sync.Mutex
+	status      statusBoard
+	failures    []*FileError
+	findings    []Finding
+	warnings    *warningLog
+	excluded    []Source
+	stats       *ContentStats
+	extraPages  []PageSource
+}
+
+func NewBuilder(configuration Configuration) *Builder {
+	return &Builder{
+		Configuration: configuration,
+		Output:        NewOutputWriter(configuration.Output),
+		Now:           time.Now,
+	}
+}
+
+func (builder *Builder) WithOutputSink(sink OutputSink) *Builder {
+	builder.Output = sink
+	return builder
+}
+
+func (builder *Builder) now() (time.Time, error) {
+	clock := builder.Now
+	if clock == nil {
+		clock = time.Now
+	}
+	now := clock()
+	location, err := loadLocation(builder.Configuration)
+	if err == nil && location != nil {
+		now = now.In(location)
+	}
+	return now, err
+}
+
+func (builder *Builder) BeforePage(hook BeforePageHook) {
+	builder.beforePage = append(builder.beforePage, hook)
+}
+
+func (builder *Builder) AfterRender(hook AfterRenderHook) {
+	builder.afterRender = append(builder.afterRender, hook)
+}
+
+func (builder *Builder) BeforeIndex(hook BeforeIndexHook) {
+	builder.beforeIndex = append(builder.beforeIndex, hook)
+}
+
+func hookError(name string, number int, err error) error {
+	if err == ErrSkipPage {
+		return err
+	}
+	msg := fmt.Sprintf("%s hook #%d: %s", name, number+1, err)
+	return errors.New(msg)
+}
+
+func (builder *Builder) runBeforePage(path string, meta *MetaBlock) error {
+	for number, hook := range builder.beforePage {
+		err := hook(path, meta)
+		if err != nil {
+			return hookError("BeforePage", number, err)
+		}
+	}
+	return nil
+}
+
+func (builder *Builder) runAfterRender(page *Page) error {
+	builder.hooks.Lock()
+	defer builder.hooks.Unlock()
+	for number, hook := range builder.afterRender {
+		err := hook(page)
+		if err != nil {
+			return hookError("AfterRender", number, err)
+		}
+	}
+	return nil
+}
+
+func (builder *Builder) runBeforeIndex(index *Index) error {
+	for number, hook := range builder.beforeIndex {
+		err := hook(index)
+		if err != nil {
+			return hookError("BeforeIndex", number, err)
+		}
+	}
+	return nil
+}
+
+func (builder *Builder) ManifestDiff() ManifestDiff {
+	return DiffManifests(builder.PreviousManifest, builder.Manifest)
+}
+
+func (builder *Builder) Build() error {
+	builder.status.start(time.Now())
+	builder.Summary = Summary{}
+	builder.failures = nil
+	builder.findings = nil
+	builder.warnings = newWarningLog(builder.Configuration)
+	builder.excluded = nil
+	builder.stats = nil
+	err := builder.lockOutput()
+	if err == nil {
+		err = builder.result(renderFiles(builder))
+		builder.warnings.flush()
+		statsErr := writeFailedStats(builder, err)
+		if statsErr != nil {
+			log.Print("content stats error: ", statsErr)
+		}
+		unlockErr := builder.Unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}
+	err = builder.result(err)
+	builder.status.finish(time.Now(), err)
+	return err
+}
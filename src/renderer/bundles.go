@@ -0,0 +1,119 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const BUNDLE_INDEX_NAME = "index.md"
+const BUNDLE_MARKDOWN_IGNORE = "ignore"
+const BUNDLE_MARKDOWN_RESOURCE = "resource"
+
+type Bundles struct {
+	Markdown string
+}
+
+func checkBundles(configuration Configuration) error {
+	bundles := configuration.Bundles
+	if bundles == nil {
+		return nil
+	}
+	if bundles.Markdown != "" && bundles.Markdown != BUNDLE_MARKDOWN_IGNORE && bundles.Markdown != BUNDLE_MARKDOWN_RESOURCE {
+		msg := fmt.Sprintf("Bundles Markdown must be '%s' or '%s', not '%s'", BUNDLE_MARKDOWN_IGNORE, BUNDLE_MARKDOWN_RESOURCE, bundles.Markdown)
+		return errors.New(msg)
+	}
+	if !configuration.Recursive {
+		return errors.New("Bundles needs Recursive, a bundle is a directory inside Input")
+	}
+	return nil
+}
+
+func isBundleDirectory(configuration Configuration, directory string) bool {
+	if configuration.Bundles == nil || len(directory) == 0 {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(configuration.Input, filepath.FromSlash(directory), BUNDLE_INDEX_NAME))
+	return err == nil && info.Mode().IsRegular()
+}
+
+func bundleDirectory(configuration Configuration, name string) (string, bool) {
+	if configuration.Bundles == nil || path.Base(name) != BUNDLE_INDEX_NAME || path.Dir(name) == "." {
+		return "", false
+	}
+	return path.Dir(name), true
+}
+
+func enclosingBundle(configuration Configuration, name string) (string, bool) {
+	for directory := path.Dir(name); directory != "." && directory != "/"; directory = path.Dir(directory) {
+		if isBundleDirectory(configuration, directory) {
+			return directory, true
+		}
+	}
+	return "", false
+}
+
+func pageOutputUrl(configuration Configuration, name string, outputPath string) string {
+	if _, bundled := bundleDirectory(configuration, name); bundled && path.Base(outputPath) == INDEX_FILE_NAME {
+		return outputUrl(path.Dir(outputPath)) + "/"
+	}
+	return outputUrl(outputPath)
+}
+
+func listBundle(configuration Configuration, bundle string, directory string) ([]string, error) {
+	var names []string
+	inputFiles, err := ioutil.ReadDir(filepath.Join(configuration.Input, filepath.FromSlash(directory)))
+	for index := 0; err == nil && index < len(inputFiles); index++ {
+		inputFile := inputFiles[index]
+		name := path.Join(directory, inputFile.Name())
+		if inputFile.IsDir() {
+			if isExcluded(configuration, name, true) {
+				continue
+			}
+			if isBundleDirectory(configuration, name) {
+				msg := fmt.Sprintf("bundle %s contains the bundle %s, bundles cannot be nested", bundle, name)
+				return names, errors.New(msg)
+			}
+			var nested []string
+			nested, err = listBundle(configuration, bundle, name)
+			names = append(names, nested...)
+			continue
+		}
+		if !inputFile.Mode().IsRegular() || name == path.Join(bundle, BUNDLE_INDEX_NAME) || matchAny(configuration.Exclude, name, false) {
+			continue
+		}
+		if strings.HasSuffix(name, MARKDOWN_FILE_ENDING) && configuration.Bundles.Markdown != BUNDLE_MARKDOWN_RESOURCE {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, err
+}
+
+func bundleAssets(configuration Configuration, sources []Source) ([]assetFile, error) {
+	var assets []assetFile
+	for _, source := range sources {
+		bundle, bundled := bundleDirectory(configuration, source.Name)
+		if !bundled {
+			continue
+		}
+		names, err := listBundle(configuration, bundle, bundle)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			output := normalizeName(path.Join(path.Dir(source.OutputPath), strings.TrimPrefix(name, bundle+"/")))
+			err = checkOutputPath(configuration, output)
+			if err != nil {
+				msg := fmt.Sprintf("bundle %s: %s", bundle, err)
+				return nil, errors.New(msg)
+			}
+			assets = append(assets, assetFile{name: name, output: output})
+		}
+	}
+	return assets, nil
+}
@@ -0,0 +1,198 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const CACHE_FILE_NAME = ".mdcache.json"
+const CACHE_VERSION = 19
+
+type BuildCache struct {
+	Version     int
+	Fingerprint string
+	Sources     []Source
+	Images      map[string]ImageSet `json:",omitempty"`
+	IconsHash   string              `json:",omitempty"`
+}
+
+func cachePath(configuration Configuration) string {
+	path := configuration.CacheFile
+	if len(path) == 0 && len(configuration.Output) > 0 && !IsArchivePath(configuration.Output) {
+		path = filepath.Join(configuration.Output, CACHE_FILE_NAME)
+	}
+	return path
+}
+
+func loadCache(path string) (BuildCache, error) {
+	var cache BuildCache
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &cache)
+	}
+	if err == nil && cache.Version != CACHE_VERSION {
+		msg := fmt.Sprintf("build cache version %d does not match %d", cache.Version, CACHE_VERSION)
+		err = errors.New(msg)
+	}
+	return cache, err
+}
+
+func saveCache(path string, cache BuildCache) error {
+	var file *os.File
+	cache.Version = CACHE_VERSION
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err == nil {
+		file, err = ioutil.TempFile(filepath.Dir(path), ".mdcache-*")
+	}
+	if err == nil {
+		tempPath := file.Name()
+		_, err = file.Write(data)
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tempPath, 0644)
+		}
+		if err == nil {
+			err = os.Rename(tempPath, path)
+		}
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}
+	return err
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func buildFingerprint(configuration Configuration) (string, error) {
+	var template []byte
+	var shortcodes []byte
+	var abbreviations []byte
+	var inlined []byte
+	settings, err := json.Marshal(configuration)
+	if err == nil {
+		template, err = ioutil.ReadFile(configuration.TemplatePage)
+	}
+	for _, rule := range configuration.TemplateRules {
+		var data []byte
+		if err == nil {
+			data, err = ioutil.ReadFile(rule.Template)
+			template = append(template, data...)
+		}
+	}
+	if err == nil {
+		shortcodes, err = shortcodeFingerprint(configuration)
+	}
+	if err == nil && len(configuration.Abbreviations) > 0 {
+		abbreviations, err = ioutil.ReadFile(configuration.Abbreviations)
+	}
+	if err == nil {
+		inlined, err = inlineFingerprint(configuration)
+	}
+	if err != nil {
+		return "", err
+	}
+	return hashContent(append(append(append(append(settings, template...), shortcodes...), abbreviations...), inlined...)), nil
+}
+
+func cachedSources(cache BuildCache) map[string]Source {
+	byName := map[string]Source{}
+	for _, source := range cache.Sources {
+		byName[source.Name] = source
+	}
+	return byName
+}
+
+func sameLinks(cached map[string]Source, sources []Source) bool {
+	if len(cached) != len(sources) {
+		return false
+	}
+	for _, source := range sources {
+		previous, found := cached[source.Name]
+		if !found || previous.OutputPath != source.OutputPath || previous.Expired != source.Expired || previous.Unlisted != source.Unlisted || previous.NoIndex != source.NoIndex || !reflect.DeepEqual(previous.Link, source.Link) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameEmbeds(source Source, sources map[string]Source) bool {
+	for name, hash := range source.Embeds {
+		embedded, found := sources[name]
+		if !found || embedded.Hash != hash {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeSources(cached []Source, collected []Source) []Source {
+	byName := map[string]Source{}
+	for _, source := range cached {
+		byName[source.Name] = source
+	}
+	for _, source := range collected {
+		byName[source.Name] = source
+	}
+	merged := make([]Source, 0, len(byName))
+	for _, source := range byName {
+		merged = append(merged, source)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Name < merged[j].Name
+	})
+	return merged
+}
+
+func resolvePageArgument(configuration Configuration, argument string) (string, error) {
+	var name string
+	var err error
+	path := argument
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configuration.Input, path)
+	}
+	absoluteInput, err := filepath.Abs(configuration.Input)
+	if err == nil {
+		path, err = filepath.Abs(path)
+	}
+	if err == nil {
+		name, err = filepath.Rel(absoluteInput, path)
+	}
+	if err == nil {
+		name = filepath.ToSlash(name)
+		if name == ".." || strings.HasPrefix(name, "../") {
+			msg := fmt.Sprintf("'%s' is outside of the input directory", argument)
+			err = errors.New(msg)
+		} else if !strings.HasSuffix(name, MARKDOWN_FILE_ENDING) {
+			msg := fmt.Sprintf("'%s' is not a markdown file", argument)
+			err = errors.New(msg)
+		} else if strings.Contains(name, "/") && !configuration.Recursive {
+			msg := fmt.Sprintf("'%s' is not directly inside the input directory", argument)
+			err = errors.New(msg)
+		} else if bundle, inside := enclosingBundle(configuration, name); inside && name != bundle+"/"+BUNDLE_INDEX_NAME {
+			msg := fmt.Sprintf("'%s' belongs to the bundle %s and is not a page", argument, bundle)
+			err = errors.New(msg)
+		} else if isExcludedPath(configuration, name) {
+			msg := fmt.Sprintf("'%s' is excluded by the Include/Exclude patterns", argument)
+			err = errors.New(msg)
+		} else if CheckPathError(path) != nil {
+			msg := fmt.Sprintf("'%s' does not exist", argument)
+			err = errors.New(msg)
+		}
+	}
+	return name, err
+}
@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const TEST_EMBED_TEMPLATE = "<title>{{.Title}}</title>{{with pageByPath \"b.md\"}}<embed>{{.Content}}</embed>{{end}}\n"
+const TEST_EMBED_SHORTCODE = "{{with pageByPath (index .Args 0)}}{{.Content}}{{end}}"
+
+func TestCacheReusesPagesWithEmbeds(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    string
+		body      string
+		want      string
+		unchanged int
+	}{
+		{"embedded page changes", "b.md", "second version of b\n", "second version of b", 2},
+		{"nested embedded page changes", "c.md", "second version of c\n", "second version of c", 1},
+		{"unrelated page changes", "d.md", "second version of d\n", "first c", 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			template := site.path("embed.html")
+			site.writeFile(template, TEST_EMBED_TEMPLATE)
+			site.Configuration.TemplateRules = []TemplateRule{{Pattern: "a.md", Template: template}}
+			site.Configuration.Shortcodes = site.path("shortcodes")
+			site.writeFile(filepath.Join(site.Configuration.Shortcodes, "embed.html"), TEST_EMBED_SHORTCODE)
+			site.page("a.md", `{"Title": "A"}`, "a\n")
+			site.page("b.md", `{"Title": "B"}`, "b includes {{< embed \"c.md\" >}}\n")
+			site.page("c.md", `{"Title": "C"}`, "first c\n")
+			site.page("d.md", `{"Title": "D"}`, "first d\n")
+			site.mustBuild()
+			if first := site.read("a.html"); !strings.Contains(first, "first c") {
+				t.Fatalf("a.html = %q, want the embedded content of b and c", first)
+			}
+			meta := map[string]string{"b.md": `{"Title": "B"}`, "c.md": `{"Title": "C"}`, "d.md": `{"Title": "D"}`}
+			site.page(test.change, meta[test.change], test.body)
+			builder := NewBuilder(site.Configuration)
+			err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second := site.read("a.html"); !strings.Contains(second, test.want) {
+				t.Errorf("a.html = %q, want %q", second, test.want)
+			}
+			if builder.Summary.Unchanged != test.unchanged {
+				t.Errorf("%d pages reused, want %d", builder.Summary.Unchanged, test.unchanged)
+			}
+		})
+	}
+}
+
+func TestSameEmbeds(t *testing.T) {
+	sources := map[string]Source{
+		"b.md": {Name: "b.md", Hash: "b1"},
+		"c.md": {Name: "c.md", Hash: "c1"},
+	}
+	tests := []struct {
+		name   string
+		embeds map[string]string
+		want   bool
+	}{
+		{"no embeds", nil, true},
+		{"same hashes", map[string]string{"b.md": "b1", "c.md": "c1"}, true},
+		{"changed hash", map[string]string{"b.md": "b1", "c.md": "c0"}, false},
+		{"removed page", map[string]string{"e.md": "e1"}, false},
+	}
+	for _, test := range tests {
+		got := sameEmbeds(Source{Name: "a.md", Embeds: test.embeds}, sources)
+		if got != test.want {
+			t.Errorf("%s: sameEmbeds = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const CALENDAR_FILE_NAME = "calendar.json"
+const CALENDAR_BUCKET_DAY = "day"
+const CALENDAR_BUCKET_WEEK = "week"
+
+func checkCalendar(configuration Configuration) error {
+	switch configuration.Calendar {
+	case "", CALENDAR_BUCKET_DAY, CALENDAR_BUCKET_WEEK:
+		return nil
+	}
+	msg := fmt.Sprintf("calendar must be '%s' or '%s', not '%s'", CALENDAR_BUCKET_DAY, CALENDAR_BUCKET_WEEK, configuration.Calendar)
+	return errors.New(msg)
+}
+
+func calendarBucket(date time.Time, bucket string) string {
+	if bucket == CALENDAR_BUCKET_WEEK {
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return date.Format(DATE_DISPLAY_FORMAT)
+}
+
+func buildCalendar(links []Link, bucket string) map[string]int {
+	calendar := map[string]int{}
+	for _, link := range links {
+		if len(link.Date) == 0 || link.Date == ZERO_DATE {
+			continue
+		}
+		date, err := time.Parse(DATE_DISPLAY_FORMAT, link.Date)
+		if err == nil {
+			calendar[calendarBucket(date, bucket)]++
+		}
+	}
+	return calendar
+}
+
+func renderCalendar(calendar map[string]int) ([]byte, error) {
+	data, err := json.MarshalIndent(calendar, "", "  ")
+	return append(data, '\n'), err
+}
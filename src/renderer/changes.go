@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const CHANGE_NEW = "new"
+const CHANGE_CHANGED = "changed"
+const CHANGE_UNCHANGED = "unchanged"
+
+type PageChange struct {
+	Link
+	Change string
+}
+
+func changeHash(metaBlock MetaBlock, text []byte, ignored []string) string {
+	var fields map[string]interface{}
+	data := metaBlock.raw
+	var err error
+	if len(data) == 0 {
+		data, err = json.Marshal(metaBlock)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &fields)
+	}
+	if err != nil {
+		return hashContent(text)
+	}
+	for key := range fields {
+		for _, name := range ignored {
+			if strings.EqualFold(key, name) {
+				delete(fields, key)
+			}
+		}
+	}
+	data, _ = json.Marshal(fields)
+	return hashContent(append(append(data, '\n'), text...))
+}
+
+func pageChange(configuration Configuration, firstBuild bool, previous Source, found bool, hash string) string {
+	switch {
+	case firstBuild && !configuration.FirstBuildAsNew:
+		return CHANGE_UNCHANGED
+	case !found:
+		return CHANGE_NEW
+	case previous.ChangeHash != hash:
+		return CHANGE_CHANGED
+	}
+	return CHANGE_UNCHANGED
+}
+
+func stampUpdated(sources []Source, buildTime time.Time) {
+	for index := range sources {
+		if sources[index].Change == CHANGE_CHANGED {
+			sources[index].Updated = buildTime
+		}
+	}
+}
+
+func changedPages(sources []Source) []PageChange {
+	var changes []PageChange
+	for _, source := range sources {
+		if !isPublic(source) || (source.Change != CHANGE_NEW && source.Change != CHANGE_CHANGED) {
+			continue
+		}
+		changes = append(changes, PageChange{Link: source.Link, Change: source.Change})
+	}
+	return changes
+}
@@ -0,0 +1,125 @@
+package renderer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const TEST_CHANGES_TEMPLATE = "{{range .ChangedPages}}{{.Title}}={{.Change}}\n{{end}}"
+
+func TestChangedPages(t *testing.T) {
+	tests := []struct {
+		name       string
+		firstAsNew bool
+		ignored    []string
+		first      map[string]string
+		second     map[string]string
+		firstWant  string
+		secondWant string
+	}{
+		{
+			"first build is unchanged by default", false, nil,
+			map[string]string{"a.md": `{"Title": "A"}`}, nil,
+			"", "",
+		},
+		{
+			"first build as new", true, nil,
+			map[string]string{"a.md": `{"Title": "A"}`}, nil,
+			"A=new\n", "",
+		},
+		{
+			"new and changed pages", false, nil,
+			map[string]string{"a.md": `{"Title": "A"}`, "b.md": `{"Title": "B"}`},
+			map[string]string{"b.md": `{"Title": "B", "Tags": ["go"]}`, "c.md": `{"Title": "C"}`},
+			"", "B=changed\nC=new\n",
+		},
+		{
+			"ignored field", false, []string{"weight"},
+			map[string]string{"a.md": `{"Title": "A", "Weight": 1}`},
+			map[string]string{"a.md": `{"Title": "A", "Weight": 2}`},
+			"", "",
+		},
+		{
+			"field that is not ignored", false, nil,
+			map[string]string{"a.md": `{"Title": "A", "Weight": 1}`},
+			map[string]string{"a.md": `{"Title": "A", "Weight": 2}`},
+			"", "A=changed\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.FirstBuildAsNew = test.firstAsNew
+			site.Configuration.ChangeIgnoreFields = test.ignored
+			site.writeFile(site.path("changes.txt"), TEST_CHANGES_TEMPLATE)
+			site.Configuration.ExtraOutputs = []ExtraOutput{{Template: site.path("changes.txt"), Output: "changes.txt"}}
+			for name, meta := range test.first {
+				site.page(name, meta, "body\n")
+			}
+			site.mustBuild()
+			if changes := site.read("changes.txt"); changes != test.firstWant {
+				t.Errorf("first build changes = %q, want %q", changes, test.firstWant)
+			}
+			for name, meta := range test.second {
+				site.page(name, meta, "body\n")
+			}
+			site.mustBuild()
+			if changes := site.read("changes.txt"); changes != test.secondWant {
+				t.Errorf("second build changes = %q, want %q", changes, test.secondWant)
+			}
+		})
+	}
+}
+
+func TestFeedDateModified(t *testing.T) {
+	tests := []struct {
+		name     string
+		modified bool
+		edits    []string
+		want     string
+	}{
+		{"not enabled", false, []string{"edited\n"}, ""},
+		{"never changed", true, nil, ""},
+		{"changed", true, []string{"edited\n"}, "2024-06-02T00:00:00Z"},
+		{"changed, then kept", true, []string{"edited\n", ""}, "2024-06-02T00:00:00Z"},
+		{"changed twice", true, []string{"edited\n", "edited again\n"}, "2024-06-03T00:00:00Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.BaseURL = "https://example.com/"
+			site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}, DateModified: test.modified}
+			site.page("a.md", `{"Title": "A", "Date": "2024-05-01"}`, "body\n")
+			day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+			build := func() {
+				builder := NewBuilder(site.Configuration)
+				builder.Now = func() time.Time { return day }
+				err := builder.Build()
+				if err != nil {
+					t.Fatal(err)
+				}
+				day = day.AddDate(0, 0, 1)
+			}
+			build()
+			for _, body := range test.edits {
+				if len(body) > 0 {
+					site.page("a.md", `{"Title": "A", "Date": "2024-05-01"}`, body)
+				}
+				build()
+			}
+			var feed jsonFeed
+			err := json.Unmarshal([]byte(site.read(JSON_FEED_FILE_NAME)), &feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if feed.Items[0].DateModified != test.want {
+				t.Errorf("date_modified = %q, want %q", feed.Items[0].DateModified, test.want)
+			}
+			if published := feed.Items[0].DatePublished; !strings.HasPrefix(published, "2024-05-01") {
+				t.Errorf("date_published = %q, want the page date", published)
+			}
+		})
+	}
+}
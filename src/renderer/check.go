@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+)
+
+const FINDING_FORMAT_TEXT = "text"
+const FINDING_FORMAT_JSON = "json"
+
+var FINDING_LINE = regexp.MustCompile(`^line (\d+): `)
+var FINDING_META_LINE = regexp.MustCompile(`^meta block error: (\d+):\d+: `)
+
+type Finding struct {
+	Severity  string
+	Condition string
+	Path      string
+	Line      int `json:",omitempty"`
+	Message   string
+}
+
+type discardOutput struct{}
+
+func (output discardOutput) Create(path string) (io.WriteCloser, error) {
+	return discardEntry{}, nil
+}
+
+func (output discardOutput) MkdirAll(path string) error {
+	return nil
+}
+
+func (output discardOutput) Remove(path string) error {
+	return nil
+}
+
+func (output discardOutput) Finish() error {
+	return nil
+}
+
+func NewCheckBuilder(configuration Configuration) *Builder {
+	configuration.CopyAssets = false
+	configuration.Images = nil
+	configuration.Feed = nil
+	configuration.ContentStats = false
+	configuration.OutlineLint = true
+	configuration.MediaIndex = true
+	builder := NewBuilder(configuration)
+	builder.Output = discardOutput{}
+	builder.DryRun = true
+	builder.NoCache = true
+	builder.Check = true
+	builder.Validate = true
+	builder.ValidateAll = true
+	return builder
+}
+
+func newFinding(severity string, condition string, path string, message string) Finding {
+	finding := Finding{Severity: severity, Condition: condition, Path: path, Message: message}
+	if match := FINDING_LINE.FindStringSubmatch(message); match != nil {
+		finding.Line, _ = strconv.Atoi(match[1])
+		finding.Message = message[len(match[0]):]
+	} else if match := FINDING_META_LINE.FindStringSubmatch(message); match != nil {
+		finding.Line, _ = strconv.Atoi(match[1])
+	}
+	return finding
+}
+
+func (builder *Builder) Findings() []Finding {
+	return append([]Finding(nil), builder.findings...)
+}
+
+func (finding Finding) String() string {
+	location := finding.Path
+	if finding.Line > 0 {
+		location += ":" + strconv.Itoa(finding.Line)
+	}
+	if len(location) == 0 {
+		return finding.Severity + ": " + finding.Condition + ": " + finding.Message
+	}
+	return location + ": " + finding.Severity + ": " + finding.Condition + ": " + finding.Message
+}
@@ -0,0 +1,76 @@
+package renderer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCheckFindings(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.Policies = map[string]string{CONDITION_BROKEN_INTERNAL_LINK: POLICY_WARN}
+	site.page("a.md", `{"Title": "A"}`, "intro\n\n[gone](gone.md)\n")
+	site.write("b.md", "```json\n{\"Title\": \"B\",\n\"Date\": }\n```\nbody\n")
+	site.page("c.md", `{"Title": "C"}`, "fine\n")
+	builder := NewCheckBuilder(site.Configuration)
+	err := builder.Build()
+	if err == nil || !strings.Contains(err.Error(), site.path("content/b.md")) {
+		t.Errorf("check = %v, want the meta block error of b.md", err)
+	}
+	var got []string
+	for _, finding := range builder.Findings() {
+		got = append(got, strings.Replace(finding.String(), site.Configuration.Input, "content", -1))
+	}
+	want := []string{
+		"content/b.md:3: error: " + FAILURE_COLLECT + ": meta block error: 3:9: invalid character '}' looking for beginning of value: \"Date\": }",
+		"content/a.md: warn: " + CONDITION_BROKEN_INTERNAL_LINK + ": link to 'gone.md' does not resolve to a page",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for index := range want {
+		if !strings.HasPrefix(got[index], want[index]) {
+			t.Errorf("finding %d = %q, want %q", index, got[index], want[index])
+		}
+	}
+	data, err := json.Marshal(builder.Findings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded[0]["Line"] != float64(3) || decoded[0]["Severity"] != POLICY_ERROR || decoded[0]["Condition"] != FAILURE_COLLECT {
+		t.Errorf("json = %s, want the meta block error at line 3", data)
+	}
+	if files, err := ioutil.ReadDir(site.Configuration.Output); err != nil || len(files) > 0 {
+		t.Errorf("check wrote into the output directory: %v %v", files, err)
+	}
+}
+
+func TestCheckLeavesOutputUntouched(t *testing.T) {
+	site := newTestSite(t)
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	site.mustBuild()
+	before := map[string]string{}
+	for _, name := range []string{INDEX_FILE_NAME, "a.html", CACHE_FILE_NAME} {
+		before[name] = site.read(name)
+	}
+	site.page("a.md", `{"Title": "A"}`, "changed body\n")
+	site.page("b.md", `{"Title": "B"}`, "body\n")
+	if err := NewCheckBuilder(site.Configuration).Build(); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range before {
+		if site.read(name) != content {
+			t.Errorf("check changed %s", name)
+		}
+	}
+	for _, name := range []string{"b.html", LOCK_FILE_NAME} {
+		if site.exists(name) {
+			t.Errorf("check wrote %s", name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+const DEFAULT_CLEAN_LIMIT = 50
+
+func cleanLimit(configuration Configuration) int {
+	if configuration.CleanLimit == 0 {
+		return DEFAULT_CLEAN_LIMIT
+	}
+	return configuration.CleanLimit
+}
+
+func checkCleanLimit(configuration Configuration) error {
+	if configuration.CleanLimit < 0 || configuration.CleanLimit > 100 {
+		msg := fmt.Sprintf("CleanLimit must be a percentage from 1 to 100, not %d", configuration.CleanLimit)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func cleanablePath(outputPath string) bool {
+	cleaned := path.Clean(outputPath)
+	return cleaned == outputPath && cleaned != "." && !path.IsAbs(cleaned) && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+func cleanOutput(builder *Builder, output *manifestOutput) error {
+	if !builder.Clean {
+		return nil
+	}
+	var removed []string
+	for _, outputPath := range DiffManifests(builder.PreviousManifest, output.manifest).Removed {
+		if cleanablePath(outputPath) {
+			removed = append(removed, outputPath)
+		} else {
+			builder.warn(WARNING_UNSAFE_FILE_NAME, MANIFEST_FILE_NAME, "not cleaning '%s' of the previous manifest, it is not a path inside the output", outputPath)
+		}
+	}
+	limit := cleanLimit(builder.Configuration)
+	total, counted := 0, 0
+	for _, entry := range builder.PreviousManifest.Files {
+		if !entry.Auxiliary {
+			total++
+		}
+	}
+	for _, outputPath := range removed {
+		if !builder.PreviousManifest.Files[outputPath].Auxiliary {
+			counted++
+		}
+	}
+	if !builder.ConfirmClean && counted*100 > total*limit {
+		for _, outputPath := range removed {
+			output.add(outputPath, builder.PreviousManifest.Files[outputPath])
+		}
+		msg := fmt.Sprintf("clean would remove %d of the %d files of the previous output, more than the CleanLimit of %d%%, run again with -yes to remove them", counted, total, limit)
+		builder.fail(FAILURE_BUILD, "", errors.New(msg))
+		return nil
+	}
+	for _, outputPath := range removed {
+		err := builder.Output.Remove(outputPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			msg := fmt.Sprintf("clean error: %s", err)
+			return errors.New(msg)
+		}
+		if !builder.DryRun {
+			log.Print("removing: ", outputPath)
+		}
+		builder.Summary.Cleaned = append(builder.Summary.Cleaned, outputPath)
+	}
+	return nil
+}
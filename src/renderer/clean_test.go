@@ -0,0 +1,188 @@
+package renderer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCleanOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		clean   bool
+		dryRun  bool
+		remove  []string
+		cleaned []string
+		kept    []string
+		gone    []string
+	}{
+		{"nothing changed", true, false, nil, nil, []string{"a.html", "b.html", "style.css"}, nil},
+		{"page removed without clean", false, false, []string{"b.md"}, nil, []string{"a.html", "b.html"}, nil},
+		{"page removed", true, false, []string{"b.md"}, []string{"b.html"}, []string{"a.html", "style.css"}, []string{"b.html"}},
+		{"asset removed", true, false, []string{"style.css"}, []string{"style.css"}, []string{"a.html", "b.html"}, []string{"style.css"}},
+		{"dry run", true, true, []string{"b.md"}, []string{"b.html"}, []string{"a.html", "b.html"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.CopyAssets = true
+			site.page("a.md", `{"Title": "A"}`, "body\n")
+			site.page("b.md", `{"Title": "B"}`, "body\n")
+			site.write("style.css", "body {}\n")
+			site.mustBuild()
+			for _, name := range test.remove {
+				site.remove(name)
+			}
+			builder := NewBuilder(site.Configuration)
+			builder.Clean = test.clean
+			var dryRun *dryRunOutput
+			if test.dryRun {
+				dryRun = &dryRunOutput{path: site.Configuration.Output}
+				builder.Output = dryRun
+				builder.DryRun = true
+			}
+			err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(builder.Summary.Cleaned, test.cleaned) {
+				t.Errorf("cleaned = %v, want %v", builder.Summary.Cleaned, test.cleaned)
+			}
+			for _, name := range test.kept {
+				if !site.exists(name) {
+					t.Errorf("%s was removed", name)
+				}
+			}
+			for _, name := range test.gone {
+				if site.exists(name) {
+					t.Errorf("%s was not removed", name)
+				}
+			}
+			if dryRun != nil {
+				var want []string
+				for _, name := range test.cleaned {
+					want = append(want, filepath.Join(site.Configuration.Output, name))
+				}
+				if !reflect.DeepEqual(dryRun.removals, want) {
+					t.Errorf("planned removals = %v, want %v", dryRun.removals, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanKeepsPathsOutsideTheOutput(t *testing.T) {
+	site := newTestSite(t)
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	site.writeFile(site.path("outside.html"), "keep\n")
+	manifest := `{"Files": {"a.html": {"SHA256": "", "Size": 0}, "../outside.html": {"SHA256": "", "Size": 0}, "/etc/hostname": {"SHA256": "", "Size": 0}}}`
+	site.writeFile(filepath.Join(site.Configuration.Output, MANIFEST_FILE_NAME), manifest)
+	builder := NewBuilder(site.Configuration)
+	builder.Clean = true
+	err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builder.Summary.Cleaned) > 0 {
+		t.Errorf("cleaned = %v, want nothing", builder.Summary.Cleaned)
+	}
+	if data, err := ioutil.ReadFile(site.path("outside.html")); err != nil || string(data) != "keep\n" {
+		t.Errorf("outside.html = %q, %v, want it untouched", data, err)
+	}
+	if entries := builder.warnings.sorted(); len(entries) != 2 {
+		t.Errorf("warnings = %v, want one per path outside the output", entries)
+	}
+}
+
+func TestCleanLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		confirm bool
+		remove  []string
+		err     string
+	}{
+		{"below the default limit", 0, false, []string{"a.md"}, ""},
+		{"above the default limit", 0, false, []string{"a.md", "b.md", "c.md"}, "clean would remove 3 of the 5 files of the previous output, more than the CleanLimit of 50%"},
+		{"above the limit with -yes", 0, true, []string{"a.md", "b.md", "c.md"}, ""},
+		{"every page removed", 0, false, []string{"a.md", "b.md", "c.md", "d.md"}, "clean would remove 4 of the 5 files"},
+		{"above a lower limit", 20, false, []string{"a.md", "b.md"}, "more than the CleanLimit of 20%"},
+		{"everything allowed", 100, false, []string{"a.md", "b.md", "c.md", "d.md"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.CleanLimit = test.limit
+			for _, name := range []string{"a", "b", "c", "d"} {
+				site.page(name+".md", `{"Title": "`+name+`"}`, "body\n")
+			}
+			site.mustBuild()
+			for _, name := range test.remove {
+				site.remove(name)
+			}
+			builder := NewBuilder(site.Configuration)
+			builder.Clean = true
+			builder.ConfirmClean = test.confirm
+			err := builder.Build()
+			for _, name := range test.remove {
+				output := strings.TrimSuffix(name, ".md") + ".html"
+				if removed := !site.exists(output); removed != (len(test.err) == 0) {
+					t.Errorf("%s removed = %v", output, removed)
+				}
+			}
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("build error = %v, want %q", err, test.err)
+			}
+			builder = NewBuilder(site.Configuration)
+			builder.Clean = true
+			builder.ConfirmClean = true
+			err = builder.Build()
+			if err != nil || len(builder.Summary.Cleaned) != len(test.remove) {
+				t.Errorf("confirmed clean = %v, %v, want the %d refused files removed", builder.Summary.Cleaned, err, len(test.remove))
+			}
+		})
+	}
+}
+
+func TestCheckCleanLimit(t *testing.T) {
+	for limit, valid := range map[int]bool{-1: false, 0: true, 1: true, 50: true, 100: true, 101: false} {
+		if err := checkCleanLimit(Configuration{CleanLimit: limit}); (err == nil) != valid {
+			t.Errorf("checkCleanLimit(%d) = %v, want valid = %v", limit, err, valid)
+		}
+	}
+}
+
+func TestCleanLimitIgnoresListingPages(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.CleanLimit = 45
+	site.writeFile(site.Configuration.TemplateIndex, TEST_PAGED_INDEX_TEMPLATE)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		site.page(name+".md", `{"Title": "`+name+`"}`, "body\n")
+	}
+	site.mustBuild()
+	site.remove("c.md")
+	site.remove("d.md")
+	builder := NewBuilder(site.Configuration)
+	builder.Clean = true
+	err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c.html", "d.html", "page/3.html", "page/4.html"}
+	if !reflect.DeepEqual(builder.Summary.Cleaned, want) {
+		t.Errorf("cleaned = %v, want %v", builder.Summary.Cleaned, want)
+	}
+	for _, path := range want {
+		if site.exists(path) {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	markdownHtml "github.com/gomarkdown/markdown/html"
+)
+
+const DEFAULT_CODE_BLOCK_ELEMENT = "div"
+const DEFAULT_CODE_BLOCK_CLASS = "code-block"
+const CODE_BLOCK_RAW_SCRIPT = "script"
+const CODE_BLOCK_RAW_ATTRIBUTE = "attribute"
+const CODE_BLOCK_RAW_ATTRIBUTE_NAME = "data-code"
+
+var ELEMENT_NAME = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+type CodeBlockWrapper struct {
+	Element string
+	Class   string
+	RawCopy string
+}
+
+func checkCodeBlockWrapper(configuration Configuration) error {
+	wrapper := configuration.CodeBlockWrapper
+	if wrapper == nil {
+		return nil
+	}
+	if len(wrapper.Element) > 0 && !ELEMENT_NAME.MatchString(wrapper.Element) {
+		msg := fmt.Sprintf("code block wrapper element '%s' is not a plain lowercase element name", wrapper.Element)
+		return errors.New(msg)
+	}
+	switch wrapper.RawCopy {
+	case "", CODE_BLOCK_RAW_SCRIPT, CODE_BLOCK_RAW_ATTRIBUTE:
+		return nil
+	}
+	msg := fmt.Sprintf("code block raw copy must be '%s' or '%s', not '%s'", CODE_BLOCK_RAW_SCRIPT, CODE_BLOCK_RAW_ATTRIBUTE, wrapper.RawCopy)
+	return errors.New(msg)
+}
+
+func codeBlockLanguage(info []byte) string {
+	fields := strings.Fields(string(info))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func codeBlockHook(wrapper CodeBlockWrapper) nodeHook {
+	element := wrapper.Element
+	if len(element) == 0 {
+		element = DEFAULT_CODE_BLOCK_ELEMENT
+	}
+	class := wrapper.Class
+	if len(class) == 0 {
+		class = DEFAULT_CODE_BLOCK_CLASS
+	}
+	return func(renderer *markdownHtml.Renderer, writer io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		block, isCodeBlock := node.(*ast.CodeBlock)
+		if !isCodeBlock || !block.IsFenced {
+			return ast.GoToNext, false
+		}
+		var opening strings.Builder
+		fmt.Fprintf(&opening, `<%s class="%s"`, element, html.EscapeString(class))
+		language := codeBlockLanguage(block.Info)
+		if len(language) > 0 {
+			fmt.Fprintf(&opening, ` data-lang="%s"`, html.EscapeString(language))
+		}
+		if wrapper.RawCopy == CODE_BLOCK_RAW_ATTRIBUTE {
+			fmt.Fprintf(&opening, ` %s="%s"`, CODE_BLOCK_RAW_ATTRIBUTE_NAME, html.EscapeString(string(block.Literal)))
+		}
+		opening.WriteString(">")
+		renderer.CR(writer)
+		renderer.Outs(writer, opening.String())
+		renderer.CodeBlock(writer, block)
+		if wrapper.RawCopy == CODE_BLOCK_RAW_SCRIPT {
+			renderer.Outs(writer, `<script type="text/plain">`+html.EscapeString(string(block.Literal))+"</script>\n")
+		}
+		renderer.Outs(writer, "</"+element+">")
+		renderer.CR(writer)
+		return ast.GoToNext, true
+	}
+}
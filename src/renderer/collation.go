@@ -0,0 +1,121 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+var DEFAULT_SORT_ARTICLES = map[string][]string{
+	"en": {"The", "A", "An"},
+	"de": {"Der", "Die", "Das", "Ein", "Eine"},
+	"fr": {"Le", "La", "Les", "L'", "Un", "Une"},
+	"es": {"El", "La", "Los", "Las", "Un", "Una"},
+	"it": {"Il", "Lo", "La", "I", "Gli", "Le", "L'", "Un", "Una"},
+	"nl": {"De", "Het", "Een"},
+}
+
+type titleCollator struct {
+	mutex    sync.Mutex
+	collator *collate.Collator
+	buffer   collate.Buffer
+	articles []string
+}
+
+type titleOrder struct {
+	collator *titleCollator
+	keys     map[string][]byte
+}
+
+var titleCollators sync.Map
+
+func sortLocale(configuration Configuration) (language.Tag, error) {
+	if len(configuration.SortLocale) == 0 {
+		return language.Und, nil
+	}
+	return language.Parse(configuration.SortLocale)
+}
+
+func checkSortLocale(configuration Configuration) error {
+	_, err := sortLocale(configuration)
+	if err != nil {
+		msg := fmt.Sprintf("SortLocale '%s' is not a language tag: %s", configuration.SortLocale, err)
+		err = errors.New(msg)
+	}
+	return err
+}
+
+func sortArticles(configuration Configuration, locale language.Tag) []string {
+	if !configuration.SortIgnoreArticles {
+		return nil
+	}
+	if configuration.SortArticles != nil {
+		return configuration.SortArticles
+	}
+	base, _ := locale.Base()
+	return DEFAULT_SORT_ARTICLES[base.String()]
+}
+
+func collatorFor(configuration Configuration) *titleCollator {
+	locale, err := sortLocale(configuration)
+	if err != nil {
+		locale = language.Und
+	}
+	articles := sortArticles(configuration, locale)
+	key := locale.String() + "\x00" + strings.Join(articles, "\x00")
+	cached, found := titleCollators.Load(key)
+	if !found {
+		cached, _ = titleCollators.LoadOrStore(key, &titleCollator{
+			collator: collate.New(locale),
+			articles: articles,
+		})
+	}
+	return cached.(*titleCollator)
+}
+
+func (collator *titleCollator) sortTitle(title string) string {
+	title = strings.TrimLeftFunc(title, func(character rune) bool {
+		return unicode.IsSpace(character) || unicode.IsPunct(character) || unicode.IsSymbol(character)
+	})
+	for _, article := range collator.articles {
+		if len(title) <= len(article) || !strings.EqualFold(title[:len(article)], article) {
+			continue
+		}
+		rest := title[len(article):]
+		if strings.HasSuffix(article, "'") || strings.TrimLeftFunc(rest, unicode.IsSpace) != rest {
+			return strings.TrimLeftFunc(rest, unicode.IsSpace)
+		}
+	}
+	return title
+}
+
+func (collator *titleCollator) key(title string) []byte {
+	collator.mutex.Lock()
+	defer collator.mutex.Unlock()
+	key := append([]byte(nil), collator.collator.KeyFromString(&collator.buffer, collator.sortTitle(title))...)
+	collator.buffer.Reset()
+	return key
+}
+
+func (collator *titleCollator) order() *titleOrder {
+	return &titleOrder{collator: collator, keys: map[string][]byte{}}
+}
+
+func (order *titleOrder) compare(left string, right string) int {
+	for _, title := range []string{left, right} {
+		if _, found := order.keys[title]; !found {
+			order.keys[title] = order.collator.key(title)
+		}
+	}
+	compared := bytes.Compare(order.keys[left], order.keys[right])
+	if compared == 0 {
+		compared = strings.Compare(left, right)
+	}
+	return compared
+}
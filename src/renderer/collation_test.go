@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTitleCollation(t *testing.T) {
+	titles := []string{"Zebra", "Äpfel", `"Birnen"`, "Der Apfel", "apfel", "Öl", "Orangen"}
+	tests := []struct {
+		name     string
+		locale   string
+		articles bool
+		want     string
+	}{
+		{"german", "de", false, `apfel|Äpfel|"Birnen"|Der Apfel|Öl|Orangen|Zebra|`},
+		{"german without articles", "de", true, `apfel|Der Apfel|Äpfel|"Birnen"|Öl|Orangen|Zebra|`},
+		{"swedish", "sv", false, `apfel|"Birnen"|Der Apfel|Orangen|Zebra|Äpfel|Öl|`},
+		{"swedish without articles", "sv", true, `apfel|"Birnen"|Der Apfel|Orangen|Zebra|Äpfel|Öl|`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.SortLocale = test.locale
+			site.Configuration.SortIgnoreArticles = test.articles
+			site.writeFile(site.Configuration.TemplateIndex, `{{range sortBy "Title" "asc" .Links}}{{.Title}}|{{end}}`)
+			for index, title := range titles {
+				site.page(string(rune('a'+index))+".md", `{"Title": `+strconv.Quote(title)+`}`, "body\n")
+			}
+			site.mustBuild()
+			if got := site.read(INDEX_FILE_NAME); got != test.want {
+				t.Errorf("index = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
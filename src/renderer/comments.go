@@ -0,0 +1,59 @@
+package renderer
+
+import (
+	"bytes"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+func removeComments(literal []byte) []byte {
+	var result []byte
+	for {
+		start := bytes.Index(literal, []byte(HTML_COMMENT_START))
+		if start == -1 {
+			break
+		}
+		end := bytes.Index(literal[start+len(HTML_COMMENT_START):], []byte(HTML_COMMENT_END))
+		if end == -1 {
+			break
+		}
+		result = append(result, literal[:start]...)
+		literal = literal[start+len(HTML_COMMENT_START)+end+len(HTML_COMMENT_END):]
+	}
+	if result == nil {
+		return literal
+	}
+	return append(result, literal...)
+}
+
+func stripComments(document ast.Node) {
+	var empty []ast.Node
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch typed := node.(type) {
+		case *ast.HTMLSpan:
+			typed.Literal = removeComments(typed.Literal)
+			if len(typed.Literal) == 0 {
+				empty = append(empty, typed)
+			}
+		case *ast.HTMLBlock:
+			typed.Literal = removeComments(typed.Literal)
+			if len(bytes.TrimSpace(typed.Literal)) == 0 {
+				empty = append(empty, typed)
+			}
+		}
+		return ast.GoToNext
+	})
+	for _, node := range empty {
+		parent := node.GetParent()
+		var children []ast.Node
+		for _, child := range parent.GetChildren() {
+			if child != node {
+				children = append(children, child)
+			}
+		}
+		parent.SetChildren(children)
+	}
+}
@@ -0,0 +1,328 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+func configPath() (string, error) {
+	path := os.Getenv(ENVIRONMENTAL_VARIABLE)
+	if len(path) == 0 {
+		msg := fmt.Sprintf("missing environmental variable '%s'", ENVIRONMENTAL_VARIABLE)
+		return "", errors.New(msg)
+	}
+	return path, nil
+}
+
+type configPathField struct {
+	name  string
+	value *string
+}
+
+func configPathFields(configuration *Configuration) []configPathField {
+	fields := []configPathField{
+		{"Input", &configuration.Input},
+		{"Output", &configuration.Output},
+		{"TemplatePage", &configuration.TemplatePage},
+		{"TemplateIndex", &configuration.TemplateIndex},
+		{"Template404", &configuration.Template404},
+		{"TemplateBook", &configuration.TemplateBook},
+		{"TemplateTerm", &configuration.TemplateTerm},
+		{"TemplateAZ", &configuration.TemplateAZ},
+		{"CacheFile", &configuration.CacheFile},
+		{"Shortcodes", &configuration.Shortcodes},
+		{"Abbreviations", &configuration.Abbreviations},
+		{"InlineDirectory", &configuration.InlineDirectory},
+		{"Inject.HeadAppendFile", &configuration.Inject.HeadAppendFile},
+		{"Inject.BodyPrependFile", &configuration.Inject.BodyPrependFile},
+		{"Inject.BodyAppendFile", &configuration.Inject.BodyAppendFile},
+	}
+	for index := range configuration.ExtraOutputs {
+		name := fmt.Sprintf("ExtraOutputs[%d].Template", index)
+		fields = append(fields, configPathField{name, &configuration.ExtraOutputs[index].Template})
+	}
+	for index := range configuration.TemplateRules {
+		name := fmt.Sprintf("TemplateRules[%d].Template", index)
+		fields = append(fields, configPathField{name, &configuration.TemplateRules[index].Template})
+	}
+	if configuration.Icons != nil {
+		fields = append(fields, configPathField{"Icons.Source", &configuration.Icons.Source})
+	}
+	return fields
+}
+
+func expandPath(field string, value string) (string, error) {
+	expanded := value
+	if expanded == "~" || strings.HasPrefix(expanded, "~/") || strings.HasPrefix(expanded, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			msg := fmt.Sprintf("%s '%s': the home directory is unknown: %s", field, value, err)
+			return "", errors.New(msg)
+		}
+		expanded = home + expanded[1:]
+	} else if strings.HasPrefix(expanded, "~") {
+		msg := fmt.Sprintf("%s '%s': only a leading ~ for the home directory of the current user is expanded", field, value)
+		return "", errors.New(msg)
+	}
+	var err error
+	expanded = os.Expand(expanded, func(name string) string {
+		if name == "$" {
+			return name
+		}
+		variable, found := os.LookupEnv(name)
+		if !found && err == nil {
+			msg := fmt.Sprintf("%s '%s': environment variable '%s' is not set", field, value, name)
+			err = errors.New(msg)
+		}
+		return variable
+	})
+	return expanded, err
+}
+
+func resolveConfigPaths(configuration *Configuration, directory string) error {
+	var err error
+	fields := configPathFields(configuration)
+	for _, field := range fields {
+		if len(*field.value) == 0 {
+			continue
+		}
+		expanded, expandErr := expandPath(field.name, *field.value)
+		if expandErr != nil {
+			if err == nil {
+				err = expandErr
+			}
+			continue
+		}
+		if expanded != *field.value {
+			log.Printf("configuration: %s '%s' expands to %s", field.name, *field.value, expanded)
+		}
+		*field.value = expanded
+	}
+	for _, field := range fields {
+		if len(*field.value) > 0 && !filepath.IsAbs(*field.value) {
+			*field.value = filepath.Join(directory, *field.value)
+		}
+	}
+	return err
+}
+
+func OverrideOutput(configuration Configuration, output string, baseURL string) Configuration {
+	if len(output) > 0 {
+		configuration.Output = output
+		configuration.CacheFile = ""
+	}
+	if len(baseURL) > 0 {
+		configuration.BaseURL = baseURL
+	}
+	return configuration
+}
+
+func expandBuildMeta(configuration *Configuration) error {
+	var keys []string
+	for key := range configuration.BuildMeta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expanded := map[string]string{}
+	for _, key := range keys {
+		var err error
+		expanded[key] = os.Expand(configuration.BuildMeta[key], func(name string) string {
+			if name == "$" {
+				return name
+			}
+			value, found := os.LookupEnv(name)
+			if !found && configuration.StrictEnvironment && err == nil {
+				msg := fmt.Sprintf("BuildMeta %s: environment variable '%s' is not set", key, name)
+				err = errors.New(msg)
+			} else if !found {
+				log.Printf("warning: BuildMeta %s: environment variable '%s' is not set", key, name)
+			}
+			return value
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if configuration.BuildMeta != nil {
+		configuration.BuildMeta = expanded
+	}
+	return nil
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "-" || len(field.PkgPath) > 0 {
+		return "", false
+	}
+	if len(tag) > 0 {
+		return tag, true
+	}
+	return field.Name, true
+}
+
+func collectUnknownKeys(value interface{}, kind reflect.Type, prefix string, unknown *[]string) {
+	for kind.Kind() == reflect.Ptr {
+		kind = kind.Elem()
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if kind.Kind() == reflect.Map {
+			for key, item := range typed {
+				collectUnknownKeys(item, kind.Elem(), prefix+key+".", unknown)
+			}
+			return
+		}
+		if kind.Kind() != reflect.Struct {
+			return
+		}
+		fields := map[string]reflect.Type{}
+		for index := 0; index < kind.NumField(); index++ {
+			name, exported := jsonFieldName(kind.Field(index))
+			if exported {
+				fields[strings.ToLower(name)] = kind.Field(index).Type
+			}
+		}
+		for key, item := range typed {
+			fieldType, known := fields[strings.ToLower(key)]
+			if !known {
+				*unknown = append(*unknown, prefix+key)
+				continue
+			}
+			collectUnknownKeys(item, fieldType, prefix+key+".", unknown)
+		}
+	case []interface{}:
+		if kind.Kind() == reflect.Slice || kind.Kind() == reflect.Array {
+			for index, item := range typed {
+				collectUnknownKeys(item, kind.Elem(), fmt.Sprintf("%s%d.", prefix, index), unknown)
+			}
+		}
+	}
+}
+
+func unknownConfigKeys(data []byte) ([]string, error) {
+	var unknown []string
+	var raw interface{}
+	err := json.Unmarshal(data, &raw)
+	if err == nil {
+		collectUnknownKeys(raw, reflect.TypeOf(Configuration{}), "", &unknown)
+	}
+	sort.Strings(unknown)
+	return unknown, err
+}
+
+func checkTemplate(name string, path string, configuration Configuration) error {
+	_, err := template.New(filepath.Base(path)).Funcs(templateFuncs(configuration, nil, "")).ParseFiles(path)
+	if err != nil {
+		msg := fmt.Sprintf("%s: %s", name, err)
+		err = errors.New(msg)
+	}
+	return err
+}
+
+func checkTemplates(configuration Configuration) []error {
+	var problems []error
+	templates := []struct {
+		name     string
+		path     string
+		required bool
+	}{
+		{"TemplatePage", configuration.TemplatePage, configuration.OutputMode != OUTPUT_MODE_BOOK || len(configuration.TemplateBook) == 0},
+		{"TemplateIndex", configuration.TemplateIndex, configuration.OutputMode != OUTPUT_MODE_BOOK},
+		{"Template404", configuration.Template404, false},
+		{"TemplateBook", configuration.TemplateBook, false},
+		{"TemplateTerm", configuration.TemplateTerm, false},
+		{"TemplateAZ", configuration.TemplateAZ, false},
+	}
+	for _, entry := range templates {
+		if len(entry.path) == 0 {
+			if entry.required {
+				msg := fmt.Sprintf("%s is not set", entry.name)
+				problems = append(problems, errors.New(msg))
+			}
+			continue
+		}
+		err := checkTemplate(entry.name, entry.path, configuration)
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+	files, err := shortcodeFiles(configuration)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	for _, file := range files {
+		err = checkTemplate("shortcode", file, configuration)
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+func checkDirectories(configuration Configuration) []error {
+	var problems []error
+	outputDirectory := configuration.Output
+	if IsArchivePath(outputDirectory) {
+		outputDirectory = filepath.Dir(outputDirectory)
+	}
+	for _, entry := range [][2]string{{"Input", configuration.Input}, {"Output", outputDirectory}} {
+		if len(entry[1]) == 0 {
+			msg := fmt.Sprintf("%s is not set", entry[0])
+			problems = append(problems, errors.New(msg))
+		} else if err := CheckPathError(entry[1]); err != nil {
+			msg := fmt.Sprintf("%s: %s", entry[0], err)
+			problems = append(problems, errors.New(msg))
+		}
+	}
+	return problems
+}
+
+func CheckConfig() (Configuration, []error) {
+	var configuration Configuration
+	var problems []error
+	var data []byte
+	path, err := configPath()
+	if err == nil {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &configuration)
+	}
+	if err != nil {
+		return configuration, []error{err}
+	}
+	unknown, _ := unknownConfigKeys(data)
+	for _, key := range unknown {
+		msg := fmt.Sprintf("unknown key '%s'", key)
+		problems = append(problems, errors.New(msg))
+	}
+	err = resolveConfigPaths(&configuration, filepath.Dir(path))
+	if err != nil {
+		problems = append(problems, err)
+	}
+	err = expandBuildMeta(&configuration)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	_, err = LoadInject(configuration.Inject)
+	if err != nil {
+		msg := fmt.Sprintf("inject snippet error: %s", err)
+		problems = append(problems, errors.New(msg))
+	}
+	err = Preflight(configuration)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	problems = append(problems, checkDirectories(configuration)...)
+	problems = append(problems, checkTemplates(configuration)...)
+	return configuration, problems
+}
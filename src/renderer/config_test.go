@@ -0,0 +1,167 @@
+package renderer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setTestEnv(t *testing.T, name string, value string, set bool) {
+	previous, found := os.LookupEnv(name)
+	if set {
+		os.Setenv(name, value)
+	} else {
+		os.Unsetenv(name)
+	}
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(name, previous)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func TestLoadConfigFromAnotherDirectory(t *testing.T) {
+	directory := t.TempDir()
+	site := filepath.Join(directory, "site")
+	config := filepath.Join(site, "config.json")
+	err := os.MkdirAll(site, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `{
+		"Input": "./content",
+		"Output": "$SITE_OUTPUT/public",
+		"TemplatePage": "~/templates/page.html",
+		"TemplateIndex": "/srv/templates/index.html",
+		"BaseURL": "https://example.com/$SITE_OUTPUT/",
+		"Icons": {"Source": "./images/icon.png"}
+	}`
+	err = ioutil.WriteFile(config, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setTestEnv(t, ENVIRONMENTAL_VARIABLE, config, true)
+	setTestEnv(t, "SITE_OUTPUT", "build", true)
+	setTestEnv(t, "HOME", "/home/writer", true)
+	configuration, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"Input", configuration.Input, filepath.Join(site, "content")},
+		{"Output", configuration.Output, filepath.Join(site, "build", "public")},
+		{"TemplatePage", configuration.TemplatePage, "/home/writer/templates/page.html"},
+		{"TemplateIndex", configuration.TemplateIndex, "/srv/templates/index.html"},
+		{"BaseURL", configuration.BaseURL, "https://example.com/$SITE_OUTPUT/"},
+		{"Icons.Source", configuration.Icons.Source, filepath.Join(site, "images", "icon.png")},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %q, want %q", test.field, test.got, test.want)
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		home  string
+		set   bool
+		want  string
+		err   string
+	}{
+		{"absolute path", "/srv/site", "/home/writer", true, "/srv/site", ""},
+		{"home directory", "~", "/home/writer", true, "/home/writer", ""},
+		{"inside the home directory", "~/site", "/home/writer", true, "/home/writer/site", ""},
+		{"variable", "$TEST_SITE/content", "/home/writer", true, "/srv/site/content", ""},
+		{"braced variable", "${TEST_SITE}/content", "/home/writer", true, "/srv/site/content", ""},
+		{"escaped dollar", "cost$$", "/home/writer", true, "cost$", ""},
+		{"unset variable", "$TEST_UNSET/content", "/home/writer", true, "", "environment variable 'TEST_UNSET' is not set"},
+		{"unknown home", "~/site", "", false, "", "the home directory is unknown"},
+		{"home of another user", "~writer/site", "/home/writer", true, "", "only a leading ~"},
+	}
+	setTestEnv(t, "TEST_SITE", "/srv/site", true)
+	setTestEnv(t, "TEST_UNSET", "", false)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestEnv(t, "HOME", test.home, test.set)
+			got, err := expandPath("Input", test.value)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expandPath(%q) error = %v, want %q", test.value, err, test.err)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("expandPath(%q) = %q, %v, want %q", test.value, got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestPreviewBuild(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.BaseURL = "https://example.com/"
+	site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}}
+	site.writeFile(site.Configuration.TemplatePage, `<link href="{{absURL "style.css"}}"><a href="{{rootURL "a.html"}}">{{.Content}}`)
+	site.page("a.md", `{"Title": "A", "Date": "2024-01-01"}`, "body a\n")
+	site.page("b.md", `{"Title": "B", "Date": "2024-01-02"}`, "body b\n")
+	site.mustBuild()
+	files := func(root string) map[string]string {
+		contents := map[string]string{}
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || info.Name() == CACHE_FILE_NAME || info.Name() == MANIFEST_FILE_NAME {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			relative, _ := filepath.Rel(root, path)
+			contents[filepath.ToSlash(relative)] = string(data)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return contents
+	}
+	production := files(site.Configuration.Output)
+	cache := site.read(CACHE_FILE_NAME)
+
+	preview := OverrideOutput(site.Configuration, site.path("preview/pr-1"), "https://preview.example.com/pr-1/")
+	if cachePath(preview) != filepath.Join(preview.Output, CACHE_FILE_NAME) {
+		t.Errorf("preview cache = %s, want it inside the preview output", cachePath(preview))
+	}
+	if err := NewBuilder(preview).Build(); err != nil {
+		t.Fatal(err)
+	}
+	previewed := files(preview.Output)
+	if len(previewed) != len(production) {
+		t.Fatalf("preview wrote %d files, production %d", len(previewed), len(production))
+	}
+	differing := 0
+	for name, content := range production {
+		other := previewed[name]
+		if other != content {
+			differing++
+		}
+		other = strings.Replace(other, "https://preview.example.com/pr-1/", "https://example.com/", -1)
+		other = strings.Replace(other, `"/pr-1/`, `"/`, -1)
+		if other != content {
+			t.Errorf("%s differs in more than its URLs:\n%s\n%s", name, content, previewed[name])
+		}
+	}
+	if differing == 0 {
+		t.Error("the preview build did not use its base URL")
+	}
+	if site.read(CACHE_FILE_NAME) != cache {
+		t.Error("the preview build changed the production cache")
+	}
+}
@@ -0,0 +1,154 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DATE_DISPLAY_FORMAT = "2006-01-02"
+
+var NAME_DATE_PREFIX = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(-|$)`)
+var NAME_DAY_PREFIX = regexp.MustCompile(`^(\d{2})(-|$)`)
+var DIRECTORY_YEAR = regexp.MustCompile(`^\d{4}$`)
+var DIRECTORY_MONTH = regexp.MustCompile(`^\d{2}$`)
+
+var FLOATING_DATE_FORMATS = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var locations sync.Map
+
+type MetaDate struct {
+	time.Time
+	Floating bool
+	Unparsed string
+}
+
+func (date *MetaDate) UnmarshalJSON(data []byte) error {
+	var text string
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		date.Unparsed = string(data)
+		return nil
+	}
+	if len(text) == 0 {
+		return nil
+	}
+	date.Time, err = time.Parse(time.RFC3339Nano, text)
+	if err == nil {
+		date.Floating = false
+		return nil
+	}
+	for _, format := range FLOATING_DATE_FORMATS {
+		date.Time, err = time.Parse(format, text)
+		if err == nil {
+			date.Floating = true
+			return nil
+		}
+	}
+	date.Time = time.Time{}
+	date.Unparsed = text
+	return nil
+}
+
+func (date MetaDate) MarshalJSON() ([]byte, error) {
+	text := date.Unparsed
+	if date.Floating {
+		text = date.Format(FLOATING_DATE_FORMATS[0])
+	} else if !date.IsZero() {
+		text = date.Format(time.RFC3339Nano)
+	}
+	return json.Marshal(text)
+}
+
+func loadLocation(configuration Configuration) (*time.Location, error) {
+	name := configuration.Timezone
+	if len(name) == 0 {
+		return nil, nil
+	}
+	cached, found := locations.Load(name)
+	if found {
+		return cached.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		msg := fmt.Sprintf("invalid Timezone '%s': %s", name, err)
+		return nil, errors.New(msg)
+	}
+	locations.Store(name, location)
+	return location, nil
+}
+
+func checkDate(date MetaDate) error {
+	if len(date.Unparsed) > 0 {
+		msg := fmt.Sprintf("meta block error: unparseable date '%s'", date.Unparsed)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func resolveDate(date MetaDate, location *time.Location) time.Time {
+	value := date.Time
+	if date.IsZero() {
+		return value
+	}
+	if date.Floating {
+		zone := location
+		if zone == nil {
+			zone = time.UTC
+		}
+		value = time.Date(value.Year(), value.Month(), value.Day(), value.Hour(), value.Minute(), value.Second(), value.Nanosecond(), zone)
+	} else if location != nil {
+		value = value.In(location)
+	}
+	return value
+}
+
+func formatDate(date MetaDate, location *time.Location) string {
+	return resolveDate(date, location).Format(DATE_DISPLAY_FORMAT)
+}
+
+func nameDate(name string) (MetaDate, string, bool) {
+	directory, file := path.Split(strings.TrimSuffix(name, MARKDOWN_FILE_ENDING))
+	if match := NAME_DATE_PREFIX.FindStringSubmatch(file); match != nil {
+		date, err := time.Parse(DATE_DISPLAY_FORMAT, match[1])
+		if err == nil {
+			return MetaDate{Time: date, Floating: true}, match[0], true
+		}
+		return MetaDate{}, "", false
+	}
+	segments := strings.Split(strings.Trim(directory, "/"), "/")
+	if len(segments) < 2 || !DIRECTORY_YEAR.MatchString(segments[len(segments)-2]) || !DIRECTORY_MONTH.MatchString(segments[len(segments)-1]) {
+		return MetaDate{}, "", false
+	}
+	text := segments[len(segments)-2] + "-" + segments[len(segments)-1] + "-01"
+	prefix := ""
+	if match := NAME_DAY_PREFIX.FindStringSubmatch(file); match != nil {
+		text = text[:len(text)-2] + match[1]
+		prefix = match[0]
+	}
+	date, err := time.Parse(DATE_DISPLAY_FORMAT, text)
+	if err != nil {
+		return MetaDate{}, "", false
+	}
+	return MetaDate{Time: date, Floating: true}, prefix, true
+}
+
+func jekyllOutputPath(outputPath string, prefix string) string {
+	directory, file := path.Split(outputPath)
+	stripped := strings.TrimPrefix(file, prefix)
+	if len(prefix) == 0 || stripped == ".html" {
+		return outputPath
+	}
+	return directory + stripped
+}
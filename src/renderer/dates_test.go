@@ -0,0 +1,117 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimezoneBuild(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		timezone string
+		meta     string
+		date     string
+		err      string
+		built    bool
+	}{
+		{"floating time in utc", "", `"Date": "2024-06-01T08:00"`, "2024-06-01", "is in the future", false},
+		{"floating time before now in tokyo", "Asia/Tokyo", `"Date": "2024-06-01T08:00"`, "2024-06-01", "", true},
+		{"floating time after now in tokyo", "Asia/Tokyo", `"Date": "2024-06-01T10:00"`, "", "date 2024-06-01T10:00:00+09:00 is in the future", false},
+		{"utc time shown in tokyo", "Asia/Tokyo", `"Date": "2024-05-31T20:00:00Z"`, "2024-06-01", "", true},
+		{"utc time shown in utc", "", `"Date": "2024-05-31T20:00:00Z"`, "2024-05-31", "", true},
+		{"expired in tokyo", "Asia/Tokyo", `"Date": "2024-05-01", "ExpiryDate": "2024-06-01T08:00"`, "", "", false},
+		{"not yet expired in utc", "", `"Date": "2024-05-01", "ExpiryDate": "2024-06-01T08:00"`, "2024-05-01", "", true},
+		{"unknown zone", "Mars/Olympus", `"Date": "2024-05-01"`, "", "invalid Timezone 'Mars/Olympus'", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Timezone = test.timezone
+			site.Configuration.UnpublishExpired = true
+			site.Configuration.Policies = map[string]string{CONDITION_FUTURE_DATE: POLICY_ERROR}
+			site.writeFile(site.Configuration.TemplatePage, "{{.Date}}")
+			site.page("a.md", `{"Title": "A", `+test.meta+`}`, "body\n")
+			builder := NewBuilder(site.Configuration)
+			builder.Now = func() time.Time { return now }
+			err := builder.Build()
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("build = %v, want %q", err, test.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if built := site.exists("a.html"); built != test.built {
+				t.Fatalf("a.html written = %v, want %v", built, test.built)
+			}
+			if test.built {
+				if date := site.read("a.html"); date != test.date {
+					t.Errorf("a.html date = %q, want %q", date, test.date)
+				}
+			}
+		})
+	}
+}
+
+func TestNameDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		date   string
+		prefix string
+		found  bool
+	}{
+		{"2019-04-02-some-title.md", "2019-04-02", "2019-04-02-", true},
+		{"posts/2019-04-02.md", "2019-04-02", "2019-04-02", true},
+		{"2019-13-99-not-a-date.md", "", "", false},
+		{"2019/04/some-title.md", "2019-04-01", "", true},
+		{"archive/2019/04/02-some-title.md", "2019-04-02", "02-", true},
+		{"2019/04/31-some-title.md", "", "", false},
+		{"2019/13/some-title.md", "", "", false},
+		{"2019/some-title.md", "", "", false},
+		{"posts/some-title.md", "", "", false},
+		{"20190402-some-title.md", "", "", false},
+	}
+	for _, test := range tests {
+		date, prefix, found := nameDate(test.name)
+		got := ""
+		if found {
+			got = date.Format(DATE_DISPLAY_FORMAT)
+		}
+		if found != test.found || got != test.date || prefix != test.prefix {
+			t.Errorf("nameDate(%q) = %q, %q, %v, want %q, %q, %v", test.name, got, prefix, found, test.date, test.prefix, test.found)
+		}
+	}
+}
+
+func TestJekyllStyleNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		meta   string
+		output string
+		date   string
+	}{
+		{"prefix only", "2019-04-02-some-title.md", `{"Title": "A"}`, "some-title.html", "2019-04-02"},
+		{"meta only", "some-title.md", `{"Title": "A", "Date": "2020-01-05"}`, "some-title.html", "2020-01-05"},
+		{"meta wins", "2019-04-02-some-title.md", `{"Title": "A", "Date": "2020-01-05"}`, "some-title.html", "2020-01-05"},
+		{"invalid prefix", "2019-13-99-some-title.md", `{"Title": "A"}`, "2019-13-99-some-title.html", ZERO_DATE},
+		{"directory", "2019/04/some-title.md", `{"Title": "A"}`, "2019/04/some-title.html", "2019-04-01"},
+		{"directory and day", "2019/04/02-some-title.md", `{"Title": "A"}`, "2019/04/some-title.html", "2019-04-02"},
+		{"directory and meta", "2019/04/02-some-title.md", `{"Title": "A", "Date": "2020-01-05"}`, "2019/04/some-title.html", "2020-01-05"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Recursive = true
+			site.Configuration.JekyllStyleNames = true
+			site.writeFile(site.Configuration.TemplatePage, "{{.Date}}")
+			site.page(test.file, test.meta, "body\n")
+			site.mustBuild()
+			if date := site.read(test.output); date != test.date {
+				t.Errorf("%s date = %q, want %q", test.output, date, test.date)
+			}
+		})
+	}
+}
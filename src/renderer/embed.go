@@ -0,0 +1,183 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"strings"
+	"sync"
+)
+
+const SUMMARY_SEPARATOR = "<!--more-->"
+
+type EmbeddedPage struct {
+	Title   string
+	Date    string
+	Url     string
+	Summary htmlTemplate.HTML
+	Content htmlTemplate.HTML
+}
+
+type embedTable struct {
+	configuration Configuration
+	sources       map[string]Source
+	report        func(condition string, path string, detail string) bool
+
+	mutex  *sync.Mutex
+	pages  map[string]*EmbeddedPage
+	active []string
+	uses   map[string]map[string]bool
+}
+
+func newEmbedTable(sources []Source, configuration Configuration) *embedTable {
+	table := &embedTable{
+		configuration: configuration,
+		sources:       map[string]Source{},
+		mutex:         &sync.Mutex{},
+		pages:         map[string]*EmbeddedPage{},
+		uses:          map[string]map[string]bool{},
+	}
+	for _, source := range sources {
+		table.sources[source.Name] = source
+	}
+	return table
+}
+
+func (table *embedTable) worker() *embedTable {
+	worker := *table
+	worker.active = nil
+	return &worker
+}
+
+func contentSummary(content string) string {
+	if index := strings.Index(content, SUMMARY_SEPARATOR); index != -1 {
+		return strings.TrimSpace(content[:index])
+	}
+	start := strings.Index(content, PARAGRAPH_START)
+	if start == -1 {
+		return ""
+	}
+	end := strings.Index(content[start:], PARAGRAPH_END)
+	if end == -1 {
+		return ""
+	}
+	return content[start : start+end+len(PARAGRAPH_END)]
+}
+
+func (table *embedTable) hidden(source Source, current string) error {
+	if !source.Unlisted && !source.Expired {
+		return nil
+	}
+	detail := fmt.Sprintf("pageByPath '%s' embeds an unlisted or expired page", source.Name)
+	failed := policyFor(table.configuration, CONDITION_EMBEDDED_UNLISTED) == POLICY_ERROR
+	if table.report != nil {
+		failed = table.report(CONDITION_EMBEDDED_UNLISTED, current, detail)
+	}
+	if failed {
+		return errors.New(detail)
+	}
+	return nil
+}
+
+func (table *embedTable) page(site *Site, name string, current string) (*EmbeddedPage, error) {
+	source := table.sources[name]
+	err := table.hidden(source, current)
+	if err != nil {
+		return nil, err
+	}
+	table.mutex.Lock()
+	embedded, found := table.pages[name]
+	table.mutex.Unlock()
+	if found {
+		table.use(current, name)
+		return embedded, nil
+	}
+	err = table.enter(name)
+	if err != nil {
+		return nil, err
+	}
+	content, err := sourceContent(table.configuration, source, site)
+	table.leave()
+	if err != nil {
+		msg := fmt.Sprintf("pageByPath '%s': %s", name, err)
+		return nil, errors.New(msg)
+	}
+	embedded = &EmbeddedPage{
+		Title:   source.Link.Title,
+		Date:    source.Link.Date,
+		Url:     source.Link.Url,
+		Summary: htmlTemplate.HTML(contentSummary(content)),
+		Content: htmlTemplate.HTML(content),
+	}
+	table.mutex.Lock()
+	table.pages[name] = embedded
+	table.mutex.Unlock()
+	table.use(current, name)
+	return embedded, nil
+}
+
+func (table *embedTable) use(current string, name string) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	dependencies := []string{name}
+	for dependency := range table.uses[name] {
+		dependencies = append(dependencies, dependency)
+	}
+	for _, user := range append([]string{current}, table.active...) {
+		if user == name {
+			continue
+		}
+		if table.uses[user] == nil {
+			table.uses[user] = map[string]bool{}
+		}
+		for _, dependency := range dependencies {
+			table.uses[user][dependency] = true
+		}
+	}
+}
+
+func (table *embedTable) dependencies(source Source) map[string]string {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	var embeds map[string]string
+	for _, user := range append([]string{source.Name}, variantPaths(source)...) {
+		for name := range table.uses[user] {
+			if embeds == nil {
+				embeds = map[string]string{}
+			}
+			embeds[name] = table.sources[name].Hash
+		}
+	}
+	return embeds
+}
+
+func (table *embedTable) enter(name string) error {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	for _, active := range table.active {
+		if active == name {
+			chain := strings.Join(append(append([]string(nil), table.active...), name), " -> ")
+			msg := fmt.Sprintf("pageByPath: pages embed each other in a cycle: %s", chain)
+			return errors.New(msg)
+		}
+	}
+	table.active = append(table.active, name)
+	return nil
+}
+
+func (table *embedTable) leave() {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	table.active = table.active[:len(table.active)-1]
+}
+
+func (site *Site) pageByPath(reference string, current string) (*EmbeddedPage, error) {
+	name, err := site.resolveName("pageByPath", reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		current = reference
+	}
+	return site.embeds.page(site, name, current)
+}
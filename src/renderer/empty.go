@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+const EMPTY_SITE_WARN = "warn"
+const EMPTY_SITE_WRITE = "write"
+const EMPTY_SITE_FAIL = "fail"
+const EMPTY_SITE_KEEP = "keep"
+
+func checkEmptySite(configuration Configuration) error {
+	switch configuration.EmptySite {
+	case "", EMPTY_SITE_WARN, EMPTY_SITE_WRITE, EMPTY_SITE_FAIL, EMPTY_SITE_KEEP:
+		return nil
+	}
+	msg := fmt.Sprintf("EmptySite must be '%s', '%s', '%s' or '%s', not '%s'", EMPTY_SITE_WARN, EMPTY_SITE_WRITE, EMPTY_SITE_FAIL, EMPTY_SITE_KEEP, configuration.EmptySite)
+	return errors.New(msg)
+}
+
+func emptySite(configuration Configuration) (bool, error) {
+	switch configuration.EmptySite {
+	case EMPTY_SITE_WRITE:
+		return false, nil
+	case EMPTY_SITE_FAIL:
+		msg := fmt.Sprintf("no published pages in %s", configuration.Input)
+		return false, errors.New(msg)
+	case EMPTY_SITE_KEEP:
+		log.Printf("warning: no published pages in %s, the previous output is kept", configuration.Input)
+		return true, nil
+	}
+	log.Printf("warning: no published pages in %s, writing an empty index", configuration.Input)
+	return false, nil
+}
@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmptySite(t *testing.T) {
+	sites := []struct {
+		name  string
+		pages map[string]string
+	}{
+		{"empty directory", nil},
+		{"only expired pages", map[string]string{"a.md": `{"Title": "A", "ExpiryDate": "2000-01-01"}`, "b.md": `{"Title": "B", "ExpiryDate": "2001-01-01"}`}},
+		{"only unlisted pages", map[string]string{"a.md": `{"Title": "A", "Unlisted": true}`}},
+	}
+	modes := []struct {
+		mode  string
+		index bool
+		kept  bool
+		err   string
+	}{
+		{"", true, false, ""},
+		{EMPTY_SITE_WARN, true, false, ""},
+		{EMPTY_SITE_WRITE, true, false, ""},
+		{EMPTY_SITE_FAIL, false, false, "no published pages in"},
+		{EMPTY_SITE_KEEP, false, true, ""},
+	}
+	for _, site := range sites {
+		for _, mode := range modes {
+			t.Run(site.name+"/"+mode.mode, func(t *testing.T) {
+				test := newTestSite(t)
+				test.Configuration.EmptySite = mode.mode
+				test.page("old.md", `{"Title": "Old"}`, "body\n")
+				test.mustBuild()
+				test.remove("old.md")
+				for name, meta := range site.pages {
+					test.page(name, meta, "body\n")
+				}
+				err := test.build()
+				if len(mode.err) > 0 {
+					if err == nil || !strings.Contains(err.Error(), mode.err) {
+						t.Fatalf("build error = %v, want %q", err, mode.err)
+					}
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				index := test.read(INDEX_FILE_NAME)
+				if kept := strings.Contains(index, "Old"); kept != (mode.kept || !mode.index) {
+					t.Errorf("index.html = %q, previous index kept = %v", index, kept)
+				}
+				if mode.index && strings.Contains(index, "<a href") {
+					t.Errorf("index.html = %q, want an empty index", index)
+				}
+			})
+		}
+	}
+}
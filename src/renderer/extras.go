@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+type ExtraOutput struct {
+	Template string
+	Output   string
+}
+
+func checkExtraOutputPath(value string) error {
+	cleaned := path.Clean(value)
+	switch {
+	case len(value) == 0:
+		return errors.New("Output is not set")
+	case strings.Contains(value, "\\"):
+		return errors.New("Output must use forward slashes")
+	case strings.HasPrefix(value, "/") || strings.HasSuffix(value, "/"):
+		return errors.New("Output must be a file path relative to the output root")
+	case cleaned != value:
+		msg := fmt.Sprintf("Output must be written as '%s'", cleaned)
+		return errors.New(msg)
+	case cleaned == ".." || strings.HasPrefix(cleaned, "../"):
+		return errors.New("Output leaves the output root")
+	}
+	return nil
+}
+
+func checkExtraOutputs(configuration Configuration) error {
+	if len(configuration.ExtraOutputs) > 0 && configuration.OutputMode == OUTPUT_MODE_BOOK {
+		return errors.New("extra outputs are not written in book output mode")
+	}
+	builtin := configuration
+	builtin.ExtraOutputs = nil
+	reserved := knownOutputs(builtin, nil)
+	seen := map[string]bool{}
+	for number, extra := range configuration.ExtraOutputs {
+		err := checkExtraOutputPath(extra.Output)
+		if err == nil && len(extra.Template) == 0 {
+			err = errors.New("Template is not set")
+		}
+		if err == nil && reserved[extra.Output] {
+			msg := fmt.Sprintf("'%s' is already written by the generator", extra.Output)
+			err = errors.New(msg)
+		}
+		if err == nil && seen[strings.ToLower(extra.Output)] {
+			msg := fmt.Sprintf("'%s' is listed more than once", extra.Output)
+			err = errors.New(msg)
+		}
+		if err == nil {
+			err = checkTemplate("Template", extra.Template, configuration)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("extra output #%d '%s': %s", number+1, extra.Output, err)
+			return errors.New(msg)
+		}
+		seen[strings.ToLower(extra.Output)] = true
+	}
+	return nil
+}
+
+func renderExtraOutputs(configuration Configuration, output OutputWriter, index Index) error {
+	for _, extra := range configuration.ExtraOutputs {
+		buffer := getBuffer()
+		err := executeTemplate(buffer, extra.Output, extra.Template, index, index.Site, configuration)
+		if err == nil {
+			err = writeFile(output, extra.Output, buffer.Bytes())
+		}
+		putBuffer(buffer)
+		if err != nil {
+			msg := fmt.Sprintf("extra output '%s': %s", extra.Output, err)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const FAILURE_COLLECT = "collect"
+const FAILURE_RENDER = "render"
+const FAILURE_ASSET = "asset"
+const FAILURE_BUILD = "build"
+
+type FileError struct {
+	Category string
+	Path     string
+	Message  string
+	Err      error `json:"-"`
+}
+
+type BuildError struct {
+	Errors []*FileError
+}
+
+func (failure *FileError) Error() string {
+	if len(failure.Path) == 0 {
+		return fmt.Sprintf("%s: %s", failure.Category, failure.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", failure.Category, failure.Path, failure.Message)
+}
+
+func (failure *FileError) Unwrap() error {
+	return failure.Err
+}
+
+func (buildError *BuildError) Error() string {
+	var messages []string
+	for _, failure := range buildError.Errors {
+		messages = append(messages, failure.Error())
+	}
+	if len(messages) == 1 {
+		return messages[0]
+	}
+	return fmt.Sprintf("%d errors: %s", len(messages), strings.Join(messages, "; "))
+}
+
+func (buildError *BuildError) As(target interface{}) bool {
+	for _, failure := range buildError.Errors {
+		if errors.As(failure, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (builder *Builder) fail(category string, path string, err error) {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+	builder.Summary.Failed++
+	builder.failures = append(builder.failures, &FileError{Category: category, Path: path, Message: err.Error(), Err: err})
+	builder.findings = append(builder.findings, newFinding(POLICY_ERROR, category, path, err.Error()))
+}
+
+func (builder *Builder) pagesFailed() bool {
+	for _, failure := range builder.failures {
+		if failure.Category == FAILURE_COLLECT || failure.Category == FAILURE_RENDER {
+			return true
+		}
+	}
+	return false
+}
+
+func (builder *Builder) result(err error) error {
+	if _, isBuildError := err.(*BuildError); isBuildError {
+		return err
+	}
+	failures := append([]*FileError(nil), builder.failures...)
+	if err != nil {
+		var failure *FileError
+		if !errors.As(err, &failure) {
+			failure = &FileError{Category: FAILURE_BUILD, Message: err.Error(), Err: err}
+		}
+		failures = append(failures, failure)
+		builder.findings = append(builder.findings, newFinding(POLICY_ERROR, failure.Category, failure.Path, failure.Message))
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &BuildError{Errors: sortFailures(failures)}
+}
+
+func sortFailures(failures []*FileError) []*FileError {
+	sort.SliceStable(failures, func(i, j int) bool {
+		if failures[i].Path != failures[j].Path {
+			return failures[i].Path < failures[j].Path
+		}
+		return failures[i].Category < failures[j].Category
+	})
+	return failures
+}
@@ -0,0 +1,83 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestBuildErrorOrderAndStats(t *testing.T) {
+	var runs []string
+	for run := 0; run < 3; run++ {
+		site := newTestSite(t)
+		site.Configuration.ContentStats = true
+		site.Configuration.PageWorkers = 4
+		site.writeFile(site.path("broken.html"), "{{.Missing}}\n")
+		site.Configuration.TemplateRules = []TemplateRule{{Pattern: "c.md", Template: site.path("broken.html")}}
+		site.page("c.md", `{"Title": "C"}`, "body\n")
+		site.page("b.md", `{"Title": "B", "Date": "yesterday"}`, "body\n")
+		site.write("a.md", "no meta block\n")
+		site.page("d.md", `{"Title": "D"}`, "body\n")
+		err := site.build()
+		var buildError *BuildError
+		if !errors.As(err, &buildError) {
+			t.Fatalf("build = %v, want a *BuildError", err)
+		}
+		var got []string
+		for _, failure := range buildError.Errors {
+			got = append(got, failure.Category+" "+strings.TrimPrefix(failure.Path, site.Configuration.Input+"/"))
+		}
+		want := []string{CONDITION_MISSING_META + " a.md", CONDITION_UNPARSEABLE_DATE + " b.md", FAILURE_RENDER + " c.md"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("failures = %v, want %v", got, want)
+		}
+		var failure *FileError
+		if !errors.As(err, &failure) || failure != buildError.Errors[0] {
+			t.Errorf("errors.As(*FileError) = %v, want the first failure %v", failure, buildError.Errors[0])
+		}
+		var execError template.ExecError
+		if !errors.As(err, &execError) || !strings.Contains(execError.Error(), "Missing") {
+			t.Errorf("errors.As(template.ExecError) = %v, want the template failure of c.md", execError)
+		}
+		var stats ContentStats
+		err = json.Unmarshal([]byte(site.read(CONTENT_STATS_FILE_NAME)), &stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.Failures) != len(want) {
+			t.Fatalf("stats failures = %v, want %d", stats.Failures, len(want))
+		}
+		for index, failure := range stats.Failures {
+			if failure.Path != buildError.Errors[index].Path || failure.Category != buildError.Errors[index].Category || failure.Message != buildError.Errors[index].Message {
+				t.Errorf("stats failure %d = %+v, want %+v", index, failure, buildError.Errors[index])
+			}
+		}
+		runs = append(runs, strings.Replace(buildError.Error(), site.Configuration.Input, "content", -1))
+	}
+	for _, run := range runs[1:] {
+		if run != runs[0] {
+			t.Errorf("build errors differ between runs:\n%s\n%s", runs[0], run)
+		}
+	}
+}
+
+func TestStatsListPolicyFailures(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.ContentStats = true
+	site.Configuration.Policies = map[string]string{CONDITION_BROKEN_INTERNAL_LINK: POLICY_ERROR}
+	site.page("a.md", `{"Title": "A"}`, "[gone](gone.md)\n")
+	if err := site.build(); err == nil {
+		t.Fatal("build with a broken link succeeded, want the broken-internal-link error")
+	}
+	var stats ContentStats
+	err := json.Unmarshal([]byte(site.read(CONTENT_STATS_FILE_NAME)), &stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Posts != 1 || len(stats.Failures) != 1 || stats.Failures[0].Category != CONDITION_BROKEN_INTERNAL_LINK {
+		t.Errorf("stats = %+v, want the post and the broken-internal-link failure", stats)
+	}
+}
@@ -0,0 +1,183 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const FEED_FORMAT_JSON = "jsonfeed"
+const JSON_FEED_FILE_NAME = "feed.json"
+const JSON_FEED_VERSION = "https://jsonfeed.org/version/1.1"
+const DEFAULT_FEED_LIMIT = 20
+
+type Feed struct {
+	Title        string
+	Formats      []string
+	Limit        int
+	DateModified bool
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageUrl string         `json:"home_page_url"`
+	FeedUrl     string         `json:"feed_url"`
+	Language    string         `json:"language,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	Id            string           `json:"id"`
+	Url           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHtml   string           `json:"content_html"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Language      string           `json:"language,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	Url  string `json:"url,omitempty"`
+}
+
+func feedFormats(configuration Configuration) map[string]bool {
+	formats := map[string]bool{}
+	if configuration.Feed != nil {
+		for _, format := range configuration.Feed.Formats {
+			formats[format] = true
+		}
+	}
+	return formats
+}
+
+func checkFeed(configuration Configuration) error {
+	feed := configuration.Feed
+	if feed == nil {
+		return nil
+	}
+	if configuration.OutputMode == OUTPUT_MODE_BOOK {
+		return errors.New("feeds are not written in book output mode")
+	}
+	if len(configuration.BaseURL) == 0 {
+		return errors.New("feeds need a BaseURL for their absolute item URLs")
+	}
+	if len(feed.Formats) == 0 {
+		msg := fmt.Sprintf("Feed has no Formats, use '%s'", FEED_FORMAT_JSON)
+		return errors.New(msg)
+	}
+	for _, format := range feed.Formats {
+		if format != FEED_FORMAT_JSON {
+			msg := fmt.Sprintf("unknown feed format '%s', use '%s'", format, FEED_FORMAT_JSON)
+			return errors.New(msg)
+		}
+	}
+	if feed.Limit < 0 {
+		msg := fmt.Sprintf("Feed Limit must not be negative, not %d", feed.Limit)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func feedSources(sources []Source, limit int) []Source {
+	if limit <= 0 {
+		limit = DEFAULT_FEED_LIMIT
+	}
+	var items []Source
+	for _, source := range sources {
+		if isListed(source) && !source.Stale {
+			items = append(items, source)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Link.Date > items[j].Link.Date
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func feedDate(source Source, configuration Configuration) string {
+	if !source.Published.IsZero() {
+		return source.Published.Format(time.RFC3339)
+	}
+	date := source.Link.Date
+	if len(date) == 0 || date == ZERO_DATE {
+		return ""
+	}
+	location, err := loadLocation(configuration)
+	if err != nil || location == nil {
+		location = time.UTC
+	}
+	published, err := time.ParseInLocation(DATE_DISPLAY_FORMAT, date, location)
+	if err != nil {
+		return ""
+	}
+	return published.Format(time.RFC3339)
+}
+
+func renderJsonFeed(configuration Configuration, sources []Source, site *Site) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     JSON_FEED_VERSION,
+		Title:       configuration.Feed.Title,
+		HomePageUrl: absoluteUrl(configuration.BaseURL, "/"),
+		FeedUrl:     absoluteUrl(configuration.BaseURL, JSON_FEED_FILE_NAME),
+		Language:    configuration.Language,
+		Items:       []jsonFeedItem{},
+	}
+	for _, source := range feedSources(sources, configuration.Feed.Limit) {
+		content, err := sourceContent(configuration, source, site)
+		if err != nil {
+			msg := fmt.Sprintf("%s: %s", source.Path, err)
+			return nil, errors.New(msg)
+		}
+		url := absoluteUrl(configuration.BaseURL, source.Link.Url)
+		item := jsonFeedItem{
+			Id:            url,
+			Url:           url,
+			Title:         source.Link.Title,
+			ContentHtml:   content,
+			DatePublished: feedDate(source, configuration),
+			Tags:          source.Link.Tags,
+		}
+		if configuration.Feed.DateModified && !source.Updated.IsZero() {
+			item.DateModified = source.Updated.Format(time.RFC3339)
+		}
+		if source.Link.Lang != configuration.Language {
+			item.Language = source.Link.Lang
+		}
+		for _, author := range source.Link.Authors {
+			feedAuthor := jsonFeedAuthor{Name: author.Name}
+			if len(author.Mail) > 0 {
+				feedAuthor.Url = "mailto:" + author.Mail
+			}
+			item.Authors = append(item.Authors, feedAuthor)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(feed)
+	return buffer.Bytes(), err
+}
+
+func renderFeeds(configuration Configuration, output OutputWriter, sources []Source, site *Site) error {
+	var err error
+	if feedFormats(configuration)[FEED_FORMAT_JSON] {
+		var data []byte
+		data, err = renderJsonFeed(configuration, sources, site)
+		if err == nil {
+			err = writeFile(output, JSON_FEED_FILE_NAME, data)
+		}
+	}
+	return err
+}
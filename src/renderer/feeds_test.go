@@ -0,0 +1,47 @@
+package renderer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFeedDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		date     string
+		want     string
+	}{
+		{"utc date on a utc site", "", "2024-06-01T00:00:00Z", "2024-06-01T00:00:00Z"},
+		{"utc date on a +10:00 site", "Australia/Brisbane", "2024-06-01T00:00:00Z", "2024-06-01T10:00:00+10:00"},
+		{"time of day is kept", "", "2024-06-01T18:30:15Z", "2024-06-01T18:30:15Z"},
+		{"offset date on a utc site", "", "2024-06-01T08:00:00+02:00", "2024-06-01T08:00:00+02:00"},
+		{"floating date takes the site zone", "Australia/Brisbane", "2024-06-01T09:00:00", "2024-06-01T09:00:00+10:00"},
+		{"no date", "", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.BaseURL = "https://example.com/"
+			site.Configuration.Timezone = test.timezone
+			site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}}
+			meta := `{"Title": "A"}`
+			if len(test.date) > 0 {
+				meta = `{"Title": "A", "Date": "` + test.date + `"}`
+			}
+			site.page("a.md", meta, "body\n")
+			site.mustBuild()
+			var feed jsonFeed
+			err := json.Unmarshal([]byte(site.read(JSON_FEED_FILE_NAME)), &feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(feed.Items) != 1 {
+				t.Fatalf("feed has %d items, want 1", len(feed.Items))
+			}
+			if feed.Items[0].DatePublished != test.want {
+				t.Errorf("date_published = %q, want %q", feed.Items[0].DatePublished, test.want)
+			}
+		})
+	}
+}
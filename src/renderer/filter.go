@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"path"
+	"strings"
+)
+
+const PATTERN_ANY_DEPTH = "**"
+
+func matchSegments(patternParts []string, pathParts []string) bool {
+	if len(patternParts) == 0 {
+		return len(pathParts) == 0
+	}
+	if patternParts[0] == PATTERN_ANY_DEPTH {
+		for skip := 0; skip <= len(pathParts); skip++ {
+			if matchSegments(patternParts[1:], pathParts[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pathParts) == 0 {
+		return false
+	}
+	matched, err := path.Match(patternParts[0], pathParts[0])
+	return err == nil && matched && matchSegments(patternParts[1:], pathParts[1:])
+}
+
+func matchPattern(pattern string, relativePath string, isDir bool) bool {
+	directoryOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if len(pattern) == 0 || (directoryOnly && !isDir) {
+		return false
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pathParts := strings.Split(relativePath, "/")
+	if !anchored {
+		return matchSegments([]string{pattern}, pathParts[len(pathParts)-1:])
+	}
+	return matchSegments(strings.Split(pattern, "/"), pathParts)
+}
+
+func matchAny(patterns []string, relativePath string, isDir bool) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, relativePath, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func isExcluded(configuration Configuration, relativePath string, isDir bool) bool {
+	if matchAny(configuration.Exclude, relativePath, isDir) {
+		return true
+	}
+	return !isDir && len(configuration.Include) > 0 && !matchAny(configuration.Include, relativePath, false)
+}
+
+func isExcludedPath(configuration Configuration, relativePath string) bool {
+	parts := strings.Split(relativePath, "/")
+	for depth := 1; depth < len(parts); depth++ {
+		if isExcluded(configuration, strings.Join(parts[:depth], "/"), true) {
+			return true
+		}
+	}
+	return isExcluded(configuration, relativePath, false)
+}
@@ -0,0 +1,115 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+)
+
+const DEFAULT_FOOTNOTE_RETURN_TEXT = "&#x21A9;&#xFE0E;"
+const DEFAULT_FOOTNOTE_RETURN_CLASS = "footnote-return"
+
+type FootnoteReturnLink struct {
+	Text  string
+	Class string
+}
+
+type footnoteIndex struct {
+	counts map[int]int
+	items  map[ast.Node]int
+}
+
+func footnotePrefix(name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+	return Slugify(strings.TrimSuffix(name, MARKDOWN_FILE_ENDING)) + ":"
+}
+
+func collectFootnotes(document ast.Node) footnoteIndex {
+	footnotes := footnoteIndex{counts: map[int]int{}, items: map[ast.Node]int{}}
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		link, isLink := node.(*ast.Link)
+		if entering && isLink && link.NoteID != 0 {
+			footnotes.counts[link.NoteID]++
+			if link.Footnote != nil {
+				footnotes.items[link.Footnote] = link.NoteID
+			}
+		}
+		return ast.GoToNext
+	})
+	return footnotes
+}
+
+func footnoteRefId(prefix string, id int, occurrence int) string {
+	refId := fmt.Sprintf("fnref:%s%d", prefix, id)
+	if occurrence > 1 {
+		refId += fmt.Sprintf(":%d", occurrence)
+	}
+	return refId
+}
+
+func footnoteReturnLinks(prefix string, id int, count int, returnLink FootnoteReturnLink, registry *idRegistry) string {
+	var links strings.Builder
+	text := returnLink.Text
+	if len(text) == 0 {
+		text = DEFAULT_FOOTNOTE_RETURN_TEXT
+	}
+	class := returnLink.Class
+	if len(class) == 0 {
+		class = DEFAULT_FOOTNOTE_RETURN_CLASS
+	}
+	for occurrence := 1; occurrence <= count; occurrence++ {
+		label := text
+		if occurrence > 1 {
+			label += fmt.Sprintf("<sup>%d</sup>", occurrence)
+		}
+		fmt.Fprintf(&links, ` <a class="%s" href="#%s">%s</a>`, class, registry.idFor(footnoteRefId(prefix, id, occurrence)), label)
+	}
+	return links.String()
+}
+
+func footnoteNoteId(prefix string, id int) string {
+	return fmt.Sprintf("fn:%s%d", prefix, id)
+}
+
+func footnoteHook(footnotes footnoteIndex, prefix string, returnLink FootnoteReturnLink, registry *idRegistry) nodeHook {
+	seen := map[int]int{}
+	return func(renderer *html.Renderer, writer io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		switch typed := node.(type) {
+		case *ast.Link:
+			if typed.NoteID == 0 {
+				return ast.GoToNext, false
+			}
+			if entering {
+				seen[typed.NoteID]++
+				refId := registry.idFor(footnoteRefId(prefix, typed.NoteID, seen[typed.NoteID]))
+				noteId := registry.idFor(footnoteNoteId(prefix, typed.NoteID))
+				fmt.Fprintf(writer, `<sup class="footnote-ref" id="%s"><a href="#%s">%d</a></sup>`, refId, noteId, typed.NoteID)
+			}
+			return ast.SkipChildren, true
+		case *ast.ListItem:
+			id, found := footnotes.items[node]
+			if !found {
+				return ast.GoToNext, false
+			}
+			var buffer bytes.Buffer
+			renderer.ListItem(&buffer, typed, entering)
+			if entering {
+				rendered := buffer.Bytes()
+				start := bytes.Index(rendered, []byte("<li"))
+				writer.Write(rendered[:start])
+				fmt.Fprintf(writer, `<li id="%s">`, registry.idFor(footnoteNoteId(prefix, id)))
+			} else {
+				io.WriteString(writer, footnoteReturnLinks(prefix, id, footnotes.counts[id], returnLink, registry))
+				writer.Write(buffer.Bytes())
+			}
+			return ast.GoToNext, true
+		}
+		return ast.GoToNext, false
+	}
+}
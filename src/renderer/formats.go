@@ -0,0 +1,118 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const FORMAT_HTML = "html"
+const FORMAT_JSON = "json"
+const FORMAT_TEXT = "txt"
+const JSON_FILE_ENDING = ".json"
+
+var KNOWN_FORMATS = []string{FORMAT_HTML, FORMAT_JSON, FORMAT_TEXT}
+
+type pageDocument struct {
+	Title    string
+	Date     string
+	Authors  []Author
+	Category string
+	Tags     []string
+	Url      string
+	Content  string
+	Lang     string `json:",omitempty"`
+}
+
+func containsFormat(formats []string, format string) bool {
+	for _, known := range formats {
+		if known == format {
+			return true
+		}
+	}
+	return false
+}
+
+func siteFormats(configuration Configuration) []string {
+	formats := configuration.Outputs
+	if len(formats) == 0 {
+		formats = []string{FORMAT_HTML}
+	}
+	if configuration.OutputText && !containsFormat(formats, FORMAT_TEXT) {
+		formats = append(append([]string(nil), formats...), FORMAT_TEXT)
+	}
+	return formats
+}
+
+func checkFormats(configuration Configuration) error {
+	for _, format := range configuration.Outputs {
+		if !containsFormat(KNOWN_FORMATS, format) {
+			msg := fmt.Sprintf("unknown output format '%s', expected one of %s", format, strings.Join(KNOWN_FORMATS, ", "))
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func pageFormats(configuration Configuration, metaBlock MetaBlock) ([]string, error) {
+	enabled := siteFormats(configuration)
+	if len(metaBlock.Outputs) == 0 {
+		return enabled, nil
+	}
+	for _, format := range metaBlock.Outputs {
+		if !containsFormat(enabled, format) {
+			msg := fmt.Sprintf("output format '%s' is not enabled site-wide, enabled are %s", format, strings.Join(enabled, ", "))
+			return nil, errors.New(msg)
+		}
+	}
+	return metaBlock.Outputs, nil
+}
+
+func formatOutputPath(outputPath string, format string) string {
+	switch format {
+	case FORMAT_TEXT:
+		return textOutputPath(outputPath)
+	case FORMAT_JSON:
+		return strings.TrimSuffix(outputPath, ".html") + JSON_FILE_ENDING
+	}
+	return outputPath
+}
+
+func variantPaths(source Source) []string {
+	var paths []string
+	for _, format := range source.Formats {
+		paths = append(paths, formatOutputPath(source.OutputPath, format))
+	}
+	return paths
+}
+
+func outputsExist(configuration Configuration, source Source) bool {
+	for _, path := range variantPaths(source) {
+		if CheckPathError(filepath.Join(configuration.Output, filepath.FromSlash(path))) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func renderJson(page Page, url string) ([]byte, error) {
+	document := pageDocument{
+		Title:    page.Title,
+		Date:     page.Date,
+		Authors:  page.Authors,
+		Category: page.Category,
+		Tags:     page.Tags,
+		Url:      url,
+		Content:  page.Content,
+		Lang:     page.Lang,
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(document)
+	return buffer.Bytes(), err
+}
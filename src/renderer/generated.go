@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+)
+
+type GeneratedPage struct {
+	Path      string
+	Url       string
+	Owner     string
+	Date      string
+	Auxiliary bool
+	Sitemap   bool
+}
+
+func generatedPages(configuration Configuration, built map[string][]TaxonomyTerm, letters []AZLetter) []GeneratedPage {
+	var pages []GeneratedPage
+	if termPagesEnabled(configuration) {
+		var names []string
+		for name := range built {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, term := range built[name] {
+				_, newest := dateRange(term.Links)
+				pages = append(pages, GeneratedPage{
+					Path:      termOutputPath(name, term.Slug),
+					Url:       term.Url,
+					Owner:     fmt.Sprintf("the %s listing of '%s'", name, term.Name),
+					Date:      newest,
+					Auxiliary: true,
+				})
+			}
+		}
+	}
+	pages = append(pages, azPages(configuration, letters)...)
+	for _, extra := range configuration.ExtraOutputs {
+		pages = append(pages, GeneratedPage{
+			Path:  extra.Output,
+			Url:   outputUrl(extra.Output),
+			Owner: fmt.Sprintf("the extra output '%s'", extra.Output),
+		})
+	}
+	return pages
+}
+
+func sitemapLinks(configuration Configuration, links []Link, pages []GeneratedPage) []Link {
+	listed := append([]Link(nil), links...)
+	for _, page := range pages {
+		if page.Sitemap || page.Auxiliary && configuration.SitemapListings {
+			listed = append(listed, Link{Url: page.Url, Date: page.Date})
+		}
+	}
+	return listed
+}
+
+func markAuxiliary(output *manifestOutput, pages []GeneratedPage) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	for _, page := range pages {
+		if entry, written := output.manifest.Files[page.Path]; written && page.Auxiliary {
+			entry.Auxiliary = true
+			output.manifest.Files[page.Path] = entry
+		}
+	}
+}
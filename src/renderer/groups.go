@@ -0,0 +1,45 @@
+package renderer
+
+const DEFAULT_GROUP_NAME = "Uncategorized"
+
+type Group struct {
+	Name  string
+	Links []Link
+}
+
+func groupLinks(links []Link, order []string, defaultName string) []Group {
+	if len(defaultName) == 0 {
+		defaultName = DEFAULT_GROUP_NAME
+	}
+	var names []string
+	grouped := map[string][]Link{}
+	for _, link := range links {
+		name := link.Category
+		if len(name) == 0 {
+			name = defaultName
+		}
+		if _, found := grouped[name]; !found {
+			names = append(names, name)
+		}
+		grouped[name] = append(grouped[name], link)
+	}
+
+	var groups []Group
+	placed := map[string]bool{}
+	for _, name := range order {
+		if members, found := grouped[name]; found && !placed[name] {
+			groups = append(groups, Group{name, members})
+			placed[name] = true
+		}
+	}
+	for _, name := range names {
+		if !placed[name] && name != defaultName {
+			groups = append(groups, Group{name, grouped[name]})
+			placed[name] = true
+		}
+	}
+	if members, found := grouped[defaultName]; found && !placed[defaultName] {
+		groups = append(groups, Group{defaultName, members})
+	}
+	return groups
+}
@@ -0,0 +1,137 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+const DEFAULT_TOP_HEADING_LEVEL = 1
+const MAX_HEADING_LEVEL = 6
+
+var EXPLICIT_HEADING_ID = regexp.MustCompile(`[ \t]*\{#([^{}\s]+)\}[ \t]*$`)
+
+type Heading struct {
+	Level int
+	Text  string
+}
+
+func topHeadingLevel(configuration Configuration) int {
+	if configuration.TopHeadingLevel <= 0 {
+		return DEFAULT_TOP_HEADING_LEVEL
+	}
+	return configuration.TopHeadingLevel
+}
+
+func checkTopHeadingLevel(configuration Configuration) error {
+	if configuration.TopHeadingLevel < 0 || configuration.TopHeadingLevel > MAX_HEADING_LEVEL {
+		msg := fmt.Sprintf("TopHeadingLevel must be between 1 and %d, not %d", MAX_HEADING_LEVEL, configuration.TopHeadingLevel)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func headingText(heading ast.Node) string {
+	var builder strings.Builder
+	ast.WalkFunc(heading, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch typed := node.(type) {
+		case *ast.Text:
+			builder.Write(typed.Literal)
+		case *ast.Code:
+			builder.Write(typed.Literal)
+		}
+		return ast.GoToNext
+	})
+	return strings.TrimSpace(builder.String())
+}
+
+func explicitHeadingIds(document ast.Node, enabled bool) map[*ast.Heading]bool {
+	explicit := map[*ast.Heading]bool{}
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if !entering || !isHeading || len(heading.Children) == 0 {
+			return ast.GoToNext
+		}
+		text, isText := heading.Children[len(heading.Children)-1].(*ast.Text)
+		if !isText {
+			return ast.GoToNext
+		}
+		match := EXPLICIT_HEADING_ID.FindSubmatchIndex(text.Literal)
+		if match == nil {
+			return ast.GoToNext
+		}
+		id := string(text.Literal[match[2]:match[3]])
+		text.Literal = text.Literal[:match[0]]
+		if enabled {
+			heading.HeadingID = id
+			explicit[heading] = true
+		}
+		return ast.GoToNext
+	})
+	return explicit
+}
+
+func normalizeHeadings(document ast.Node, top int) {
+	var headings []*ast.Heading
+	lowest := MAX_HEADING_LEVEL + 1
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if entering && isHeading {
+			headings = append(headings, heading)
+			if heading.Level < lowest {
+				lowest = heading.Level
+			}
+		}
+		return ast.GoToNext
+	})
+	shift := top - lowest
+	for _, heading := range headings {
+		level := heading.Level + shift
+		if level > MAX_HEADING_LEVEL {
+			level = MAX_HEADING_LEVEL
+		}
+		heading.Level = level
+	}
+}
+
+func collectHeadings(document ast.Node) []Heading {
+	var headings []Heading
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if entering && isHeading {
+			headings = append(headings, Heading{Level: heading.Level, Text: headingText(heading)})
+		}
+		return ast.GoToNext
+	})
+	return headings
+}
+
+func outlineProblems(headings []Heading, top int) []string {
+	var problems []string
+	if len(headings) == 0 {
+		return problems
+	}
+	if headings[0].Level != top {
+		problems = append(problems, fmt.Sprintf("first heading '%s' is h%d, expected h%d", headings[0].Text, headings[0].Level, top))
+	}
+	for index := 1; index < len(headings); index++ {
+		previous := headings[index-1].Level
+		current := headings[index]
+		if current.Level > previous+1 {
+			problems = append(problems, fmt.Sprintf("heading '%s' jumps from h%d to h%d", current.Text, previous, current.Level))
+		}
+	}
+	return problems
+}
+
+func checkOutline(builder *Builder, entry *pageLog, source Source) {
+	if !builder.Configuration.OutlineLint {
+		return
+	}
+	for _, problem := range outlineProblems(source.Headings, topHeadingLevel(builder.Configuration)) {
+		builder.reportTo(entry, CONDITION_HEADING_OUTLINE, source.Path, problem)
+	}
+}
@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const TEST_PAGE_TEMPLATE = "<html><head><title>{{.Title}}</title></head><body>{{.Content}}</body></html>\n"
+const TEST_INDEX_TEMPLATE = "<html><body>{{range .Links}}<a href=\"{{.Url}}\">{{.Title}}</a>\n{{end}}</body></html>\n"
+
+func TestMain(m *testing.M) {
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+type testSite struct {
+	t             *testing.T
+	directory     string
+	Configuration Configuration
+}
+
+func newTestSite(t *testing.T) *testSite {
+	directory := t.TempDir()
+	site := &testSite{t: t, directory: directory}
+	site.Configuration = Configuration{
+		Input:         filepath.Join(directory, "content"),
+		Output:        filepath.Join(directory, "public"),
+		TemplatePage:  filepath.Join(directory, "page.html"),
+		TemplateIndex: filepath.Join(directory, "index.html"),
+	}
+	site.writeFile(site.Configuration.TemplatePage, TEST_PAGE_TEMPLATE)
+	site.writeFile(site.Configuration.TemplateIndex, TEST_INDEX_TEMPLATE)
+	err := os.MkdirAll(site.Configuration.Output, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return site
+}
+
+func (site *testSite) writeFile(path string, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+	}
+	if err != nil {
+		site.t.Fatal(err)
+	}
+}
+
+func (site *testSite) write(name string, content string) {
+	site.writeFile(filepath.Join(site.Configuration.Input, filepath.FromSlash(name)), content)
+}
+
+func (site *testSite) page(name string, meta string, body string) {
+	site.write(name, META_BLOCK_START+meta+"\n"+META_BLOCK_END+body)
+}
+
+func (site *testSite) remove(name string) {
+	err := os.Remove(filepath.Join(site.Configuration.Input, filepath.FromSlash(name)))
+	if err != nil {
+		site.t.Fatal(err)
+	}
+}
+
+func (site *testSite) path(name string) string {
+	return filepath.Join(site.directory, filepath.FromSlash(name))
+}
+
+func (site *testSite) build() error {
+	return NewBuilder(site.Configuration).Build()
+}
+
+func (site *testSite) mustBuild() {
+	err := site.build()
+	if err != nil {
+		site.t.Fatal(err)
+	}
+}
+
+func (site *testSite) exists(name string) bool {
+	return CheckPathError(filepath.Join(site.Configuration.Output, filepath.FromSlash(name))) == nil
+}
+
+func (site *testSite) read(name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(site.Configuration.Output, filepath.FromSlash(name)))
+	if err != nil {
+		site.t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,290 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+const WEB_MANIFEST_FILE_NAME = "site.webmanifest"
+const FAVICON_FILE_NAME = "favicon.ico"
+const SVG_ICON_FILE_NAME = "icon.svg"
+
+var FAVICON_SIZES = []int{16, 32, 48}
+
+var PNG_ICONS = []pngIcon{
+	{Name: "favicon-32x32.png", Size: 32, Rel: "icon"},
+	{Name: "apple-touch-icon.png", Size: 180, Rel: "apple-touch-icon"},
+	{Name: "android-chrome-192x192.png", Size: 192, Manifest: true},
+	{Name: "android-chrome-512x512.png", Size: 512, Manifest: true},
+}
+
+type Icons struct {
+	Source          string
+	Name            string
+	ShortName       string
+	ThemeColor      string
+	BackgroundColor string
+}
+
+type IconLink struct {
+	Rel   string
+	Type  string
+	Sizes string
+	Href  string
+}
+
+type pngIcon struct {
+	Name     string
+	Size     int
+	Rel      string
+	Manifest bool
+}
+
+type webManifest struct {
+	Name            string            `json:"name,omitempty"`
+	ShortName       string            `json:"short_name,omitempty"`
+	Icons           []webManifestIcon `json:"icons"`
+	ThemeColor      string            `json:"theme_color,omitempty"`
+	BackgroundColor string            `json:"background_color,omitempty"`
+}
+
+type webManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+func iconSourcePath(configuration Configuration) string {
+	source := configuration.Icons.Source
+	if filepath.IsAbs(source) {
+		return source
+	}
+	return filepath.Join(configuration.Input, filepath.FromSlash(source))
+}
+
+func isSvgIcon(configuration Configuration) bool {
+	return strings.ToLower(filepath.Ext(configuration.Icons.Source)) == ".svg"
+}
+
+func checkIcons(configuration Configuration) error {
+	icons := configuration.Icons
+	if icons == nil {
+		return nil
+	}
+	if configuration.OutputMode == OUTPUT_MODE_BOOK {
+		return errors.New("icons are not written in book output mode")
+	}
+	if len(icons.Source) == 0 {
+		return errors.New("Icons has no Source image")
+	}
+	switch strings.ToLower(filepath.Ext(icons.Source)) {
+	case ".png", ".jpg", ".jpeg", ".svg":
+	default:
+		msg := fmt.Sprintf("Icons Source '%s' must be a PNG, JPEG or SVG image", icons.Source)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func iconOutputs(configuration Configuration) []string {
+	if configuration.Icons == nil {
+		return nil
+	}
+	if isSvgIcon(configuration) {
+		return []string{SVG_ICON_FILE_NAME, WEB_MANIFEST_FILE_NAME}
+	}
+	outputs := []string{FAVICON_FILE_NAME}
+	for _, icon := range PNG_ICONS {
+		outputs = append(outputs, icon.Name)
+	}
+	return append(outputs, WEB_MANIFEST_FILE_NAME)
+}
+
+func iconUrl(configuration Configuration, name string) string {
+	url, err := rootRelativeUrl(configuration.BaseURL, name)
+	if err != nil {
+		url = "/" + name
+	}
+	return url
+}
+
+func iconLinks(configuration Configuration) []IconLink {
+	if configuration.Icons == nil {
+		return nil
+	}
+	var links []IconLink
+	if isSvgIcon(configuration) {
+		links = append(links, IconLink{Rel: "icon", Type: "image/svg+xml", Href: iconUrl(configuration, SVG_ICON_FILE_NAME)})
+	} else {
+		links = append(links, IconLink{Rel: "icon", Sizes: "any", Href: iconUrl(configuration, FAVICON_FILE_NAME)})
+		for _, icon := range PNG_ICONS {
+			if len(icon.Rel) > 0 {
+				sizes := fmt.Sprintf("%dx%d", icon.Size, icon.Size)
+				links = append(links, IconLink{Rel: icon.Rel, Type: "image/png", Sizes: sizes, Href: iconUrl(configuration, icon.Name)})
+			}
+		}
+	}
+	return append(links, IconLink{Rel: "manifest", Href: iconUrl(configuration, WEB_MANIFEST_FILE_NAME)})
+}
+
+func iconTags(configuration Configuration) htmlTemplate.HTML {
+	var builder strings.Builder
+	for _, link := range iconLinks(configuration) {
+		builder.WriteString(`<link rel="` + htmlTemplate.HTMLEscapeString(link.Rel) + `"`)
+		if len(link.Type) > 0 {
+			builder.WriteString(` type="` + htmlTemplate.HTMLEscapeString(link.Type) + `"`)
+		}
+		if len(link.Sizes) > 0 {
+			builder.WriteString(` sizes="` + htmlTemplate.HTMLEscapeString(link.Sizes) + `"`)
+		}
+		builder.WriteString(` href="` + htmlTemplate.HTMLEscapeString(link.Href) + "\">\n")
+	}
+	if configuration.Icons != nil && len(configuration.Icons.ThemeColor) > 0 {
+		builder.WriteString(`<meta name="theme-color" content="` + htmlTemplate.HTMLEscapeString(configuration.Icons.ThemeColor) + "\">\n")
+	}
+	return htmlTemplate.HTML(builder.String())
+}
+
+func squareImage(picture image.Image) image.Image {
+	bounds := picture.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	left := bounds.Min.X + (bounds.Dx()-side)/2
+	top := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), picture, image.Pt(left, top), draw.Src)
+	return square
+}
+
+func encodePng(picture image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := png.Encode(&buffer, picture)
+	return buffer.Bytes(), err
+}
+
+func encodeFavicon(picture image.Image) ([]byte, error) {
+	var images [][]byte
+	for _, size := range FAVICON_SIZES {
+		data, err := encodePng(downscale(picture, size))
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, data)
+	}
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, []uint16{0, 1, uint16(len(images))})
+	offset := 6 + 16*len(images)
+	for index, data := range images {
+		size := uint8(FAVICON_SIZES[index])
+		buffer.Write([]byte{size, size, 0, 0})
+		binary.Write(&buffer, binary.LittleEndian, []uint16{1, 32})
+		binary.Write(&buffer, binary.LittleEndian, []uint32{uint32(len(data)), uint32(offset)})
+		offset += len(data)
+	}
+	for _, data := range images {
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
+
+func renderWebManifest(configuration Configuration) ([]byte, error) {
+	icons := configuration.Icons
+	manifest := webManifest{
+		Name:            icons.Name,
+		ShortName:       icons.ShortName,
+		Icons:           []webManifestIcon{},
+		ThemeColor:      icons.ThemeColor,
+		BackgroundColor: icons.BackgroundColor,
+	}
+	if len(manifest.Name) == 0 && configuration.Feed != nil {
+		manifest.Name = configuration.Feed.Title
+	}
+	if isSvgIcon(configuration) {
+		manifest.Icons = append(manifest.Icons, webManifestIcon{Src: iconUrl(configuration, SVG_ICON_FILE_NAME), Sizes: "any", Type: "image/svg+xml"})
+	}
+	for _, icon := range PNG_ICONS {
+		if icon.Manifest && !isSvgIcon(configuration) {
+			sizes := fmt.Sprintf("%dx%d", icon.Size, icon.Size)
+			manifest.Icons = append(manifest.Icons, webManifestIcon{Src: iconUrl(configuration, icon.Name), Sizes: sizes, Type: "image/png"})
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	return append(data, '\n'), err
+}
+
+func iconsExist(configuration Configuration) bool {
+	for _, name := range iconOutputs(configuration) {
+		if name != WEB_MANIFEST_FILE_NAME && CheckPathError(filepath.Join(configuration.Output, name)) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func generateIcons(configuration Configuration, output OutputWriter, data []byte) error {
+	if isSvgIcon(configuration) {
+		return writeFile(output, SVG_ICON_FILE_NAME, data)
+	}
+	picture, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	square := squareImage(picture)
+	favicon, err := encodeFavicon(square)
+	if err == nil {
+		err = writeFile(output, FAVICON_FILE_NAME, favicon)
+	}
+	for _, icon := range PNG_ICONS {
+		if err != nil {
+			break
+		}
+		var encoded []byte
+		encoded, err = encodePng(downscale(square, icon.Size))
+		if err == nil {
+			err = writeFile(output, icon.Name, encoded)
+		}
+	}
+	return err
+}
+
+func renderIcons(configuration Configuration, output *manifestOutput, reuse bool, previousHash string) (string, error) {
+	if configuration.Icons == nil {
+		return "", nil
+	}
+	sourcePath := iconSourcePath(configuration)
+	data, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return "", err
+	}
+	hash := hashContent(data)
+	if reuse && hash == previousHash && iconsExist(configuration) {
+		for _, name := range iconOutputs(configuration) {
+			if err == nil && name != WEB_MANIFEST_FILE_NAME {
+				err = output.addExisting(configuration.Output, name)
+			}
+		}
+	} else {
+		log.Print("generating icons: ", sourcePath)
+		err = generateIcons(configuration, output, data)
+	}
+	if err == nil {
+		data, err = renderWebManifest(configuration)
+	}
+	if err == nil {
+		err = writeFile(output, WEB_MANIFEST_FILE_NAME, data)
+	}
+	return hash, err
+}
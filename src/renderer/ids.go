@@ -0,0 +1,231 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+type idRegistry struct {
+	used     map[string]bool
+	assigned map[string]string
+}
+
+func newIdRegistry() *idRegistry {
+	return &idRegistry{used: map[string]bool{}, assigned: map[string]string{}}
+}
+
+func (registry *idRegistry) allocate(id string) string {
+	unique := id
+	for suffix := 1; registry.used[unique]; suffix++ {
+		unique = fmt.Sprintf("%s-%d", id, suffix)
+	}
+	registry.used[unique] = true
+	return unique
+}
+
+func (registry *idRegistry) idFor(key string) string {
+	id, found := registry.assigned[key]
+	if !found {
+		id = registry.allocate(key)
+		registry.assigned[key] = id
+	}
+	return id
+}
+
+func allocateHeadingIds(document ast.Node, registry *idRegistry, explicit map[*ast.Heading]bool) error {
+	var err error
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if entering && isHeading && explicit[heading] {
+			heading.HeadingID = normalizeName(heading.HeadingID)
+			if registry.used[heading.HeadingID] && err == nil {
+				msg := fmt.Sprintf("heading id '%s' is set on more than one heading", heading.HeadingID)
+				err = errors.New(msg)
+			}
+			registry.used[heading.HeadingID] = true
+		}
+		return ast.GoToNext
+	})
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if entering && isHeading && !explicit[heading] && len(heading.HeadingID) > 0 {
+			heading.HeadingID = registry.allocate(heading.HeadingID)
+		}
+		return ast.GoToNext
+	})
+	return err
+}
+
+func attributeValue(attributes string, name string) (string, bool) {
+	lower := strings.ToLower(attributes)
+	for offset := 0; offset < len(lower); {
+		index := strings.Index(lower[offset:], name)
+		if index == -1 {
+			break
+		}
+		index += offset
+		offset = index + len(name)
+		if index > 0 && !strings.ContainsAny(lower[index-1:index], " \t\r\n\"'/") {
+			continue
+		}
+		rest := strings.TrimLeft(attributes[offset:], " \t\r\n")
+		if !strings.HasPrefix(rest, "=") {
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end != -1 {
+				return rest[1 : end+1], true
+			}
+			return rest[1:], true
+		}
+		end := strings.IndexAny(rest, " \t\r\n>")
+		if end == -1 {
+			end = len(rest)
+		}
+		return rest[:end], true
+	}
+	return "", false
+}
+
+func duplicateIds(markup string) []string {
+	var duplicates []string
+	counts := map[string]int{}
+	offset := 0
+	for offset < len(markup) {
+		start := strings.IndexByte(markup[offset:], '<')
+		if start == -1 {
+			break
+		}
+		start += offset
+		if strings.HasPrefix(markup[start:], HTML_COMMENT_START) {
+			end := strings.Index(markup[start:], HTML_COMMENT_END)
+			if end == -1 {
+				break
+			}
+			offset = start + end + len(HTML_COMMENT_END)
+			continue
+		}
+		next := start + 1
+		if next >= len(markup) || !isTagStart(markup[next]) || markup[next] == '/' {
+			offset = next
+			continue
+		}
+		end := tagEnd(markup, next)
+		if end == -1 {
+			break
+		}
+		tag := markup[next:end]
+		name, _ := tagName(tag)
+		id, found := attributeValue(tag[len(name):], "id")
+		if found && len(id) > 0 {
+			counts[id]++
+			if counts[id] == 2 {
+				duplicates = append(duplicates, id)
+			}
+		}
+		offset = end + 1
+		if SKIPPED_TAGS[name] {
+			closing := strings.Index(strings.ToLower(markup[offset:]), "</"+name)
+			if closing == -1 {
+				break
+			}
+			offset += closing
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+type idCheckOutput struct {
+	output     OutputWriter
+	mutex      sync.Mutex
+	duplicates map[string][]string
+}
+
+type idCheckWriter struct {
+	io.WriteCloser
+	path   string
+	buffer bytes.Buffer
+	parent *idCheckOutput
+}
+
+func newIdCheckOutput(output OutputWriter) *idCheckOutput {
+	return &idCheckOutput{output: output, duplicates: map[string][]string{}}
+}
+
+func (output *idCheckOutput) Create(path string) (io.WriteCloser, error) {
+	file, err := output.output.Create(path)
+	if err != nil || !strings.HasSuffix(path, ".html") {
+		return file, err
+	}
+	return &idCheckWriter{WriteCloser: file, path: filepath.ToSlash(path), parent: output}, nil
+}
+
+func (output *idCheckOutput) Finish() error {
+	return output.output.Finish()
+}
+
+func (output *idCheckOutput) take(path string) []string {
+	if output == nil {
+		return nil
+	}
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	duplicates := output.duplicates[path]
+	delete(output.duplicates, path)
+	return duplicates
+}
+
+func (output *idCheckOutput) paths() []string {
+	if output == nil {
+		return nil
+	}
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	var paths []string
+	for path := range output.duplicates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (writer *idCheckWriter) Write(data []byte) (int, error) {
+	written, err := writer.WriteCloser.Write(data)
+	writer.buffer.Write(data[:written])
+	return written, err
+}
+
+func (writer *idCheckWriter) Close() error {
+	err := writer.WriteCloser.Close()
+	duplicates := duplicateIds(writer.buffer.String())
+	if err == nil && len(duplicates) > 0 {
+		writer.parent.mutex.Lock()
+		writer.parent.duplicates[writer.path] = duplicates
+		writer.parent.mutex.Unlock()
+	}
+	return err
+}
+
+func checkIds(builder *Builder, entry *pageLog, checks *idCheckOutput, path string) {
+	for _, id := range checks.take(path) {
+		detail := fmt.Sprintf("id '%s' is used more than once", id)
+		builder.reportTo(entry, CONDITION_DUPLICATE_ID, path, detail)
+	}
+}
+
+func checkRemainingIds(builder *Builder, checks *idCheckOutput) {
+	for _, path := range checks.paths() {
+		checkIds(builder, nil, checks, path)
+	}
+}
@@ -0,0 +1,340 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+)
+
+const IMAGE_WIDTH_SUFFIX = "-%dw"
+const DEFAULT_IMAGE_SIZES = "100vw"
+const DEFAULT_IMAGE_QUALITY = 85
+
+var RASTER_IMAGE_EXTENSIONS = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+}
+
+type ImageOptions struct {
+	Widths  []int
+	Sizes   string
+	Quality int
+}
+
+type ImageVariant struct {
+	Path  string
+	Width int
+}
+
+type ImageSet struct {
+	Hash     string
+	Widths   []int
+	Width    int
+	Variants []ImageVariant
+}
+
+type imageProcessor struct {
+	configuration Configuration
+	output        *manifestOutput
+	reuse         bool
+	previous      map[string]ImageSet
+	sets          map[string]ImageSet
+	failed        map[string]bool
+	warnings      *warningLog
+	resources     map[string]string
+	mutex         sync.Mutex
+}
+
+func checkImages(configuration Configuration) error {
+	options := configuration.Images
+	if options == nil {
+		return nil
+	}
+	if len(options.Widths) == 0 {
+		return errors.New("images: Widths must list at least one width")
+	}
+	for _, width := range options.Widths {
+		if width <= 0 {
+			msg := fmt.Sprintf("images: width must be positive, not %d", width)
+			return errors.New(msg)
+		}
+	}
+	if options.Quality < 0 || options.Quality > 100 {
+		msg := fmt.Sprintf("images: quality must be between 1 and 100, or 0 for the default, not %d", options.Quality)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func imageWidths(options *ImageOptions) []int {
+	seen := map[int]bool{}
+	var widths []int
+	for _, width := range options.Widths {
+		if !seen[width] {
+			seen[width] = true
+			widths = append(widths, width)
+		}
+	}
+	sort.Ints(widths)
+	return widths
+}
+
+func variantName(name string, width int) string {
+	extension := path.Ext(name)
+	return strings.TrimSuffix(name, extension) + fmt.Sprintf(IMAGE_WIDTH_SUFFIX, width) + extension
+}
+
+func newImageProcessor(configuration Configuration, output *manifestOutput, reuse bool, previous map[string]ImageSet, warnings *warningLog, bundled []assetFile) *imageProcessor {
+	if previous == nil {
+		previous = map[string]ImageSet{}
+	}
+	resources := map[string]string{}
+	for _, asset := range bundled {
+		resources[asset.output] = asset.name
+	}
+	return &imageProcessor{
+		configuration: configuration,
+		output:        output,
+		reuse:         reuse,
+		previous:      previous,
+		sets:          map[string]ImageSet{},
+		failed:        map[string]bool{},
+		warnings:      warnings,
+		resources:     resources,
+	}
+}
+
+func readImageSource(configuration Configuration, target string) ([]byte, error) {
+	var data []byte
+	var err error
+	for _, root := range []string{configuration.Input, configuration.Output} {
+		data, err = ioutil.ReadFile(diskPath(root, target))
+		if err == nil {
+			break
+		}
+	}
+	return data, err
+}
+
+func downscale(source image.Image, width int) image.Image {
+	bounds := source.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	pixels := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(pixels, pixels.Bounds(), source, bounds.Min, draw.Src)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top := y * bounds.Dy() / height
+		bottom := (y + 1) * bounds.Dy() / height
+		if bottom <= top {
+			bottom = top + 1
+		}
+		for x := 0; x < width; x++ {
+			left := x * bounds.Dx() / width
+			right := (x + 1) * bounds.Dx() / width
+			if right <= left {
+				right = left + 1
+			}
+			var sum [4]int
+			for sourceY := top; sourceY < bottom; sourceY++ {
+				offset := pixels.PixOffset(left, sourceY)
+				for sourceX := left; sourceX < right; sourceX++ {
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(pixels.Pix[offset+channel])
+					}
+					offset += 4
+				}
+			}
+			count := (bottom - top) * (right - left)
+			offset := scaled.PixOffset(x, y)
+			for channel := 0; channel < 4; channel++ {
+				scaled.Pix[offset+channel] = uint8(sum[channel] / count)
+			}
+		}
+	}
+	return scaled
+}
+
+func encodeImage(writer io.Writer, picture image.Image, format string, quality int) error {
+	switch format {
+	case "jpeg":
+		if quality == 0 {
+			quality = DEFAULT_IMAGE_QUALITY
+		}
+		return jpeg.Encode(writer, picture, &jpeg.Options{Quality: quality})
+	case "png":
+		return png.Encode(writer, picture)
+	case "gif":
+		return gif.Encode(writer, picture, nil)
+	}
+	msg := fmt.Sprintf("cannot encode %s images", format)
+	return errors.New(msg)
+}
+
+func (processor *imageProcessor) variantsExist(set ImageSet) bool {
+	for _, variant := range set.Variants {
+		if CheckPathError(filepath.Join(processor.configuration.Output, filepath.FromSlash(variant.Path))) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (processor *imageProcessor) generate(target string, data []byte, widths []int) (ImageSet, error) {
+	set := ImageSet{Hash: hashContent(data), Widths: widths}
+	picture, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return set, err
+	}
+	set.Width = picture.Bounds().Dx()
+	for _, width := range widths {
+		if width >= set.Width {
+			continue
+		}
+		buffer := getBuffer()
+		err = encodeImage(buffer, downscale(picture, width), format, processor.configuration.Images.Quality)
+		variant := ImageVariant{Path: variantName(target, width), Width: width}
+		if err == nil {
+			err = writeFile(processor.output, variant.Path, buffer.Bytes())
+		}
+		putBuffer(buffer)
+		if err != nil {
+			return set, err
+		}
+		set.Variants = append(set.Variants, variant)
+	}
+	return set, nil
+}
+
+func (processor *imageProcessor) imageSet(target string) (ImageSet, bool) {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+	if set, found := processor.sets[target]; found {
+		return set, len(set.Variants) > 0
+	}
+	if processor.failed[target] || !RASTER_IMAGE_EXTENSIONS[strings.ToLower(path.Ext(target))] {
+		return ImageSet{}, false
+	}
+	source := target
+	if name, found := processor.resources[target]; found {
+		source = name
+	}
+	data, err := readImageSource(processor.configuration, source)
+	if err != nil {
+		processor.failed[target] = true
+		return ImageSet{}, false
+	}
+	widths := imageWidths(processor.configuration.Images)
+	previous, cached := processor.previous[target]
+	if processor.reuse && cached && previous.Hash == hashContent(data) && reflect.DeepEqual(previous.Widths, widths) && processor.variantsExist(previous) {
+		for _, variant := range previous.Variants {
+			err = processor.output.addExisting(processor.configuration.Output, variant.Path)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			processor.sets[target] = previous
+			return previous, len(previous.Variants) > 0
+		}
+	}
+	set, err := processor.generate(target, data, widths)
+	if err != nil {
+		processor.failed[target] = true
+		message := fmt.Sprintf("image '%s' is used without resized variants: %s", target, err)
+		processor.warnings.add(WARNING_IMAGE_VARIANTS, target, message, "warning: "+message)
+		return ImageSet{}, false
+	}
+	processor.sets[target] = set
+	return set, len(set.Variants) > 0
+}
+
+func (processor *imageProcessor) sourceSet(outputPath string) func(string) (string, bool) {
+	return func(destination string) (string, bool) {
+		target, internal := referenceTarget(outputPath, destination)
+		if !internal || strings.HasPrefix(target, "../") {
+			return "", false
+		}
+		set, found := processor.imageSet(target)
+		if !found {
+			return "", false
+		}
+		parsed, _ := url.Parse(destination)
+		directory := path.Dir(parsed.Path)
+		var candidates []string
+		for _, variant := range set.Variants {
+			parsed.Path = path.Join(directory, path.Base(variant.Path))
+			candidates = append(candidates, fmt.Sprintf("%s %dw", parsed.String(), variant.Width))
+		}
+		candidates = append(candidates, fmt.Sprintf("%s %dw", destination, set.Width))
+		return strings.Join(candidates, ", "), true
+	}
+}
+
+func (processor *imageProcessor) refresh(source Source) {
+	for _, destination := range source.Assets {
+		target, internal := referenceTarget(source.OutputPath, destination)
+		if internal && !strings.HasPrefix(target, "../") {
+			processor.imageSet(target)
+		}
+	}
+}
+
+func (processor *imageProcessor) cache(partial bool) map[string]ImageSet {
+	sets := map[string]ImageSet{}
+	if partial {
+		for target, set := range processor.previous {
+			sets[target] = set
+		}
+	}
+	for target, set := range processor.sets {
+		sets[target] = set
+	}
+	return sets
+}
+
+func imageHook(sourceSet func(string) (string, bool), sizes string) nodeHook {
+	if len(sizes) == 0 {
+		sizes = DEFAULT_IMAGE_SIZES
+	}
+	return func(renderer *html.Renderer, writer io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		image, isImage := node.(*ast.Image)
+		if !isImage || !entering || renderer.DisableTags > 0 {
+			return ast.GoToNext, false
+		}
+		candidates, found := sourceSet(string(image.Destination))
+		if !found {
+			return ast.GoToNext, false
+		}
+		var buffer bytes.Buffer
+		renderer.Image(&buffer, image, true)
+		tag := strings.TrimSuffix(buffer.String(), `" alt="`)
+		io.WriteString(writer, tag+`" srcset="`)
+		html.EscapeHTML(writer, []byte(candidates))
+		io.WriteString(writer, `" sizes="`)
+		html.EscapeHTML(writer, []byte(sizes))
+		io.WriteString(writer, `" alt="`)
+		return ast.GoToNext, true
+	}
+}
@@ -0,0 +1,261 @@
+package renderer
+
+import (
+	"bufio"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DEFAULT_CONFIG_FILE_NAME = "config.json"
+const DEFAULT_INIT_INPUT = "./_content"
+const DEFAULT_INIT_OUTPUT = "./_public"
+const DEFAULT_INIT_TITLE = "My Site"
+const INIT_TEMPLATES_DIRECTORY = "_templates"
+const SAMPLE_POST_NAME = "hello-world.md"
+const SAMPLE_POST_TITLE = "Hello, world"
+const SAMPLE_POST_DATE_FORMAT = "2006-01-02T15:04:05.000Z"
+
+//go:embed defaults
+var DEFAULT_FILES embed.FS
+
+type InitOptions struct {
+	Config      string
+	Input       string
+	Output      string
+	Title       string
+	BaseURL     string
+	Interactive bool
+	Force       bool
+	Now         time.Time
+}
+
+type initConfiguration struct {
+	TemplateIndex string
+	TemplatePage  string
+	Input         string
+	Output        string
+	Title         string
+	BaseURL       string `json:",omitempty"`
+}
+
+type initFile struct {
+	path string
+	data []byte
+}
+
+type initPrompt struct {
+	reader *bufio.Reader
+	output io.Writer
+}
+
+func checkInitDirectory(field string, value string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(strings.TrimSpace(value)))
+	if len(strings.TrimSpace(value)) == 0 || filepath.IsAbs(value) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		msg := fmt.Sprintf("%s must be a directory inside the site directory, not '%s'", field, value)
+		return "", errors.New(msg)
+	}
+	for _, component := range strings.Split(cleaned, "/") {
+		err := checkPathComponent(component)
+		if err != nil {
+			msg := fmt.Sprintf("%s: %s", field, err)
+			return "", errors.New(msg)
+		}
+	}
+	return "./" + cleaned, nil
+}
+
+func checkInitTitle(value string) (string, error) {
+	title := strings.TrimSpace(value)
+	if len(title) == 0 {
+		return "", errors.New("the site title must not be empty")
+	}
+	return title, nil
+}
+
+func checkInitBaseURL(value string) (string, error) {
+	baseURL := strings.TrimSpace(value)
+	if len(baseURL) == 0 {
+		return "", nil
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+		msg := fmt.Sprintf("the base URL must look like https://example.com/, not '%s'", value)
+		return "", errors.New(msg)
+	}
+	return baseURL, nil
+}
+
+func (prompt initPrompt) ask(question string, value string, check func(string) (string, error)) (string, error) {
+	for {
+		if len(value) > 0 {
+			fmt.Fprintf(prompt.output, "%s [%s]: ", question, value)
+		} else {
+			fmt.Fprintf(prompt.output, "%s: ", question)
+		}
+		line, err := prompt.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		answer := strings.TrimSpace(line)
+		if len(answer) == 0 {
+			answer = value
+		}
+		checked, checkErr := check(answer)
+		if checkErr == nil || err == io.EOF {
+			if err == io.EOF {
+				fmt.Fprintln(prompt.output)
+			}
+			return checked, checkErr
+		}
+		fmt.Fprintln(prompt.output, checkErr)
+	}
+}
+
+func initDefault(value string, fallback string) string {
+	if len(value) == 0 {
+		return fallback
+	}
+	return value
+}
+
+func askInitOptions(options InitOptions, input io.Reader, output io.Writer) (InitOptions, error) {
+	options.Input = initDefault(options.Input, DEFAULT_INIT_INPUT)
+	options.Output = initDefault(options.Output, DEFAULT_INIT_OUTPUT)
+	options.Title = initDefault(options.Title, DEFAULT_INIT_TITLE)
+	questions := []struct {
+		question string
+		value    *string
+		check    func(string) (string, error)
+	}{
+		{"Input directory", &options.Input, func(value string) (string, error) { return checkInitDirectory("Input", value) }},
+		{"Output directory", &options.Output, func(value string) (string, error) { return checkInitDirectory("Output", value) }},
+		{"Site title", &options.Title, checkInitTitle},
+		{"Base URL", &options.BaseURL, checkInitBaseURL},
+	}
+	prompt := initPrompt{reader: bufio.NewReader(input), output: output}
+	for _, question := range questions {
+		var err error
+		if options.Interactive {
+			*question.value, err = prompt.ask(question.question, *question.value, question.check)
+		} else {
+			*question.value, err = question.check(*question.value)
+		}
+		if err != nil {
+			return options, err
+		}
+	}
+	if path.Clean(options.Input) == path.Clean(options.Output) {
+		msg := fmt.Sprintf("Input and Output must be different directories, both are '%s'", options.Input)
+		return options, errors.New(msg)
+	}
+	return options, nil
+}
+
+func samplePost(options InitOptions) ([]byte, error) {
+	body, err := DEFAULT_FILES.ReadFile("defaults/post.md")
+	if err != nil {
+		return nil, err
+	}
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	meta := struct {
+		Title string
+		Date  string
+	}{SAMPLE_POST_TITLE, now.UTC().Format(SAMPLE_POST_DATE_FORMAT)}
+	data, err := json.MarshalIndent(meta, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	post := "```json\n" + string(data) + "\n```\n" + string(body)
+	return []byte(post), nil
+}
+
+func initFiles(options InitOptions) ([]initFile, error) {
+	configuration := initConfiguration{
+		TemplateIndex: "./" + INIT_TEMPLATES_DIRECTORY + "/index.html",
+		TemplatePage:  "./" + INIT_TEMPLATES_DIRECTORY + "/page.html",
+		Input:         options.Input,
+		Output:        options.Output,
+		Title:         options.Title,
+		BaseURL:       options.BaseURL,
+	}
+	data, err := json.MarshalIndent(configuration, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	var files []initFile
+	for _, name := range []string{"index.html", "page.html"} {
+		template, err := DEFAULT_FILES.ReadFile("defaults/" + name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, initFile{INIT_TEMPLATES_DIRECTORY + "/" + name, template})
+	}
+	post, err := samplePost(options)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, initFile{path.Join(strings.TrimPrefix(options.Input, "./"), SAMPLE_POST_NAME), post})
+	files = append(files, initFile{filepath.Base(options.Config), append(data, '\n')})
+	return files, nil
+}
+
+func RunInit(options InitOptions, input io.Reader, output io.Writer) error {
+	options.Config = initDefault(options.Config, DEFAULT_CONFIG_FILE_NAME)
+	directory := filepath.Dir(options.Config)
+	if _, err := os.Stat(options.Config); err == nil && !options.Force {
+		msg := fmt.Sprintf("%s already exists, run init with -force to overwrite it", options.Config)
+		return errors.New(msg)
+	}
+	options, err := askInitOptions(options, input, output)
+	if err != nil {
+		return err
+	}
+	files, err := initFiles(options)
+	if err != nil {
+		return err
+	}
+	site := Configuration{Output: directory}
+	for _, file := range files {
+		err = checkOutputPath(site, file.path)
+		if err != nil {
+			return err
+		}
+		fullPath := filepath.Join(directory, filepath.FromSlash(file.path))
+		if _, err := os.Stat(fullPath); err == nil && !options.Force {
+			msg := fmt.Sprintf("%s already exists, run init with -force to overwrite it", fullPath)
+			return errors.New(msg)
+		}
+	}
+	for _, name := range []string{options.Input, options.Output} {
+		err = os.MkdirAll(filepath.Join(directory, filepath.FromSlash(name)), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		fullPath := filepath.Join(directory, filepath.FromSlash(file.path))
+		err = os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(fullPath, file.data, 0644)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "created %s\n", fullPath)
+	}
+	fmt.Fprintf(output, "build the site with %s=%s\n", ENVIRONMENTAL_VARIABLE, options.Config)
+	return nil
+}
@@ -0,0 +1,96 @@
+package renderer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitWizard(t *testing.T) {
+	directory := t.TempDir()
+	config := filepath.Join(directory, DEFAULT_CONFIG_FILE_NAME)
+	input := strings.NewReader("site\n../outside\nout\n\nnot a url\nhttps://example.com/\n")
+	var output bytes.Buffer
+	options := InitOptions{Config: config, Interactive: true, Now: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)}
+	if err := RunInit(options, input, &output); err != nil {
+		t.Fatal(err)
+	}
+	transcript := output.String()
+	for _, text := range []string{
+		"Input directory [" + DEFAULT_INIT_INPUT + "]: ",
+		"Output must be a directory inside the site directory, not '../outside'",
+		"Site title [" + DEFAULT_INIT_TITLE + "]: ",
+		"the base URL must look like https://example.com/, not 'not a url'",
+		"created " + filepath.Join(directory, "site", SAMPLE_POST_NAME),
+	} {
+		if !strings.Contains(transcript, text) {
+			t.Errorf("transcript = %q, want %q", transcript, text)
+		}
+	}
+	setTestEnv(t, ENVIRONMENTAL_VARIABLE, config, true)
+	configuration, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configuration.Input != filepath.Join(directory, "site") || configuration.Output != filepath.Join(directory, "out") ||
+		configuration.Title != DEFAULT_INIT_TITLE || configuration.BaseURL != "https://example.com/" {
+		t.Fatalf("configuration = %+v, want the answers", configuration)
+	}
+	if err := NewBuilder(configuration).Build(); err != nil {
+		t.Fatalf("building the new site: %v", err)
+	}
+	for _, name := range []string{INDEX_FILE_NAME, "hello-world.html"} {
+		if _, err := os.Stat(filepath.Join(configuration.Output, name)); err != nil {
+			t.Errorf("the new site has no %s: %v", name, err)
+		}
+	}
+
+	err = RunInit(InitOptions{Config: config}, strings.NewReader(""), &output)
+	if err == nil || !strings.Contains(err.Error(), "run init with -force") {
+		t.Errorf("second init = %v, want it to refuse without -force", err)
+	}
+	if err := RunInit(InitOptions{Config: config, Input: "site", Output: "out", Force: true}, strings.NewReader(""), &output); err != nil {
+		t.Errorf("init with -force = %v", err)
+	}
+}
+
+func TestInitOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options InitOptions
+		input   string
+		err     string
+	}{
+		{"defaults at the end of the input", InitOptions{Interactive: true}, "", ""},
+		{"flags without a terminal", InitOptions{Input: "docs", Output: "public", Title: "Docs", BaseURL: "https://docs.example.com/"}, "", ""},
+		{"invalid flag", InitOptions{BaseURL: "ftp://example.com/"}, "", "the base URL must look like"},
+		{"invalid answer at the end of the input", InitOptions{Interactive: true}, "\n\n\nnot a url", "the base URL must look like"},
+		{"same directories", InitOptions{Input: "site", Output: "./site/"}, "", "Input and Output must be different directories"},
+		{"reserved directory", InitOptions{Input: "con"}, "", "Input: 'con' is a reserved file name on Windows"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.Config = filepath.Join(t.TempDir(), DEFAULT_CONFIG_FILE_NAME)
+			var output bytes.Buffer
+			err := RunInit(test.options, strings.NewReader(test.input), &output)
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := os.Stat(test.options.Config); err != nil {
+					t.Errorf("no config was written: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("init = %v, want %q", err, test.err)
+			}
+			if _, err := os.Stat(test.options.Config); !os.IsNotExist(err) {
+				t.Errorf("init failed but wrote the config: %v", err)
+			}
+		})
+	}
+}
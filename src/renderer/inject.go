@@ -0,0 +1,108 @@
+package renderer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+)
+
+type Inject struct {
+	HeadAppend      string
+	BodyPrepend     string
+	BodyAppend      string
+	HeadAppendFile  string
+	BodyPrependFile string
+	BodyAppendFile  string
+}
+
+func readSnippet(snippet string, path string) (string, error) {
+	var err error
+	if len(path) > 0 {
+		var data []byte
+		data, err = ioutil.ReadFile(path)
+		if err == nil {
+			snippet += string(data)
+		}
+	}
+	return snippet, err
+}
+
+func LoadInject(inject Inject) (Inject, error) {
+	var err error
+	var loaded Inject
+	loaded.HeadAppend, err = readSnippet(inject.HeadAppend, inject.HeadAppendFile)
+	if err == nil {
+		loaded.BodyPrepend, err = readSnippet(inject.BodyPrepend, inject.BodyPrependFile)
+	}
+	if err == nil {
+		loaded.BodyAppend, err = readSnippet(inject.BodyAppend, inject.BodyAppendFile)
+	}
+	return loaded, err
+}
+
+func isInjectEmpty(inject Inject) bool {
+	return len(inject.HeadAppend) == 0 && len(inject.BodyPrepend) == 0 && len(inject.BodyAppend) == 0
+}
+
+func findBodyStart(lower []byte) int {
+	offset := 0
+	for {
+		index := bytes.Index(lower[offset:], []byte("<body"))
+		if index == -1 {
+			return -1
+		}
+		index += offset
+		next := index + len("<body")
+		if next < len(lower) && (lower[next] == '>' || lower[next] == ' ' || lower[next] == '\t' || lower[next] == '\n' || lower[next] == '\r') {
+			end := bytes.IndexByte(lower[next:], '>')
+			if end == -1 {
+				return -1
+			}
+			return next + end + 1
+		}
+		offset = next
+	}
+}
+
+func injectSnippets(html []byte, inject Inject, path string) []byte {
+	if isInjectEmpty(inject) {
+		return html
+	}
+	lower := bytes.ToLower(html)
+	headEnd := bytes.Index(lower, []byte("</head>"))
+	bodyStart := findBodyStart(lower)
+	bodyEnd := bytes.LastIndex(lower, []byte("</body>"))
+	if len(inject.HeadAppend) > 0 && headEnd == -1 {
+		log.Printf("inject warning: %s has no </head>, written unmodified", path)
+		return html
+	}
+	if len(inject.BodyPrepend) > 0 && bodyStart == -1 {
+		log.Printf("inject warning: %s has no <body>, written unmodified", path)
+		return html
+	}
+	if len(inject.BodyAppend) > 0 && bodyEnd == -1 {
+		log.Printf("inject warning: %s has no </body>, written unmodified", path)
+		return html
+	}
+
+	var result bytes.Buffer
+	result.Grow(len(html) + len(inject.HeadAppend) + len(inject.BodyPrepend) + len(inject.BodyAppend))
+	position := 0
+	if len(inject.HeadAppend) > 0 {
+		result.Write(html[position:headEnd])
+		result.WriteString(inject.HeadAppend)
+		position = headEnd
+	}
+	if len(inject.BodyPrepend) > 0 && bodyStart >= position {
+		result.Write(html[position:bodyStart])
+		result.WriteString(inject.BodyPrepend)
+		position = bodyStart
+	}
+	if len(inject.BodyAppend) > 0 && bodyEnd >= position {
+		result.Write(html[position:bodyEnd])
+		result.WriteString(inject.BodyAppend)
+		position = bodyEnd
+	}
+	result.Write(html[position:])
+	return result.Bytes()
+}
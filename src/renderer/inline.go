@@ -0,0 +1,146 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const DEFAULT_MAX_INLINE_BYTES = 14 * 1024
+
+type inlineFiles struct {
+	mutex sync.Mutex
+	files map[string]interface{}
+}
+
+func newInlineFiles() *inlineFiles {
+	return &inlineFiles{files: map[string]interface{}{}}
+}
+
+func maxInlineBytes(configuration Configuration) int64 {
+	if configuration.MaxInlineBytes == 0 {
+		return DEFAULT_MAX_INLINE_BYTES
+	}
+	return configuration.MaxInlineBytes
+}
+
+func checkInline(configuration Configuration) error {
+	if configuration.MaxInlineBytes < 0 {
+		msg := fmt.Sprintf("MaxInlineBytes must not be negative, not %d", configuration.MaxInlineBytes)
+		return errors.New(msg)
+	}
+	if len(configuration.InlineDirectory) == 0 {
+		return nil
+	}
+	err := CheckPathError(configuration.InlineDirectory)
+	if err != nil {
+		msg := fmt.Sprintf("InlineDirectory: %s", err)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func inlinePath(configuration Configuration, name string) (string, error) {
+	if len(configuration.InlineDirectory) == 0 {
+		msg := fmt.Sprintf("inlineFile '%s': no InlineDirectory is configured", name)
+		return "", errors.New(msg)
+	}
+	cleaned := path.Clean("/" + filepath.ToSlash(name))
+	if strings.Contains(name, "\\") || filepath.IsAbs(name) || cleaned != "/"+filepath.ToSlash(name) {
+		msg := fmt.Sprintf("inlineFile '%s': the path must be relative to InlineDirectory and stay inside it", name)
+		return "", errors.New(msg)
+	}
+	root, err := filepath.EvalSymlinks(configuration.InlineDirectory)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(cleaned)))
+	if os.IsNotExist(err) {
+		msg := fmt.Sprintf("inlineFile '%s': there is no such file in %s", name, configuration.InlineDirectory)
+		return "", errors.New(msg)
+	}
+	if err != nil {
+		return "", err
+	}
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		msg := fmt.Sprintf("inlineFile '%s': the file links to a place outside of %s", name, configuration.InlineDirectory)
+		return "", errors.New(msg)
+	}
+	return resolved, nil
+}
+
+func inlineContent(name string, data []byte) interface{} {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".css":
+		return htmlTemplate.CSS(data)
+	case ".js", ".mjs":
+		return htmlTemplate.JS(data)
+	case ".html", ".htm", ".svg":
+		return htmlTemplate.HTML(data)
+	}
+	return string(data)
+}
+
+func readInline(configuration Configuration, name string) (interface{}, error) {
+	fullPath, err := inlinePath(configuration, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	limit := maxInlineBytes(configuration)
+	if int64(len(data)) > limit {
+		logPrintf("warning: inlineFile '%s' is %d bytes, over MaxInlineBytes of %d bytes", name, len(data), limit)
+	}
+	return inlineContent(name, data), nil
+}
+
+func (inlined *inlineFiles) read(configuration Configuration, name string) (interface{}, error) {
+	if inlined == nil {
+		return readInline(configuration, name)
+	}
+	inlined.mutex.Lock()
+	defer inlined.mutex.Unlock()
+	if content, found := inlined.files[name]; found {
+		return content, nil
+	}
+	content, err := readInline(configuration, name)
+	if err == nil {
+		inlined.files[name] = content
+	}
+	return content, err
+}
+
+func inlineFingerprint(configuration Configuration) ([]byte, error) {
+	if len(configuration.InlineDirectory) == 0 {
+		return nil, nil
+	}
+	var names []string
+	err := filepath.Walk(configuration.InlineDirectory, func(name string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			names = append(names, name)
+		}
+		return err
+	})
+	sort.Strings(names)
+	var data []byte
+	for _, name := range names {
+		var content []byte
+		if err == nil {
+			content, err = ioutil.ReadFile(name)
+		}
+		data = append(data, name...)
+		data = append(data, content...)
+	}
+	return data, err
+}
@@ -0,0 +1,62 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const KIND_POST = "post"
+const KIND_PAGE = "page"
+
+func checkKind(kind string) error {
+	if kind != KIND_POST && kind != KIND_PAGE {
+		msg := fmt.Sprintf("kind must be '%s' or '%s', not '%s'", KIND_POST, KIND_PAGE, kind)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func checkKindDirectories(configuration Configuration) error {
+	for directory, kind := range configuration.KindDirectories {
+		err := checkKind(kind)
+		if err != nil {
+			msg := fmt.Sprintf("kind directory '%s': %s", directory, err)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func directoryKind(configuration Configuration, name string) string {
+	kind := KIND_POST
+	longest := -1
+	for directory, mapped := range configuration.KindDirectories {
+		prefix := strings.Trim(path.Clean("/"+directory), "/")
+		if len(prefix) > longest && (len(prefix) == 0 || strings.HasPrefix(name, prefix+"/")) {
+			kind = mapped
+			longest = len(prefix)
+		}
+	}
+	return kind
+}
+
+func resolveKind(configuration Configuration, metaBlock MetaBlock, name string) (string, error) {
+	if len(metaBlock.Kind) == 0 {
+		return directoryKind(configuration, name), nil
+	}
+	return metaBlock.Kind, checkKind(metaBlock.Kind)
+}
+
+func isPublic(source Source) bool {
+	return !source.Expired && !source.Unlisted
+}
+
+func isIndexed(source Source) bool {
+	return isPublic(source) && !source.NoIndex
+}
+
+func isListed(source Source) bool {
+	return isPublic(source) && source.Link.Kind != KIND_PAGE
+}
@@ -0,0 +1,83 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const TEST_NOINDEX_TEMPLATE = "<html><body>noindex={{.NoIndex}} {{.Content}}</body></html>\n"
+
+func TestDraftUnlistedNoIndex(t *testing.T) {
+	for _, draft := range []bool{false, true} {
+		for _, unlisted := range []bool{false, true} {
+			for _, noIndex := range []bool{false, true} {
+				name := fmt.Sprintf("draft=%v/unlisted=%v/noindex=%v", draft, unlisted, noIndex)
+				t.Run(name, func(t *testing.T) {
+					rendered := !draft
+					listed := rendered && !unlisted
+					crawled := listed && !noIndex
+					site := newTestSite(t)
+					site.Configuration.BaseURL = "https://example.com/"
+					site.Configuration.MediaIndex = true
+					site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}}
+					site.writeFile(site.Configuration.TemplatePage, TEST_NOINDEX_TEMPLATE)
+					site.page("other.md", `{"Title": "Other", "Date": "2024-05-01"}`, "body\n")
+					meta := fmt.Sprintf(`{"Title": "Flagged", "Date": "2024-05-02", "Draft": %v, "Unlisted": %v, "NoIndex": %v}`, draft, unlisted, noIndex)
+					site.page("flagged.md", meta, "![photo](photo.png)\n")
+					site.mustBuild()
+					if site.exists("flagged.html") != rendered {
+						t.Fatalf("flagged.html exists = %v, want %v", !rendered, rendered)
+					}
+					if rendered {
+						want := fmt.Sprintf("noindex=%v ", unlisted || noIndex)
+						if page := site.read("flagged.html"); !strings.Contains(page, want) {
+							t.Errorf("flagged.html = %q, want %q", page, want)
+						}
+					}
+					if found := strings.Contains(site.read(INDEX_FILE_NAME), "Flagged"); found != listed {
+						t.Errorf("index lists the page = %v, want %v", found, listed)
+					}
+					var feed jsonFeed
+					err := json.Unmarshal([]byte(site.read(JSON_FEED_FILE_NAME)), &feed)
+					if err != nil {
+						t.Fatal(err)
+					}
+					found := false
+					for _, item := range feed.Items {
+						found = found || item.Title == "Flagged"
+					}
+					if found != listed {
+						t.Errorf("feed lists the page = %v, want %v", found, listed)
+					}
+					if found := strings.Contains(site.read(SITEMAP_FILE_NAME), "flagged.html"); found != crawled {
+						t.Errorf("sitemap lists the page = %v, want %v", found, crawled)
+					}
+					if found := strings.Contains(site.read(MEDIA_FILE_NAME), "photo.png"); found != listed {
+						t.Errorf("media index lists the image = %v, want %v", found, listed)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestDefaultPageTemplateNoIndex(t *testing.T) {
+	site := newTestSite(t)
+	template, err := DEFAULT_FILES.ReadFile("defaults/page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	site.writeFile(site.Configuration.TemplatePage, string(template))
+	site.page("a.md", `{"Title": "A", "NoIndex": true}`, "body\n")
+	site.page("b.md", `{"Title": "B"}`, "body\n")
+	site.mustBuild()
+	robots := `<meta name="robots" content="noindex">`
+	if page := site.read("a.html"); !strings.Contains(page, robots) {
+		t.Errorf("a.html = %q, want %q", page, robots)
+	}
+	if page := site.read("b.html"); strings.Contains(page, robots) {
+		t.Errorf("b.html = %q, contains %q", page, robots)
+	}
+}
@@ -0,0 +1,119 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const SITEMAP_XHTML_NAMESPACE = "http://www.w3.org/1999/xhtml"
+
+var LANGUAGE_CODE = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+type Translation struct {
+	Lang string
+	Url  string
+}
+
+type sitemapAlternate struct {
+	XMLName  xml.Name `xml:"xhtml:link"`
+	Rel      string   `xml:"rel,attr"`
+	HrefLang string   `xml:"hreflang,attr"`
+	Href     string   `xml:"href,attr"`
+}
+
+func checkLanguageCode(field string, code string) error {
+	if !LANGUAGE_CODE.MatchString(code) {
+		msg := fmt.Sprintf("%s '%s' is not a language code like en or pt-BR", field, code)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func checkLanguages(configuration Configuration) error {
+	if len(configuration.Language) > 0 {
+		err := checkLanguageCode("Language", configuration.Language)
+		if err != nil {
+			return err
+		}
+	}
+	for _, code := range configuration.Languages {
+		err := checkLanguageCode("Languages entry", code)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func languageRule(configuration Configuration, name string) (string, string) {
+	for _, code := range configuration.Languages {
+		if strings.HasPrefix(name, code+"/") {
+			return code, strings.TrimPrefix(name, code+"/")
+		}
+	}
+	stem := strings.TrimSuffix(name, MARKDOWN_FILE_ENDING)
+	for _, code := range configuration.Languages {
+		if strings.HasSuffix(stem, "."+code) {
+			return code, strings.TrimSuffix(stem, "."+code) + MARKDOWN_FILE_ENDING
+		}
+	}
+	return "", name
+}
+
+func resolveLanguage(configuration Configuration, name string, metaBlock MetaBlock) string {
+	if len(metaBlock.Lang) > 0 {
+		return metaBlock.Lang
+	}
+	if code, _ := languageRule(configuration, name); len(code) > 0 {
+		return code
+	}
+	return configuration.Language
+}
+
+func buildTranslations(configuration Configuration, sources []Source) map[string][]Translation {
+	if len(configuration.Languages) == 0 {
+		return nil
+	}
+	byKey := map[string][]Translation{}
+	var keys []string
+	for _, source := range sources {
+		if !isPublic(source) || len(source.Link.Lang) == 0 {
+			continue
+		}
+		_, key := languageRule(configuration, source.Name)
+		if _, found := byKey[key]; !found {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], Translation{Lang: source.Link.Lang, Url: source.Link.Url})
+	}
+	translations := map[string][]Translation{}
+	for _, key := range keys {
+		group := byKey[key]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].Lang < group[j].Lang
+		})
+		for _, translation := range group {
+			translations[translation.Url] = group
+		}
+	}
+	return translations
+}
+
+func sitemapAlternates(baseUrl string, translations []Translation) []sitemapAlternate {
+	var alternates []sitemapAlternate
+	for _, translation := range translations {
+		alternates = append(alternates, sitemapAlternate{
+			Rel:      "alternate",
+			HrefLang: translation.Lang,
+			Href:     absoluteUrl(baseUrl, translation.Url),
+		})
+	}
+	return alternates
+}
@@ -0,0 +1,74 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPageLanguage(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.Language = "en"
+	site.Configuration.Languages = []string{"fr"}
+	site.writeFile(site.Configuration.TemplatePage, `<html lang="{{.Lang}}">{{.Content}}`)
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	site.page("b.md", `{"Title": "B", "Lang": "de"}`, "body\n")
+	site.page("c.fr.md", `{"Title": "C"}`, "body\n")
+	site.page("d.fr.md", `{"Title": "D", "Lang": "pt-BR"}`, "body\n")
+	site.mustBuild()
+	for name, lang := range map[string]string{"a.html": "en", "b.html": "de", "c.fr.html": "fr", "d.fr.html": "pt-BR"} {
+		if page := site.read(name); !strings.HasPrefix(page, `<html lang="`+lang+`">`) {
+			t.Errorf("%s = %q, want lang %s", name, page, lang)
+		}
+	}
+}
+
+func TestInvalidLanguages(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		lang     string
+		err      string
+	}{
+		{"page", "en", "english!", "Lang 'english!' is not a language code"},
+		{"page with spaces", "", "en US", "Lang 'en US' is not a language code"},
+		{"page inheriting the site language", "en_US", "", "Lang 'en_US' is not a language code"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Language = test.language
+			meta := `{"Title": "A"}`
+			if len(test.lang) > 0 {
+				meta = `{"Title": "A", "Lang": "` + test.lang + `"}`
+			}
+			site.page("a.md", meta, "body\n")
+			err := site.build()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("build = %v, want %q", err, test.err)
+			}
+			if !strings.Contains(err.Error(), site.path("content/a.md")) {
+				t.Errorf("error = %q, want it to name content/a.md", err)
+			}
+		})
+	}
+}
+
+func TestPreflightLanguages(t *testing.T) {
+	tests := []struct {
+		configuration Configuration
+		err           string
+	}{
+		{Configuration{Language: "de-AT", Languages: []string{"en", "pt-BR"}}, ""},
+		{Configuration{Language: "en_US"}, "Language 'en_US' is not a language code"},
+		{Configuration{Languages: []string{"en", "French"}}, "Languages entry 'French' is not a language code"},
+	}
+	for _, test := range tests {
+		err := checkLanguages(test.configuration)
+		if len(test.err) == 0 && err != nil {
+			t.Errorf("checkLanguages(%+v) = %v, want no error", test.configuration, err)
+		}
+		if len(test.err) > 0 && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("checkLanguages(%+v) = %v, want %q", test.configuration, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+type TemplateRule struct {
+	Pattern  string
+	Template string
+}
+
+func checkTemplateRules(configuration Configuration) error {
+	for number, rule := range configuration.TemplateRules {
+		var err error
+		pattern := strings.Trim(rule.Pattern, "/")
+		if len(pattern) == 0 {
+			err = errors.New("Pattern is not set")
+		}
+		for _, part := range strings.Split(pattern, "/") {
+			if _, matchErr := path.Match(part, ""); err == nil && matchErr != nil {
+				msg := fmt.Sprintf("Pattern: %s", matchErr)
+				err = errors.New(msg)
+			}
+		}
+		if err == nil && len(rule.Template) == 0 {
+			err = errors.New("Template is not set")
+		}
+		if err == nil {
+			err = checkTemplate("Template", rule.Template, configuration)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("template rule #%d '%s': %s", number+1, rule.Pattern, err)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func pageTemplate(configuration Configuration, name string) (string, int) {
+	for number, rule := range configuration.TemplateRules {
+		if matchPattern(rule.Pattern, name, false) {
+			return rule.Template, number
+		}
+	}
+	return configuration.TemplatePage, -1
+}
@@ -0,0 +1,190 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const LOCK_FILE_NAME = ".mdbuild.lock"
+const LOCK_FILE_ENDING = ".lock"
+const LOCK_POLL_INTERVAL = 100 * time.Millisecond
+
+type lockHolder struct {
+	PID     int
+	Started string
+}
+
+type buildLock struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func lockPath(configuration Configuration) string {
+	if IsArchivePath(configuration.Output) {
+		return configuration.Output + LOCK_FILE_ENDING
+	}
+	return filepath.Join(configuration.Output, LOCK_FILE_NAME)
+}
+
+func lockTimeout(configuration Configuration) (time.Duration, error) {
+	if len(configuration.LockTimeout) == 0 {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(configuration.LockTimeout)
+	if err == nil && timeout < 0 {
+		err = errors.New("must not be negative")
+	}
+	if err != nil {
+		msg := fmt.Sprintf("lock timeout '%s': %s", configuration.LockTimeout, err)
+		err = errors.New(msg)
+	}
+	return timeout, err
+}
+
+func checkLockTimeout(configuration Configuration) error {
+	_, err := lockTimeout(configuration)
+	return err
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || (err != os.ErrProcessDone && !errors.Is(err, syscall.ESRCH))
+}
+
+func readLockHolder(path string) (lockHolder, []byte, error) {
+	var holder lockHolder
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &holder)
+	}
+	return holder, data, err
+}
+
+func createLock(path string, started time.Time) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(lockHolder{PID: os.Getpid(), Started: started.Format(time.RFC3339)})
+	_, err = file.Write(data)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+func breakStaleLock(path string, holder lockHolder, data []byte) {
+	current, err := ioutil.ReadFile(path)
+	if err != nil || string(current) != string(data) {
+		return
+	}
+	log.Printf("warning: breaking stale lock %s of process %d started %s, which is no longer running", path, holder.PID, holder.Started)
+	os.Remove(path)
+}
+
+func lockHeldError(path string, holder lockHolder, readErr error) error {
+	var msg string
+	if readErr != nil {
+		msg = fmt.Sprintf("output is locked by another build (lock file %s is unreadable: %s)", path, readErr)
+	} else {
+		msg = fmt.Sprintf("output is locked by process %d since %s (lock file %s)", holder.PID, holder.Started, path)
+	}
+	return errors.New(msg)
+}
+
+func acquireLock(path string, timeout time.Duration, now func() time.Time) error {
+	deadline := now().Add(timeout)
+	for {
+		err := createLock(path, now())
+		if err == nil {
+			return nil
+		}
+		if !os.IsExist(err) {
+			msg := fmt.Sprintf("lock error: %s", err)
+			return errors.New(msg)
+		}
+		holder, data, readErr := readLockHolder(path)
+		if os.IsNotExist(readErr) {
+			continue
+		}
+		if readErr == nil && !processAlive(holder.PID) {
+			breakStaleLock(path, holder, data)
+			continue
+		}
+		if !now().Before(deadline) {
+			return lockHeldError(path, holder, readErr)
+		}
+		time.Sleep(LOCK_POLL_INTERVAL)
+	}
+}
+
+func (lock *buildLock) hold(path string) {
+	lock.mutex.Lock()
+	lock.path = path
+	lock.mutex.Unlock()
+}
+
+func (lock *buildLock) release() error {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+	if len(lock.path) == 0 {
+		return nil
+	}
+	path := lock.path
+	lock.path = ""
+	return os.Remove(path)
+}
+
+func (builder *Builder) lockOutput() error {
+	if builder.DryRun || len(builder.Configuration.Output) == 0 {
+		return nil
+	}
+	switch builder.Output.(type) {
+	case *fileOutput, *archiveOutput:
+	default:
+		return nil
+	}
+	timeout, err := lockTimeout(builder.Configuration)
+	if err != nil {
+		return err
+	}
+	path := lockPath(builder.Configuration)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		msg := fmt.Sprintf("lock error: %s", err)
+		return errors.New(msg)
+	}
+	err = acquireLock(path, timeout, time.Now)
+	if err == nil {
+		builder.lock.hold(path)
+	}
+	return err
+}
+
+func (builder *Builder) Unlock() error {
+	err := builder.lock.release()
+	if err != nil && !os.IsNotExist(err) {
+		msg := fmt.Sprintf("lock error: %s", err)
+		return errors.New(msg)
+	}
+	return nil
+}
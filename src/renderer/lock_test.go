@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		err   string
+	}{
+		{"", 0, ""},
+		{"0s", 0, ""},
+		{"2s", 2 * time.Second, ""},
+		{"1m30s", 90 * time.Second, ""},
+		{"-1s", 0, "lock timeout '-1s': must not be negative"},
+		{"soon", 0, "lock timeout 'soon'"},
+	}
+	for _, test := range tests {
+		got, err := lockTimeout(Configuration{LockTimeout: test.value})
+		if len(test.err) > 0 {
+			if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("lockTimeout(%q) error = %v, want %q", test.value, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("lockTimeout(%q) = %v, %v, want %v", test.value, got, err, test.want)
+		}
+	}
+}
+
+func deadProcess(t *testing.T) int {
+	command := exec.Command("true")
+	err := command.Run()
+	if err != nil {
+		t.Skip("cannot start a process to get a dead PID: ", err)
+	}
+	return command.Process.Pid
+}
+
+func TestLockedOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		holder  func(t *testing.T) string
+		timeout string
+		err     string
+	}{
+		{"held by a running process", func(t *testing.T) string {
+			return fmt.Sprintf(`{"PID": %d, "Started": "2024-06-01T00:00:00Z"}`, os.Getpid())
+		}, "", fmt.Sprintf("output is locked by process %d since 2024-06-01T00:00:00Z", os.Getpid())},
+		{"held until the timeout", func(t *testing.T) string {
+			return fmt.Sprintf(`{"PID": %d, "Started": "2024-06-01T00:00:00Z"}`, os.Getpid())
+		}, "200ms", "output is locked by process"},
+		{"unreadable lock file", func(t *testing.T) string {
+			return "not json"
+		}, "", "is unreadable"},
+		{"stale lock of a dead process", func(t *testing.T) string {
+			return fmt.Sprintf(`{"PID": %d, "Started": "2024-06-01T00:00:00Z"}`, deadProcess(t))
+		}, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.LockTimeout = test.timeout
+			site.page("a.md", `{"Title": "A"}`, "body\n")
+			lock := lockPath(site.Configuration)
+			err := ioutil.WriteFile(lock, []byte(test.holder(t)), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			started := time.Now()
+			err = site.build()
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if CheckPathError(lock) == nil {
+					t.Error("the lock file is left after the build")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("build error = %v, want %q", err, test.err)
+			}
+			if timeout, _ := lockTimeout(site.Configuration); time.Since(started) < timeout {
+				t.Errorf("build gave up after %s, before the %s timeout", time.Since(started), timeout)
+			}
+			if site.exists("a.html") {
+				t.Error("a.html was written while the output was locked")
+			}
+			if CheckPathError(lock) != nil {
+				t.Error("the lock of the other build was removed")
+			}
+		})
+	}
+}
+
+func TestLockIsReleasedByTheHolder(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.LockTimeout = "5s"
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	lock := lockPath(site.Configuration)
+	err := createLock(lock, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(3 * LOCK_POLL_INTERVAL)
+		os.Remove(lock)
+	}()
+	site.mustBuild()
+	if !site.exists("a.html") {
+		t.Error("a.html was not written after the lock was released")
+	}
+}
+
+func TestConcurrentBuilds(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.LockTimeout = "10s"
+	for number := 0; number < 20; number++ {
+		site.page(fmt.Sprintf("page-%02d.md", number), fmt.Sprintf(`{"Title": "Page %d"}`, number), "body\n")
+	}
+	var group sync.WaitGroup
+	errs := make([]error, 4)
+	for index := range errs {
+		group.Add(1)
+		go func(index int) {
+			defer group.Done()
+			errs[index] = NewBuilder(site.Configuration).Build()
+		}(index)
+	}
+	group.Wait()
+	for index, err := range errs {
+		if err != nil {
+			t.Errorf("build %d: %v", index, err)
+		}
+	}
+	if CheckPathError(lockPath(site.Configuration)) == nil {
+		t.Error("the lock file is left after the builds")
+	}
+	if index := site.read(INDEX_FILE_NAME); strings.Count(index, "<a href") != 20 {
+		t.Errorf("index.html lists %d pages, want 20", strings.Count(index, "<a href"))
+	}
+}
+
+func TestLockIsReleasedAfterAFailedBuild(t *testing.T) {
+	site := newTestSite(t)
+	site.page("a.md", `{"Title": "A", "Path": "manifest.json"}`, "body\n")
+	if site.build() == nil {
+		t.Fatal("build with a reserved output succeeded")
+	}
+	if CheckPathError(lockPath(site.Configuration)) == nil {
+		t.Error("the lock file is left after the failed build")
+	}
+}
@@ -0,0 +1,86 @@
+package renderer
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+var logOutput sync.Mutex
+
+type logCoordinator struct {
+	mutex   sync.Mutex
+	ordered bool
+	next    int
+	pending map[int][]string
+}
+
+type pageLog struct {
+	coordinator *logCoordinator
+	sequence    int
+	lines       []string
+}
+
+func logPrint(v ...interface{}) {
+	logOutput.Lock()
+	defer logOutput.Unlock()
+	log.Print(v...)
+}
+
+func logPrintf(format string, v ...interface{}) {
+	logPrint(fmt.Sprintf(format, v...))
+}
+
+func newLogCoordinator(ordered bool) *logCoordinator {
+	return &logCoordinator{ordered: ordered, pending: map[int][]string{}}
+}
+
+func (coordinator *logCoordinator) page(sequence int) *pageLog {
+	return &pageLog{coordinator: coordinator, sequence: sequence}
+}
+
+func (coordinator *logCoordinator) complete(sequence int, lines []string) {
+	coordinator.mutex.Lock()
+	defer coordinator.mutex.Unlock()
+	if !coordinator.ordered {
+		coordinator.write(lines)
+		return
+	}
+	coordinator.pending[sequence] = lines
+	for {
+		next, found := coordinator.pending[coordinator.next]
+		if !found {
+			break
+		}
+		delete(coordinator.pending, coordinator.next)
+		coordinator.write(next)
+		coordinator.next++
+	}
+}
+
+func (coordinator *logCoordinator) write(lines []string) {
+	logOutput.Lock()
+	defer logOutput.Unlock()
+	for _, line := range lines {
+		log.Print(line)
+	}
+}
+
+func (entry *pageLog) Print(v ...interface{}) {
+	if entry == nil {
+		logPrint(v...)
+		return
+	}
+	entry.lines = append(entry.lines, fmt.Sprint(v...))
+}
+
+func (entry *pageLog) Printf(format string, v ...interface{}) {
+	entry.Print(fmt.Sprintf(format, v...))
+}
+
+func (entry *pageLog) flush() {
+	if entry != nil {
+		entry.coordinator.complete(entry.sequence, entry.lines)
+		entry.lines = nil
+	}
+}
@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+)
+
+const TEST_LOGGED_PAGES = 12
+const TEST_LINES_PER_PAGE = 4
+
+func TestPageLogBlocks(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ordered=%v", ordered), func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.PageWorkers = 4
+			site.Configuration.Policies = map[string]string{CONDITION_BROKEN_INTERNAL_LINK: POLICY_ERROR}
+			site.Configuration.TemplateRules = []TemplateRule{{Pattern: "*.md", Template: site.Configuration.TemplatePage}}
+			for index := 0; index < TEST_LOGGED_PAGES; index++ {
+				body := strings.Repeat("some text\n", index*50) + "[one](missing-one.md) [two](missing-two.md)\n"
+				site.page(fmt.Sprintf("page%02d.md", index), fmt.Sprintf(`{"Title": "Page %d"}`, index), body)
+			}
+			var buffer bytes.Buffer
+			log.SetOutput(&buffer)
+			log.SetFlags(0)
+			defer log.SetFlags(log.LstdFlags)
+			defer log.SetOutput(ioutil.Discard)
+			builder := NewBuilder(site.Configuration)
+			builder.OrderedLogs = ordered
+			if err := builder.Build(); err == nil {
+				t.Fatal("build with broken links succeeded, want the broken-internal-link errors")
+			}
+			var pages []string
+			blocks := map[string][]int{}
+			for number, line := range strings.Split(buffer.String(), "\n") {
+				for index := 0; index < TEST_LOGGED_PAGES; index++ {
+					name := fmt.Sprintf("page%02d.md", index)
+					if !strings.Contains(line, name) || strings.HasPrefix(line, "collecting: ") {
+						continue
+					}
+					if len(blocks[name]) == 0 {
+						pages = append(pages, name)
+					}
+					blocks[name] = append(blocks[name], number)
+				}
+			}
+			if len(pages) != TEST_LOGGED_PAGES {
+				t.Fatalf("log has blocks for %v, want %d pages:\n%s", pages, TEST_LOGGED_PAGES, buffer.String())
+			}
+			for _, name := range pages {
+				lines := blocks[name]
+				if len(lines) != TEST_LINES_PER_PAGE || lines[len(lines)-1]-lines[0] != len(lines)-1 {
+					t.Errorf("%s is logged on lines %v, want %d contiguous lines:\n%s", name, lines, TEST_LINES_PER_PAGE, buffer.String())
+				}
+			}
+			if ordered {
+				for index, name := range pages {
+					if want := fmt.Sprintf("page%02d.md", index); name != want {
+						t.Errorf("block %d is %s, want %s in source order", index, name, want)
+					}
+				}
+			}
+		})
+	}
+}
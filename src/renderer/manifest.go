@@ -0,0 +1,158 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const MANIFEST_FILE_NAME = "manifest.json"
+
+type ManifestEntry struct {
+	SHA256    string
+	Size      int64
+	Auxiliary bool `json:",omitempty"`
+}
+
+type Manifest struct {
+	Files map[string]ManifestEntry
+}
+
+type ManifestDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+type manifestOutput struct {
+	mutex    sync.Mutex
+	output   OutputWriter
+	manifest Manifest
+}
+
+type manifestEntryWriter struct {
+	io.WriteCloser
+	path   string
+	hash   hash.Hash
+	size   int64
+	parent *manifestOutput
+}
+
+func newManifestOutput(output OutputWriter, base Manifest) *manifestOutput {
+	files := map[string]ManifestEntry{}
+	for path, entry := range base.Files {
+		files[path] = entry
+	}
+	return &manifestOutput{output: output, manifest: Manifest{Files: files}}
+}
+
+func (output *manifestOutput) Create(path string) (io.WriteCloser, error) {
+	output.mutex.Lock()
+	file, err := output.output.Create(path)
+	output.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &manifestEntryWriter{
+		WriteCloser: file,
+		path:        filepath.ToSlash(path),
+		hash:        sha256.New(),
+		parent:      output,
+	}, nil
+}
+
+func (output *manifestOutput) Finish() error {
+	return output.output.Finish()
+}
+
+func (writer *manifestEntryWriter) Write(data []byte) (int, error) {
+	written, err := writer.WriteCloser.Write(data)
+	writer.hash.Write(data[:written])
+	writer.size += int64(written)
+	return written, err
+}
+
+func (writer *manifestEntryWriter) Close() error {
+	err := writer.WriteCloser.Close()
+	if err == nil {
+		writer.parent.add(writer.path, ManifestEntry{
+			SHA256: hex.EncodeToString(writer.hash.Sum(nil)),
+			Size:   writer.size,
+		})
+	}
+	return err
+}
+
+func (output *manifestOutput) add(path string, entry ManifestEntry) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	output.manifest.Files[path] = entry
+}
+
+func (output *manifestOutput) addExisting(root string, path string) error {
+	data, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if err == nil {
+		output.add(path, ManifestEntry{SHA256: hashContent(data), Size: int64(len(data))})
+	}
+	return err
+}
+
+func (output *manifestOutput) write() error {
+	data, err := json.MarshalIndent(output.manifest, "", "  ")
+	if err == nil {
+		err = writeFile(output.output, MANIFEST_FILE_NAME, data)
+	}
+	return err
+}
+
+func manifestPath(configuration Configuration) string {
+	if len(configuration.Output) == 0 || IsArchivePath(configuration.Output) {
+		return ""
+	}
+	return filepath.Join(configuration.Output, MANIFEST_FILE_NAME)
+}
+
+func loadManifest(path string) (Manifest, error) {
+	var manifest Manifest
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &manifest)
+	}
+	return manifest, err
+}
+
+func DiffManifests(previous Manifest, current Manifest) ManifestDiff {
+	var diff ManifestDiff
+	for path, entry := range current.Files {
+		old, found := previous.Files[path]
+		if !found {
+			diff.Added = append(diff.Added, path)
+		} else if old != entry {
+			diff.Changed = append(diff.Changed, path)
+		}
+	}
+	for path := range previous.Files {
+		if _, found := current.Files[path]; !found {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+func removeStaleManifest(path string) error {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
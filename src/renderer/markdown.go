@@ -0,0 +1,418 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmlEscape "html"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+const EXTENSION_TASK_LISTS = "task-lists"
+const TASK_LIST_CLASS = "task-list"
+const TASK_ITEM_CLASS = "task-list-item"
+const TASK_CHECKBOX = `<input type="checkbox" class="task-list-item-checkbox" disabled>`
+const TASK_CHECKBOX_CHECKED = `<input type="checkbox" class="task-list-item-checkbox" disabled checked>`
+
+var PARSER_EXTENSIONS = map[string]parser.Extensions{
+	"no-intra-emphasis":  parser.NoIntraEmphasis,
+	"tables":             parser.Tables,
+	"fenced-code":        parser.FencedCode,
+	"autolink":           parser.Autolink,
+	"strikethrough":      parser.Strikethrough,
+	"lax-html-blocks":    parser.LaxHTMLBlocks,
+	"space-headings":     parser.SpaceHeadings,
+	"hard-line-break":    parser.HardLineBreak,
+	"footnotes":          parser.Footnotes,
+	"heading-ids":        parser.HeadingIDs,
+	"auto-heading-ids":   parser.AutoHeadingIDs,
+	"backslash-break":    parser.BackslashLineBreak,
+	"definition-lists":   parser.DefinitionLists,
+	"mathjax":            parser.MathJax,
+	"ordered-list-start": parser.OrderedListStart,
+	"attributes":         parser.Attributes,
+	"super-subscript":    parser.SuperSubscript,
+}
+
+var DEFAULT_EXTENSIONS = []string{EXTENSION_TASK_LISTS}
+
+const SMART_PUNCTUATION_FLAGS = html.Smartypants | html.SmartypantsFractions | html.SmartypantsDashes | html.SmartypantsLatexDashes
+const SANITIZE_FLAGS = html.SkipHTML | html.Safelink
+const PARAGRAPH_START = "<p>"
+const PARAGRAPH_END = "</p>"
+
+type MarkdownOptions struct {
+	Enable   []string
+	Disable  []string
+	Sanitize bool
+}
+
+type markdownReferences struct {
+	Links    []string
+	Images   []string
+	Headings []Heading
+	Words    int
+	Content  string
+	Media    []MediaReference
+}
+
+type markdownEngine struct {
+	extensions  parser.Extensions
+	features    map[string]bool
+	flags       html.Flags
+	idPrefix    string
+	topLevel    int
+	normalize   bool
+	comments    bool
+	destination func(destination string, image bool) string
+	sourceSet   func(destination string) (string, bool)
+	imageSizes  string
+
+	abbreviations map[string]string
+	codeBlocks    *CodeBlockWrapper
+	returnLink    FootnoteReturnLink
+	site          *Site
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buffer *bytes.Buffer) {
+	buffer.Reset()
+	bufferPool.Put(buffer)
+}
+
+func isKnownExtension(name string) bool {
+	_, found := PARSER_EXTENSIONS[name]
+	return found || name == EXTENSION_TASK_LISTS
+}
+
+func checkMarkdownOptions(options MarkdownOptions) error {
+	for _, names := range [][]string{options.Enable, options.Disable} {
+		for _, name := range names {
+			if !isKnownExtension(name) {
+				msg := fmt.Sprintf("unknown markdown extension '%s'", name)
+				return errors.New(msg)
+			}
+		}
+	}
+	return nil
+}
+
+func newMarkdownEngine(configuration Configuration, abbreviations map[string]string) markdownEngine {
+	options := configuration.Markdown
+	engine := markdownEngine{
+		extensions: parser.CommonExtensions,
+		features:   map[string]bool{},
+		flags:      html.CommonFlags &^ SMART_PUNCTUATION_FLAGS,
+		returnLink: configuration.FootnoteReturnLink,
+		topLevel:   topHeadingLevel(configuration),
+		normalize:  configuration.NormalizeHeadings,
+		comments:   configuration.StripComments,
+	}
+	engine.abbreviations = abbreviations
+	engine.codeBlocks = configuration.CodeBlockWrapper
+	if configuration.SmartPunctuation == nil || *configuration.SmartPunctuation {
+		engine.flags |= SMART_PUNCTUATION_FLAGS
+		if configuration.AngledQuotes {
+			engine.flags |= html.SmartypantsAngledQuotes
+		}
+	}
+	if options.Sanitize {
+		engine.flags |= SANITIZE_FLAGS
+	}
+	for _, name := range DEFAULT_EXTENSIONS {
+		engine.features[name] = true
+	}
+	for _, name := range options.Enable {
+		engine.extensions |= PARSER_EXTENSIONS[name]
+		engine.features[name] = true
+	}
+	for _, name := range options.Disable {
+		engine.extensions &^= PARSER_EXTENSIONS[name]
+		delete(engine.features, name)
+	}
+	return engine
+}
+
+func taskMarker(item *ast.ListItem) (*ast.Text, string) {
+	if item.ListFlags&(ast.ListTypeDefinition|ast.ListTypeTerm) != 0 || item.RefLink != nil {
+		return nil, ""
+	}
+	children := item.GetChildren()
+	if len(children) == 0 {
+		return nil, ""
+	}
+	paragraph, isParagraph := children[0].(*ast.Paragraph)
+	if !isParagraph || len(paragraph.Children) == 0 {
+		return nil, ""
+	}
+	text, isText := paragraph.Children[0].(*ast.Text)
+	if !isText {
+		return nil, ""
+	}
+	for _, marker := range []string{"[ ] ", "[x] ", "[X] "} {
+		if bytes.HasPrefix(text.Literal, []byte(marker)) {
+			return text, marker
+		}
+	}
+	return nil, ""
+}
+
+func markTaskLists(document ast.Node) (map[ast.Node]bool, map[ast.Node]bool) {
+	lists := map[ast.Node]bool{}
+	items := map[ast.Node]bool{}
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		item, isItem := node.(*ast.ListItem)
+		if !entering || !isItem {
+			return ast.GoToNext
+		}
+		text, marker := taskMarker(item)
+		if text == nil {
+			return ast.GoToNext
+		}
+		checkbox := TASK_CHECKBOX
+		if marker != "[ ] " {
+			checkbox = TASK_CHECKBOX_CHECKED
+		}
+		text.Literal = text.Literal[len(marker)-1:]
+		paragraph := text.Parent
+		span := &ast.HTMLSpan{}
+		span.Literal = []byte(checkbox)
+		span.Parent = paragraph
+		container := paragraph.AsContainer()
+		container.Children = append([]ast.Node{span}, container.Children...)
+		items[item] = true
+		lists[item.Parent] = true
+		return ast.GoToNext
+	})
+	return lists, items
+}
+
+func withClass(render func(io.Writer), tag string, class string) []byte {
+	var buffer bytes.Buffer
+	render(&buffer)
+	classed := fmt.Sprintf(`%s class="%s"`, tag, class)
+	return bytes.Replace(buffer.Bytes(), []byte(tag), []byte(classed), 1)
+}
+
+type nodeHook func(renderer *html.Renderer, writer io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool)
+
+func taskListHook(lists map[ast.Node]bool, items map[ast.Node]bool) nodeHook {
+	return func(renderer *html.Renderer, writer io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		if !entering {
+			return ast.GoToNext, false
+		}
+		switch typed := node.(type) {
+		case *ast.List:
+			if lists[node] {
+				tag := "<ul"
+				if typed.ListFlags&ast.ListTypeOrdered != 0 {
+					tag = "<ol"
+				}
+				writer.Write(withClass(func(buffer io.Writer) { renderer.List(buffer, typed, true) }, tag, TASK_LIST_CLASS))
+				return ast.GoToNext, true
+			}
+		case *ast.ListItem:
+			if items[node] {
+				writer.Write(withClass(func(buffer io.Writer) { renderer.ListItem(buffer, typed, true) }, "<li", TASK_ITEM_CLASS))
+				return ast.GoToNext, true
+			}
+		}
+		return ast.GoToNext, false
+	}
+}
+
+func collectReferences(document ast.Node) markdownReferences {
+	var references markdownReferences
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch typed := node.(type) {
+		case *ast.Link:
+			if typed.NoteID == 0 && len(typed.Destination) > 0 {
+				references.Links = append(references.Links, string(typed.Destination))
+			}
+		case *ast.Image:
+			if len(typed.Destination) > 0 {
+				references.Images = append(references.Images, string(typed.Destination))
+			}
+		}
+		return ast.GoToNext
+	})
+	return references
+}
+
+func rewriteDestinations(document ast.Node, destination func(string, bool) string) {
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			switch typed := node.(type) {
+			case *ast.Link:
+				if typed.NoteID == 0 {
+					typed.Destination = []byte(destination(string(typed.Destination), false))
+				}
+			case *ast.Image:
+				typed.Destination = []byte(destination(string(typed.Destination), true))
+			}
+		}
+		return ast.GoToNext
+	})
+}
+
+func (engine markdownEngine) render(md []byte, name string) (string, markdownReferences, error) {
+	var renderer *html.Renderer
+	var hooks []nodeHook
+	markdownParser := parser.NewWithExtensions(engine.extensions &^ parser.HeadingIDs)
+	document := markdownParser.Parse(md)
+	explicit := explicitHeadingIds(document, engine.extensions&parser.HeadingIDs != 0)
+	normalizeHeadingIds(document, md, engine.extensions&^parser.HeadingIDs, explicit)
+	options := html.RendererOptions{Flags: engine.flags}
+	options.HeadingIDPrefix = engine.idPrefix
+	if engine.comments {
+		stripComments(document)
+	}
+	if engine.destination != nil {
+		rewriteDestinations(document, engine.destination)
+	}
+	if engine.normalize {
+		normalizeHeadings(document, engine.topLevel)
+	}
+	registry := newIdRegistry()
+	err := allocateHeadingIds(document, registry, explicit)
+	if err != nil {
+		return "", markdownReferences{}, err
+	}
+	if len(engine.abbreviations) > 0 {
+		expandAbbreviations(document, engine.abbreviations)
+	}
+	if engine.codeBlocks != nil {
+		hooks = append(hooks, codeBlockHook(*engine.codeBlocks))
+	}
+	if engine.sourceSet != nil {
+		hooks = append(hooks, imageHook(engine.sourceSet, engine.imageSizes))
+	}
+	if engine.features[EXTENSION_TASK_LISTS] {
+		lists, items := markTaskLists(document)
+		if len(items) > 0 {
+			hooks = append(hooks, taskListHook(lists, items))
+		}
+	}
+	if engine.extensions&parser.Footnotes != 0 {
+		footnotes := collectFootnotes(document)
+		if len(footnotes.counts) > 0 {
+			hooks = append(hooks, footnoteHook(footnotes, footnotePrefix(name), engine.returnLink, registry))
+		}
+	}
+	if len(hooks) > 0 {
+		options.RenderNodeHook = func(writer io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+			for _, hook := range hooks {
+				status, handled := hook(renderer, writer, node, entering)
+				if handled {
+					return status, true
+				}
+			}
+			return ast.GoToNext, false
+		}
+	}
+	renderer = html.NewRenderer(options)
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	renderer.RenderHeader(buffer, document)
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		return renderer.RenderNode(buffer, node, entering)
+	})
+	renderer.RenderFooter(buffer, document)
+	references := collectReferences(document)
+	references.Headings = collectHeadings(document)
+	references.Media = collectMedia(document)
+	return buffer.String(), references, nil
+}
+
+func (engine markdownEngine) renderPage(md []byte, configuration Configuration, name string, line int) (string, markdownReferences, error) {
+	md, abbreviations := stripAbbreviations(md)
+	if len(abbreviations) > 0 {
+		for term, title := range engine.abbreviations {
+			if _, defined := abbreviations[term]; !defined {
+				abbreviations[term] = title
+			}
+		}
+		engine.abbreviations = abbreviations
+	}
+	expanded, snippets, err := expandShortcodes(md, configuration, engine.site, name, line)
+	if err != nil {
+		return "", markdownReferences{}, err
+	}
+	content, references, err := engine.render(expanded, name)
+	if err != nil {
+		return "", references, err
+	}
+	return restoreShortcodes(content, snippets), references, nil
+}
+
+func renderDocument(md []byte, configuration Configuration, abbreviations map[string]string, name string, line int) (string, markdownReferences, error) {
+	return newMarkdownEngine(configuration, abbreviations).renderPage(md, configuration, name, line)
+}
+
+func renderMarkdown(md []byte, configuration Configuration, abbreviations map[string]string, name string) string {
+	content, _, _ := newMarkdownEngine(configuration, abbreviations).render(md, name)
+	return content
+}
+
+func renderInlineMarkdown(text string, configuration Configuration) template.HTML {
+	engine := newMarkdownEngine(configuration, nil)
+	document := parser.NewWithExtensions(engine.extensions).Parse([]byte(text))
+	renderer := html.NewRenderer(html.RendererOptions{Flags: engine.flags})
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	separate := false
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node.(type) {
+		case *ast.Text, *ast.Emph, *ast.Strong, *ast.Del, *ast.Code, *ast.Subscript, *ast.Superscript, *ast.Math:
+			if entering && separate {
+				buffer.WriteString(" ")
+				separate = false
+			}
+			return renderer.RenderNode(buffer, node, entering)
+		case *ast.Softbreak, *ast.Hardbreak:
+			separate = true
+		case *ast.Image, *ast.HTMLSpan, *ast.HTMLBlock, *ast.CodeBlock, *ast.MathBlock, *ast.HorizontalRule:
+			return ast.SkipChildren
+		case *ast.Paragraph, *ast.Heading, *ast.TableCell, *ast.ListItem:
+			separate = buffer.Len() > 0
+		}
+		return ast.GoToNext
+	})
+	return template.HTML(strings.TrimSpace(buffer.String()))
+}
+
+func renderMarkdownify(text string, configuration Configuration, abbreviations map[string]string) template.HTML {
+	content := strings.TrimSpace(renderMarkdown([]byte(text), configuration, abbreviations, ""))
+	inner := strings.TrimSuffix(strings.TrimPrefix(content, PARAGRAPH_START), PARAGRAPH_END)
+	if len(inner) == len(content)-len(PARAGRAPH_START)-len(PARAGRAPH_END) && !strings.Contains(inner, PARAGRAPH_START) {
+		content = inner
+	}
+	return template.HTML(content)
+}
+
+func renderTitle(title string, configuration Configuration) template.HTML {
+	if !configuration.RenderTitles {
+		return template.HTML(htmlEscape.EscapeString(title))
+	}
+	return renderInlineMarkdown(title, configuration)
+}
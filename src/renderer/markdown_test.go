@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"testing"
+)
+
+func TestTaskLists(t *testing.T) {
+	open := `<li class="task-list-item">` + TASK_CHECKBOX
+	done := `<li class="task-list-item">` + TASK_CHECKBOX_CHECKED
+	tests := []struct {
+		name    string
+		md      string
+		disable bool
+		want    string
+	}{
+		{"nested", "- [ ] outer\n  - [x] inner\n  - plain\n- [X] done\n", false,
+			"<ul class=\"task-list\">\n" + open + " outer\n\n<ul class=\"task-list\">\n" + done + " inner</li>\n<li>plain</li>\n</ul></li>\n" + done + " done</li>\n</ul>\n"},
+		{"ordered", "1. [ ] first\n2. [x] second\n", false,
+			"<ol class=\"task-list\">\n" + open + " first</li>\n" + done + " second</li>\n</ol>\n"},
+		{"links and code", "- [ ] see [docs](https://example.com) and `[x] code`\n- [x] `go test`\n- [ ] [x]\n", false,
+			"<ul class=\"task-list\">\n" + open + " see <a href=\"https://example.com\">docs</a> and <code>[x] code</code></li>\n" + done + " <code>go test</code></li>\n" + open + " [x]</li>\n</ul>\n"},
+		{"not tasks", "- [ ]\n- [x]not a task\n", false, "<ul>\n<li>[ ]</li>\n<li>[x]not a task</li>\n</ul>\n"},
+		{"disabled", "- [ ] a\n", true, "<ul>\n<li>[ ] a</li>\n</ul>\n"},
+	}
+	for _, test := range tests {
+		configuration := Configuration{}
+		if test.disable {
+			configuration.Markdown.Disable = []string{EXTENSION_TASK_LISTS}
+		}
+		if got := renderMarkdown([]byte(test.md), configuration, nil, ""); got != test.want {
+			t.Errorf("%s: renderMarkdown(%q) = %q, want %q", test.name, test.md, got, test.want)
+		}
+	}
+}
+
+func TestSmartPunctuation(t *testing.T) {
+	off, on := false, true
+	prose := "\"Quoted\" and 'single' -- dash --- em ... 1/2\n"
+	code := "`\"code\" -- ...` and\n\n```\n\"block\" -- ...\n```\n"
+	codeHTML := "<p><code>&quot;code&quot; -- ...</code> and</p>\n\n<pre><code>&quot;block&quot; -- ...\n</code></pre>\n"
+	tests := []struct {
+		name          string
+		configuration Configuration
+		md            string
+		want          string
+	}{
+		{"default", Configuration{}, prose, "<p>&ldquo;Quoted&rdquo; and &lsquo;single&rsquo; &ndash; dash &mdash; em &hellip; <sup>1</sup>&frasl;<sub>2</sub></p>\n"},
+		{"off", Configuration{SmartPunctuation: &off}, prose, "<p>&quot;Quoted&quot; and 'single' -- dash --- em ... 1/2</p>\n"},
+		{"angled quotes", Configuration{SmartPunctuation: &on, AngledQuotes: true}, prose, "<p>&laquo;Quoted&raquo; and &lsquo;single&rsquo; &ndash; dash &mdash; em &hellip; <sup>1</sup>&frasl;<sub>2</sub></p>\n"},
+		{"angled quotes need smart punctuation", Configuration{SmartPunctuation: &off, AngledQuotes: true}, "\"Quoted\"\n", "<p>&quot;Quoted&quot;</p>\n"},
+		{"code is kept", Configuration{}, code, codeHTML},
+		{"code is kept with angled quotes", Configuration{AngledQuotes: true}, code, codeHTML},
+	}
+	for _, test := range tests {
+		if got := renderMarkdown([]byte(test.md), test.configuration, nil, ""); got != test.want {
+			t.Errorf("%s: renderMarkdown(%q) = %q, want %q", test.name, test.md, got, test.want)
+		}
+	}
+}
+
+func TestRenderMarkdownify(t *testing.T) {
+	sanitized := Configuration{Markdown: MarkdownOptions{Sanitize: true}}
+	tests := []struct {
+		name          string
+		configuration Configuration
+		text          string
+		want          string
+	}{
+		{"link", Configuration{}, "See [docs](https://example.com)", `See <a href="https://example.com">docs</a>`},
+		{"emphasis", Configuration{}, "*em* text", "<em>em</em> text"},
+		{"paragraphs are kept", Configuration{}, "one\n\ntwo", "<p>one</p>\n\n<p>two</p>"},
+		{"script kept without sanitize", Configuration{}, "Hi <script>alert(1)</script> there", "Hi <script>alert(1)</script> there"},
+		{"script dropped with sanitize", sanitized, "Hi <script>alert(1)</script> there", "Hi alert(1) there"},
+		{"link with sanitize", sanitized, "See [docs](https://example.com)", `See <a href="https://example.com">docs</a>`},
+		{"unsafe link with sanitize", sanitized, "See [x](javascript:alert(1))", "See <tt>x</tt>"},
+	}
+	for _, test := range tests {
+		if got := string(renderMarkdownify(test.text, test.configuration, nil)); got != test.want {
+			t.Errorf("%s: renderMarkdownify(%q) = %q, want %q", test.name, test.text, got, test.want)
+		}
+	}
+}
+
+func TestMarkdownifyDescription(t *testing.T) {
+	for _, sanitize := range []bool{false, true} {
+		site := newTestSite(t)
+		site.Configuration.Markdown.Sanitize = sanitize
+		site.writeFile(site.Configuration.TemplatePage, `<meta name="x">{{with .Params.description}}{{markdownify .}}{{end}}`)
+		site.page("a.md", `{"Title": "A", "Params": {"description": "Read [more](/b.html) <script>alert(1)</script>"}}`, "body\n")
+		site.page("b.md", `{"Title": "B"}`, "body\n")
+		site.mustBuild()
+		want := `<meta name="x">Read <a href="/b.html">more</a> <script>alert(1)</script>`
+		if sanitize {
+			want = `<meta name="x">Read <a href="/b.html">more</a> alert(1)`
+		}
+		if page := site.read("a.html"); page != want {
+			t.Errorf("sanitize %v: a.html = %q, want %q", sanitize, page, want)
+		}
+	}
+}
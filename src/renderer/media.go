@@ -0,0 +1,147 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gomarkdown/markdown/ast"
+	"golang.org/x/net/html"
+)
+
+const MEDIA_FILE_NAME = "media.json"
+
+type MediaReference struct {
+	Target     string
+	Alt        string
+	Title      string
+	MissingAlt bool
+}
+
+type MediaUse struct {
+	Page      string
+	Url       string
+	Reference string
+	Alt       string
+	Title     string
+}
+
+type MediaImage struct {
+	Target string
+	Uses   []MediaUse
+}
+
+func htmlImages(literal []byte) []MediaReference {
+	var images []MediaReference
+	if !bytes.Contains(bytes.ToLower(literal), []byte("<img")) {
+		return images
+	}
+	tokenizer := html.NewTokenizer(bytes.NewReader(literal))
+	for {
+		kind := tokenizer.Next()
+		if kind == html.ErrorToken {
+			break
+		}
+		if kind != html.StartTagToken && kind != html.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		if token.Data != "img" {
+			continue
+		}
+		image := MediaReference{MissingAlt: true}
+		for _, attribute := range token.Attr {
+			switch attribute.Key {
+			case "src":
+				image.Target = attribute.Val
+			case "alt":
+				image.Alt = attribute.Val
+				image.MissingAlt = false
+			case "title":
+				image.Title = attribute.Val
+			}
+		}
+		if len(image.Target) > 0 {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+func collectMedia(document ast.Node) []MediaReference {
+	var media []MediaReference
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch typed := node.(type) {
+		case *ast.Image:
+			if len(typed.Destination) > 0 {
+				alt := headingText(typed)
+				media = append(media, MediaReference{
+					Target:     string(typed.Destination),
+					Alt:        alt,
+					Title:      string(typed.Title),
+					MissingAlt: len(alt) == 0,
+				})
+			}
+		case *ast.HTMLSpan:
+			media = append(media, htmlImages(typed.Literal)...)
+		case *ast.HTMLBlock:
+			media = append(media, htmlImages(typed.Literal)...)
+		}
+		return ast.GoToNext
+	})
+	return media
+}
+
+func checkMedia(builder *Builder, entry *pageLog, source Source) {
+	if !builder.Configuration.MediaIndex {
+		return
+	}
+	for _, image := range source.Media {
+		if image.MissingAlt {
+			detail := fmt.Sprintf("image '%s' has no alt text", image.Target)
+			builder.reportTo(entry, CONDITION_A11Y, source.Path, detail)
+		}
+	}
+}
+
+func buildMediaIndex(sources []Source) []MediaImage {
+	images := []MediaImage{}
+	positions := map[string]int{}
+	for _, source := range sources {
+		if !isPublic(source) {
+			continue
+		}
+		for _, image := range source.Media {
+			key := image.Target
+			if target, internal := referenceTarget(source.OutputPath, image.Target); internal {
+				key = "/" + target
+			}
+			position, found := positions[key]
+			if !found {
+				position = len(images)
+				positions[key] = position
+				images = append(images, MediaImage{Target: key})
+			}
+			images[position].Uses = append(images[position].Uses, MediaUse{
+				Page:      source.Name,
+				Url:       source.Link.Url,
+				Reference: image.Target,
+				Alt:       image.Alt,
+				Title:     image.Title,
+			})
+		}
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Target < images[j].Target
+	})
+	return images
+}
+
+func renderMediaIndex(images []MediaImage) ([]byte, error) {
+	data, err := json.MarshalIndent(images, "", "  ")
+	return append(data, '\n'), err
+}
@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const MAX_MENU_DEPTH = 2
+const INDEX_URL = "/index.html"
+
+type MenuEntry struct {
+	Name     string
+	URL      string
+	Page     string
+	Children []MenuEntry
+}
+
+type MenuItem struct {
+	Name     string
+	Url      string
+	Active   bool
+	Children []MenuItem
+}
+
+func menuPageName(page string) string {
+	name := strings.TrimPrefix(page, "/")
+	if !strings.HasSuffix(name, MARKDOWN_FILE_ENDING) {
+		name += MARKDOWN_FILE_ENDING
+	}
+	return name
+}
+
+func checkMenuEntries(configuration Configuration, entries []MenuEntry, depth int) error {
+	var err error
+	for _, entry := range entries {
+		if len(entry.Name) == 0 {
+			err = errors.New("menu entry without Name")
+		} else if len(entry.URL) > 0 && len(entry.Page) > 0 {
+			msg := fmt.Sprintf("menu entry '%s' sets both URL and Page", entry.Name)
+			err = errors.New(msg)
+		} else if len(entry.URL) == 0 && len(entry.Page) == 0 && len(entry.Children) == 0 {
+			msg := fmt.Sprintf("menu entry '%s' needs a URL, a Page or Children", entry.Name)
+			err = errors.New(msg)
+		} else if len(entry.Children) > 0 && depth >= MAX_MENU_DEPTH {
+			msg := fmt.Sprintf("menu entry '%s' is nested deeper than %d levels", entry.Name, MAX_MENU_DEPTH)
+			err = errors.New(msg)
+		} else if len(entry.Page) > 0 {
+			_, err = resolvePageArgument(configuration, menuPageName(entry.Page))
+			if err != nil {
+				msg := fmt.Sprintf("menu entry '%s': %s", entry.Name, err)
+				err = errors.New(msg)
+			}
+		}
+		if err == nil {
+			err = checkMenuEntries(configuration, entry.Children, depth+1)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return err
+}
+
+func checkMenu(configuration Configuration) error {
+	return checkMenuEntries(configuration, configuration.Menu, 1)
+}
+
+func isCurrentUrl(url string, current string) bool {
+	if url == "/" {
+		url = INDEX_URL
+	}
+	return len(url) > 0 && url == current
+}
+
+func resolveMenu(entries []MenuEntry, current string, site *Site) []MenuItem {
+	var items []MenuItem
+	for _, entry := range entries {
+		url := entry.URL
+		if len(entry.Page) > 0 {
+			url = site.pageUrl(menuPageName(entry.Page))
+		}
+		items = append(items, MenuItem{
+			Name:     entry.Name,
+			Url:      url,
+			Active:   isCurrentUrl(url, current),
+			Children: resolveMenu(entry.Children, current, site),
+		})
+	}
+	return items
+}
@@ -0,0 +1,333 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const META_FENCE = "```"
+const META_FENCE_JSON = "json"
+const META_BLOCK_START = META_FENCE + META_FENCE_JSON + "\n"
+const META_BLOCK_END = "```\n"
+const HTML_COMMENT_START = "<!--"
+const HTML_COMMENT_END = "-->"
+const META_SNIPPET_LENGTH = 60
+
+var DEFAULT_META_FENCES = []string{META_FENCE_JSON}
+var RELAXED_META_FENCES = map[string]bool{
+	"jsonc": true,
+	"json5": true,
+}
+
+var errMissingMetaStart = errors.New("missing meta code block start")
+var errMissingMeta = errors.New("meta block error: missing meta code block start")
+var errEmptySource = errors.New("file is empty")
+
+func isBlankLine(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+func lineLength(text []byte, offset int) int {
+	lineEnd := bytes.IndexByte(text[offset:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(text) - offset
+	} else {
+		lineEnd++
+	}
+	return lineEnd
+}
+
+func skipLeadingNoise(text []byte) int {
+	offset := 0
+	for offset < len(text) {
+		lineEnd := lineLength(text, offset)
+		line := text[offset : offset+lineEnd]
+		if isBlankLine(line) {
+			offset += lineEnd
+			continue
+		}
+		trimmed := bytes.TrimLeft(line, " \t")
+		if !bytes.HasPrefix(trimmed, []byte(HTML_COMMENT_START)) {
+			break
+		}
+		commentStart := offset + len(line) - len(trimmed)
+		commentEnd := bytes.Index(text[commentStart:], []byte(HTML_COMMENT_END))
+		if commentEnd == -1 {
+			break
+		}
+		afterComment := commentStart + commentEnd + len(HTML_COMMENT_END)
+		restEnd := lineLength(text, afterComment)
+		if !isBlankLine(text[afterComment : afterComment+restEnd]) {
+			break
+		}
+		offset = afterComment + restEnd
+	}
+	return offset
+}
+
+func metaFence(text []byte, fences []string) (string, int, bool) {
+	if len(fences) == 0 {
+		fences = DEFAULT_META_FENCES
+	}
+	for _, fence := range fences {
+		for _, ending := range []string{"\n", "\r\n"} {
+			opening := META_FENCE + fence + ending
+			if bytes.HasPrefix(text, []byte(opening)) {
+				return fence, len(opening), true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func metaBlockEnd(text []byte) (int, int) {
+	offset := 0
+	for {
+		index := bytes.Index(text[offset:], []byte(META_FENCE))
+		if index == -1 {
+			return -1, 0
+		}
+		fenceEnd := offset + index + len(META_FENCE)
+		rest := text[fenceEnd:]
+		switch {
+		case len(rest) == 0:
+			return offset + index, len(META_FENCE)
+		case bytes.HasPrefix(rest, []byte("\n")):
+			return offset + index, len(META_BLOCK_END)
+		case bytes.HasPrefix(rest, []byte("\r\n")):
+			return offset + index, len(META_FENCE) + 2
+		}
+		offset += index + 1
+	}
+}
+
+func metaPosition(text []byte, offset int) (int, int, string) {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	lineStart := bytes.LastIndexByte(text[:offset], '\n') + 1
+	lineEnd := lineStart + lineLength(text, lineStart)
+	snippet := strings.TrimSpace(string(text[lineStart:lineEnd]))
+	if len(snippet) > META_SNIPPET_LENGTH {
+		snippet = snippet[:META_SNIPPET_LENGTH] + "..."
+	}
+	line := bytes.Count(text[:offset], []byte("\n")) + 1
+	return line, offset - lineStart + 1, snippet
+}
+
+func metaLocationError(text []byte, offset int, detail string) error {
+	line, column, snippet := metaPosition(text, offset)
+	msg := fmt.Sprintf("%d:%d: %s: %s", line, column, detail, snippet)
+	return errors.New(msg)
+}
+
+func metaDecodeError(text []byte, metaStart int, metaBlockText []byte, err error) error {
+	switch typed := err.(type) {
+	case authorError:
+		return metaLocationError(text, metaStart+authorsOffset(metaBlockText), typed.detail)
+	case *json.SyntaxError:
+		offset := int(typed.Offset)
+		if offset > 0 {
+			offset--
+		}
+		return metaLocationError(text, metaStart+offset, typed.Error())
+	case *json.UnmarshalTypeError:
+		detail := fmt.Sprintf("cannot use a JSON %s as %s", typed.Value, typed.Type)
+		if len(typed.Field) > 0 {
+			detail = fmt.Sprintf("%s cannot be a JSON %s, it must be %s", typed.Field, typed.Value, typed.Type)
+		}
+		return metaLocationError(text, metaStart+jsonValueStart(metaBlockText, int(typed.Offset)), detail)
+	}
+	return err
+}
+
+func jsonValueStart(metaBlockText []byte, end int) int {
+	decoder := json.NewDecoder(bytes.NewReader(metaBlockText))
+	for {
+		start := skipJsonSeparators(metaBlockText, int(decoder.InputOffset()))
+		_, err := decoder.Token()
+		if err != nil || int(decoder.InputOffset()) > end {
+			break
+		}
+		if int(decoder.InputOffset()) == end {
+			return start
+		}
+	}
+	if end > 0 {
+		end--
+	}
+	return end
+}
+
+func unknownMetaField(text []byte, metaStart int, metaBlockText []byte, configuration Configuration) error {
+	known := metaFieldNames()
+	for _, key := range taxonomies(configuration) {
+		known[strings.ToLower(key)] = true
+	}
+	decoder := json.NewDecoder(bytes.NewReader(metaBlockText))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		return nil
+	}
+	for decoder.More() {
+		token, err = decoder.Token()
+		key, isKey := token.(string)
+		if err != nil || !isKey {
+			return nil
+		}
+		keyEnd := int(decoder.InputOffset())
+		if !known[strings.ToLower(key)] {
+			quoted, _ := json.Marshal(key)
+			offset := keyEnd - len(quoted)
+			if offset < 0 || !bytes.Equal(metaBlockText[offset:keyEnd], quoted) {
+				offset = keyEnd
+			}
+			detail := fmt.Sprintf("unknown meta key '%s'", key)
+			return metaLocationError(text, metaStart+offset, detail)
+		}
+		var value json.RawMessage
+		if decoder.Decode(&value) != nil {
+			return nil
+		}
+		if strings.EqualFold(key, "Authors") && !bytes.HasPrefix(value, []byte("[")) {
+			return metaLocationError(text, metaStart+keyEnd, "Authors must be an array")
+		}
+	}
+	return nil
+}
+
+func getMetaBlock(text []byte, configuration Configuration) (MetaBlock, int, error) {
+	var metaBlock MetaBlock
+	var contentStart int
+	var err error
+	blockStart := skipLeadingNoise(text)
+	fence, opening, found := metaFence(text[blockStart:], configuration.MetaFences)
+	if found {
+		metaStart := blockStart + opening
+		index, closing := metaBlockEnd(text[metaStart:])
+		if index != -1 {
+			metaBlockText := text[metaStart : metaStart+index]
+			contentStart = metaStart + index + closing
+			if RELAXED_META_FENCES[fence] {
+				metaBlockText = stripTrailingCommas(stripJsonComments(metaBlockText))
+			}
+			err = json.Unmarshal(metaBlockText, &metaBlock)
+			if err != nil {
+				err = metaDecodeError(text, metaStart, metaBlockText, err)
+			}
+			if err == nil && configuration.DisallowUnknownFields {
+				err = unknownMetaField(text, metaStart, metaBlockText, configuration)
+			}
+			if err == nil {
+				metaBlock.Fields = metaFields(metaBlockText)
+				metaBlock.raw = metaBlockText
+			}
+		} else {
+			err = errors.New("missing meta code block end")
+		}
+	} else {
+		err = errMissingMetaStart
+	}
+	return metaBlock, contentStart, err
+}
+
+func checkMetaFences(fences []string) error {
+	for _, fence := range fences {
+		if fence != META_FENCE_JSON && !RELAXED_META_FENCES[fence] {
+			msg := fmt.Sprintf("unknown meta block fence '%s'", fence)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func stripJsonComments(data []byte) []byte {
+	stripped := make([]byte, 0, len(data))
+	inString := false
+	for index := 0; index < len(data); index++ {
+		character := data[index]
+		if inString {
+			stripped = append(stripped, character)
+			if character == '\\' && index+1 < len(data) {
+				index++
+				stripped = append(stripped, data[index])
+			} else if character == '"' {
+				inString = false
+			}
+			continue
+		}
+		if character == '"' {
+			inString = true
+		} else if bytes.HasPrefix(data[index:], []byte("//")) {
+			end := bytes.IndexByte(data[index:], '\n')
+			if end == -1 {
+				break
+			}
+			stripped = append(stripped, bytes.Repeat([]byte(" "), end)...)
+			index += end - 1
+			continue
+		} else if bytes.HasPrefix(data[index:], []byte("/*")) {
+			end := bytes.Index(data[index+2:], []byte("*/"))
+			if end == -1 {
+				break
+			}
+			for _, character := range data[index : index+end+4] {
+				if character != '\n' {
+					character = ' '
+				}
+				stripped = append(stripped, character)
+			}
+			index += end + 3
+			continue
+		}
+		stripped = append(stripped, character)
+	}
+	return stripped
+}
+
+func stripTrailingCommas(data []byte) []byte {
+	stripped := make([]byte, 0, len(data))
+	inString := false
+	for index := 0; index < len(data); index++ {
+		character := data[index]
+		if inString {
+			if character == '\\' && index+1 < len(data) {
+				stripped = append(stripped, character)
+				index++
+				character = data[index]
+			} else if character == '"' {
+				inString = false
+			}
+		} else if character == '"' {
+			inString = true
+		} else if character == ',' {
+			next := bytes.TrimLeft(data[index+1:], " \t\r\n")
+			if len(next) > 0 && (next[0] == '}' || next[0] == ']') {
+				character = ' '
+			}
+		}
+		stripped = append(stripped, character)
+	}
+	return stripped
+}
+
+func copyMetaBlock(metaBlock MetaBlock) MetaBlock {
+	metaBlock.Authors = append(AuthorList(nil), metaBlock.Authors...)
+	metaBlock.Tags = append([]string(nil), metaBlock.Tags...)
+	metaBlock.Outputs = append([]string(nil), metaBlock.Outputs...)
+	if metaBlock.Fields != nil {
+		fields := map[string][]string{}
+		for key, values := range metaBlock.Fields {
+			fields[key] = append([]string(nil), values...)
+		}
+		metaBlock.Fields = fields
+	}
+	return metaBlock
+}
+
+func isMissingMeta(err error) bool {
+	return err == errMissingMeta || err == errEmptySource
+}
@@ -0,0 +1,180 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+const TEST_META = "{\"Title\": \"A\"}\n"
+
+func TestMetaBlockAfterLeadingNoise(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		err    error
+	}{
+		{"no noise", "", nil},
+		{"leading blank line", "\n", nil},
+		{"leading whitespace lines", "  \n\t\n", nil},
+		{"leading comment", "<!-- draft -->\n", nil},
+		{"indented comment", "   <!-- draft -->\n", nil},
+		{"comment over several lines", "<!--\n  draft\n-->\n\n", nil},
+		{"comments and blank lines", "\n<!-- one -->\n\n<!-- two -->\n", nil},
+		{"crlf line endings", "\r\n<!-- draft -->\r\n", nil},
+		{"text after a comment", "<!-- draft --> text\n", errMissingMetaStart},
+		{"unclosed comment", "<!-- draft\n", errMissingMetaStart},
+		{"code block first", "```go\nfunc main() {}\n```\n\n", errMissingMetaStart},
+		{"paragraph first", "Hello\n\n", errMissingMetaStart},
+	}
+	body := "# Heading\n\nBody\n"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := test.before + META_BLOCK_START + TEST_META + META_BLOCK_END + body
+			meta, contentStart, err := getMetaBlock([]byte(text), Configuration{})
+			if err != test.err {
+				t.Fatalf("error = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if meta.Title != "A" {
+				t.Errorf("Title = %q, want A", meta.Title)
+			}
+			if text[contentStart:] != body {
+				t.Errorf("content = %q, want %q", text[contentStart:], body)
+			}
+		})
+	}
+}
+
+func TestLeadingCommentIsNotRendered(t *testing.T) {
+	site := newTestSite(t)
+	site.write("a.md", "<!-- draft -->\n\n"+META_BLOCK_START+TEST_META+META_BLOCK_END+"Body text\n")
+	site.mustBuild()
+	page := site.read("a.html")
+	if strings.Count(page, "Body text") != 1 {
+		t.Errorf("a.html = %q, want the body exactly once", page)
+	}
+	for _, unwanted := range []string{"draft", "Title", "```"} {
+		if strings.Contains(page, unwanted) {
+			t.Errorf("a.html = %q, contains %q", page, unwanted)
+		}
+	}
+}
+
+func TestMetaFences(t *testing.T) {
+	relaxed := []string{META_FENCE_JSON, "jsonc", "json5"}
+	tests := []struct {
+		name   string
+		fences []string
+		text   string
+		title  string
+		url    string
+		err    string
+	}{
+		{"strict json by default", nil, "```json\n{\"Title\": \"A\"}\n```\n", "A", "", ""},
+		{"jsonc is not accepted by default", nil, "```jsonc\n{\"Title\": \"A\"}\n```\n", "", "", "missing meta code block start"},
+		{"comments are not stripped from json", relaxed, "```json\n{\"Title\": \"A\" // note\n}\n```\n", "", "", "invalid character '/'"},
+		{"line comments", relaxed, "```jsonc\n{\n  // the title\n  \"Title\": \"A\" // trailing\n}\n```\n", "A", "", ""},
+		{"block comments", relaxed, "```json5\n{\n  /* the\n     title */\n  \"Title\": /* inline */ \"A\"\n}\n```\n", "A", "", ""},
+		{"trailing commas", relaxed, "```json5\n{\n  \"Title\": \"A\",\n  \"Tags\": [\"go\", \"web\",],\n}\n```\n", "A", "", ""},
+		{"url with a double slash", relaxed, "```jsonc\n{\"Title\": \"A\", \"Params\": {\"url\": \"https://example.com//path\"}} // source\n```\n", "A", "https://example.com//path", ""},
+		{"comment markers in a string", relaxed, "```jsonc\n{\"Title\": \"/* not a comment */ and \\\" // neither\"}\n```\n", "/* not a comment */ and \" // neither", "", ""},
+		{"comma inside a string", relaxed, "```jsonc\n{\"Title\": \"A,}\"}\n```\n", "A,}", "", ""},
+		{"unclosed block comment", relaxed, "```jsonc\n{\"Title\": \"A\" /* open\n}\n```\n", "", "", "unexpected end of JSON input"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			meta, _, err := getMetaBlock([]byte(test.text+"body\n"), Configuration{MetaFences: test.fences})
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if meta.Title != test.title {
+				t.Errorf("Title = %q, want %q", meta.Title, test.title)
+			}
+			if url, _ := meta.Params["url"].(string); url != test.url {
+				t.Errorf("Params url = %q, want %q", url, test.url)
+			}
+		})
+	}
+}
+
+func TestCheckMetaFences(t *testing.T) {
+	tests := []struct {
+		fences []string
+		err    string
+	}{
+		{nil, ""},
+		{[]string{"json", "jsonc", "json5"}, ""},
+		{[]string{"json", "yaml"}, "unknown meta block fence 'yaml'"},
+	}
+	for _, test := range tests {
+		err := checkMetaFences(test.fences)
+		if (len(test.err) == 0 && err != nil) || (len(test.err) > 0 && (err == nil || err.Error() != test.err)) {
+			t.Errorf("checkMetaFences(%q) = %v, want %q", test.fences, err, test.err)
+		}
+	}
+}
+
+func TestMetaBlockErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    string
+		unknown bool
+		err     string
+	}{
+		{"missing comma", "{\n  \"Title\": \"A\"\n  \"Tags\": [\"go\"]\n}\n", false, "4:3: invalid character '\"' after object key:value pair: \"Tags\": [\"go\"]"},
+		{"unclosed string", "{\n  \"Title\": \"A\n}\n", false, "3:14: invalid character '\\n' in string: \"Title\": \"A"},
+		{"title is a number", "{\n  \"Title\": 42\n}\n", false, "3:12: Title cannot be a JSON number, it must be string: \"Title\": 42"},
+		{"tags is a string", "{\"Title\": \"A\",\n\"Tags\": \"go\"}\n", false, "3:9: Tags cannot be a JSON string, it must be []string: \"Tags\": \"go\"}"},
+		{"tag is a number", "{\"Title\": \"A\", \"Tags\": [\"go\", 16]}\n", false, "2:31: Tags.1 cannot be a JSON number, it must be string: {\"Title\": \"A\", \"Tags\": [\"go\", 16]}"},
+		{"title is an object over several lines", "{\n  \"Title\": {\n    \"en\": \"A\"\n  }\n}\n", false, "3:12: Title cannot be a JSON object, it must be string: \"Title\": {"},
+		{"unknown key is ignored by default", "{\"Title\": \"A\", \"Titel\": \"B\"}\n", false, ""},
+		{"unknown key", "{\n  \"Title\": \"A\",\n  \"Titel\": \"B\"\n}\n", true, "4:3: unknown meta key 'Titel': \"Titel\": \"B\""},
+		{"known keys in any case", "{\"title\": \"A\", \"TAGS\": [], \"Params\": {\"Titel\": 1}}\n", true, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := META_BLOCK_START + test.meta + META_BLOCK_END + "body\n"
+			_, _, err := getMetaBlock([]byte(text), Configuration{DisallowUnknownFields: test.unknown})
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Errorf("error = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.err {
+				t.Errorf("error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestMetaBlockErrorNamesTheFile(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		err  string
+	}{
+		{"syntax error", META_BLOCK_START + "{\"Title\": \"A\",}\n" + META_BLOCK_END, "2:15: invalid character '}'"},
+		{"type error after a comment", "<!-- draft -->\n\n" + META_BLOCK_START + "{\n\"Title\": []}\n" + META_BLOCK_END, "5:10: Title cannot be a JSON array"},
+		{"unknown key", META_BLOCK_START + "{\"Titel\": \"A\"}\n" + META_BLOCK_END, "2:2: unknown meta key 'Titel'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.DisallowUnknownFields = true
+			site.write("broken.md", test.text+"body\n")
+			err := site.build()
+			if err == nil || !strings.Contains(err.Error(), site.path("content/broken.md")) || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("build error = %v, want %q in broken.md", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package renderer
+
+import (
+	"path/filepath"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"golang.org/x/text/unicode/norm"
+)
+
+func normalizeName(name string) string {
+	return norm.NFC.String(name)
+}
+
+func documentHeadings(document ast.Node) []*ast.Heading {
+	var found []*ast.Heading
+	ast.WalkFunc(document, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, isHeading := node.(*ast.Heading); isHeading && entering {
+			found = append(found, heading)
+		}
+		return ast.GoToNext
+	})
+	return found
+}
+
+func normalizeHeadingIds(document ast.Node, md []byte, extensions parser.Extensions, explicit map[*ast.Heading]bool) {
+	if extensions&parser.AutoHeadingIDs == 0 || norm.NFC.IsNormal(md) {
+		return
+	}
+	normalized := documentHeadings(parser.NewWithExtensions(extensions).Parse(norm.NFC.Bytes(md)))
+	original := documentHeadings(document)
+	if len(normalized) != len(original) {
+		return
+	}
+	for index, heading := range original {
+		if !explicit[heading] {
+			heading.HeadingID = normalized[index].HeadingID
+		}
+	}
+}
+
+func diskPath(root string, name string) string {
+	candidate := filepath.Join(root, filepath.FromSlash(name))
+	if CheckPathError(candidate) == nil {
+		return candidate
+	}
+	decomposed := filepath.Join(root, filepath.FromSlash(norm.NFD.String(name)))
+	if CheckPathError(decomposed) == nil {
+		return decomposed
+	}
+	return candidate
+}
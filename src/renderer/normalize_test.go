@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var TEST_HEADING_ID = regexp.MustCompile(`<h2 id="([^"]*)"`)
+
+func TestNormalizationForms(t *testing.T) {
+	type built struct {
+		files []string
+		index string
+		ids   []string
+	}
+	outputs := map[string]built{}
+	for _, form := range []norm.Form{norm.NFC, norm.NFD} {
+		name := map[norm.Form]string{norm.NFC: "NFC", norm.NFD: "NFD"}[form]
+		site := newTestSite(t)
+		site.Configuration.BaseURL = "https://example.com/"
+		site.Configuration.Markdown = MarkdownOptions{Enable: []string{"heading-ids", "auto-heading-ids"}}
+		site.Configuration.Taxonomies = map[string]string{"tags": "tags"}
+		body := form.String("## Café au lait\n\n```\ncafé\n```\n")
+		site.page(form.String("café.md"), form.String(`{"Title": "Menu", "Tags": ["Café"]}`), body)
+		site.page("other.md", norm.NFC.String(`{"Title": "Other", "Tags": ["Café"]}`), form.String("## Coffee {#café}\n"))
+		site.mustBuild()
+		var files []string
+		err := filepath.Walk(site.Configuration.Output, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && info.Name() != CACHE_FILE_NAME {
+				relative, _ := filepath.Rel(site.Configuration.Output, path)
+				files = append(files, filepath.ToSlash(relative))
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			if !norm.NFC.IsNormalString(file) {
+				t.Errorf("%s: output file %q is not NFC", name, file)
+			}
+		}
+		page := site.read(norm.NFC.String("café.html"))
+		if !strings.Contains(page, form.String("Café au lait")) || !strings.Contains(page, form.String("café\n</code>")) {
+			t.Errorf("%s: café.html = %q, want the heading and code block as written", name, page)
+		}
+		var ids []string
+		for _, file := range []string{norm.NFC.String("café.html"), "other.html"} {
+			for _, match := range TEST_HEADING_ID.FindAllStringSubmatch(site.read(file), -1) {
+				ids = append(ids, match[1])
+			}
+		}
+		outputs[name] = built{files: files, index: site.read(INDEX_FILE_NAME), ids: ids}
+	}
+	nfc, nfd := outputs["NFC"], outputs["NFD"]
+	if strings.Join(nfc.files, ", ") != strings.Join(nfd.files, ", ") {
+		t.Errorf("output files differ:\nNFC %v\nNFD %v", nfc.files, nfd.files)
+	}
+	if nfc.index != nfd.index {
+		t.Errorf("index differs:\nNFC %s\nNFD %s", nfc.index, nfd.index)
+	}
+	want := norm.NFC.String("café-au-lait café")
+	if got := strings.Join(nfd.ids, " "); got != want || strings.Join(nfc.ids, " ") != want {
+		t.Errorf("heading ids = %v (NFC) and %v (NFD), want %q", nfc.ids, nfd.ids, want)
+	}
+	terms := 0
+	for _, file := range nfd.files {
+		if strings.HasPrefix(file, "tags/") {
+			terms++
+		}
+	}
+	if terms != 1 {
+		t.Errorf("NFD tag pages = %v, want one page for both spellings of Café", nfd.files)
+	}
+}
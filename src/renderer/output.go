@@ -0,0 +1,403 @@
+package renderer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ZIP_FILE_ENDING = ".zip"
+const TAR_GZ_FILE_ENDING = ".tar.gz"
+const ARCHIVE_FILE_MODE = 0644
+
+var ARCHIVE_MOD_TIME = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type OutputWriter interface {
+	Create(path string) (io.WriteCloser, error)
+	Finish() error
+}
+
+type OutputSink interface {
+	OutputWriter
+	MkdirAll(path string) error
+	Remove(path string) error
+}
+
+type fileOutput struct {
+	root string
+}
+
+type fileEntry struct {
+	file *os.File
+	err  error
+}
+
+type archiveOutput struct {
+	path  string
+	files map[string]*bytes.Buffer
+}
+
+type archiveEntry struct {
+	buffer *bytes.Buffer
+}
+
+type dryRunOutput struct {
+	path     string
+	paths    []string
+	removals []string
+}
+
+type discardEntry struct{}
+
+type MemoryOutput struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+type memoryEntry struct {
+	path   string
+	buffer bytes.Buffer
+	output *MemoryOutput
+}
+
+func IsArchivePath(path string) bool {
+	return strings.HasSuffix(path, ZIP_FILE_ENDING) || strings.HasSuffix(path, TAR_GZ_FILE_ENDING)
+}
+
+func NewDryRunOutput(path string) OutputSink {
+	return &dryRunOutput{path: path}
+}
+
+func NewMemoryOutput() *MemoryOutput {
+	return &MemoryOutput{files: map[string][]byte{}}
+}
+
+func NewOutputWriter(path string) OutputSink {
+	if IsArchivePath(path) {
+		return &archiveOutput{path: path, files: map[string]*bytes.Buffer{}}
+	}
+	return &fileOutput{root: path}
+}
+
+func writeFile(output OutputWriter, path string, data []byte) error {
+	file, err := output.Create(path)
+	if err == nil {
+		_, err = file.Write(data)
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func entryName(path string, kind string) (string, error) {
+	name := normalizeName(filepath.ToSlash(filepath.Clean(path)))
+	if strings.HasPrefix(name, "../") || filepath.IsAbs(path) {
+		msg := fmt.Sprintf("%s entry '%s' is outside of the %s", kind, path, kind)
+		return name, errors.New(msg)
+	}
+	return name, nil
+}
+
+func missingEntry(path string) error {
+	return &os.PathError{Op: "remove", Path: path, Err: os.ErrNotExist}
+}
+
+func (output *fileOutput) fullPath(path string) string {
+	return filepath.Join(output.root, filepath.FromSlash(normalizeName(path)))
+}
+
+func (output *fileOutput) Create(path string) (io.WriteCloser, error) {
+	var file *os.File
+	fullPath := output.fullPath(path)
+	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err == nil {
+		file, err = os.Create(fullPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fileEntry{file: file}, nil
+}
+
+func (entry *fileEntry) Write(data []byte) (int, error) {
+	written, err := entry.file.Write(data)
+	if err != nil && entry.err == nil {
+		entry.err = err
+	}
+	return written, err
+}
+
+func (entry *fileEntry) Close() error {
+	err := entry.file.Close()
+	if entry.err != nil {
+		err = entry.err
+	}
+	if err != nil {
+		os.Remove(entry.file.Name())
+	}
+	return err
+}
+
+func (output *fileOutput) MkdirAll(path string) error {
+	return os.MkdirAll(output.fullPath(path), 0755)
+}
+
+func (output *fileOutput) Remove(path string) error {
+	return os.Remove(output.fullPath(path))
+}
+
+func (output *fileOutput) Finish() error {
+	return nil
+}
+
+func (output *archiveOutput) Create(path string) (io.WriteCloser, error) {
+	var buffer bytes.Buffer
+	name, err := entryName(path, "archive")
+	if err == nil {
+		output.files[name] = &buffer
+	}
+	return archiveEntry{&buffer}, err
+}
+
+func (output *archiveOutput) MkdirAll(path string) error {
+	return nil
+}
+
+func (output *archiveOutput) Remove(path string) error {
+	name, err := entryName(path, "archive")
+	if err == nil {
+		if _, found := output.files[name]; !found {
+			return missingEntry(path)
+		}
+		delete(output.files, name)
+	}
+	return err
+}
+
+func (entry archiveEntry) Write(data []byte) (int, error) {
+	return entry.buffer.Write(data)
+}
+
+func (entry archiveEntry) Close() error {
+	return nil
+}
+
+func (output *archiveOutput) sortedNames() []string {
+	names := make([]string, 0, len(output.files))
+	for name := range output.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (output *archiveOutput) writeZip(writer io.Writer) error {
+	var err error
+	archive := zip.NewWriter(writer)
+	for _, name := range output.sortedNames() {
+		header := &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: ARCHIVE_MOD_TIME,
+		}
+		header.SetMode(ARCHIVE_FILE_MODE)
+		var entry io.Writer
+		entry, err = archive.CreateHeader(header)
+		if err == nil {
+			_, err = entry.Write(output.files[name].Bytes())
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	return err
+}
+
+func (output *archiveOutput) writeTarGz(writer io.Writer) error {
+	var err error
+	compressor := gzip.NewWriter(writer)
+	compressor.ModTime = ARCHIVE_MOD_TIME
+	archive := tar.NewWriter(compressor)
+	for _, name := range output.sortedNames() {
+		data := output.files[name].Bytes()
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     ARCHIVE_FILE_MODE,
+			Size:     int64(len(data)),
+			ModTime:  ARCHIVE_MOD_TIME,
+		}
+		err = archive.WriteHeader(header)
+		if err == nil {
+			_, err = archive.Write(data)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err == nil {
+		err = compressor.Close()
+	}
+	return err
+}
+
+func (output *archiveOutput) Finish() error {
+	var file *os.File
+	var err error
+	file, err = ioutil.TempFile(filepath.Dir(output.path), ".archive-*")
+	if err == nil {
+		tempPath := file.Name()
+		if strings.HasSuffix(output.path, ZIP_FILE_ENDING) {
+			err = output.writeZip(file)
+		} else {
+			err = output.writeTarGz(file)
+		}
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tempPath, ARCHIVE_FILE_MODE)
+		}
+		if err == nil {
+			err = os.Rename(tempPath, output.path)
+		}
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}
+	return err
+}
+
+func (output *dryRunOutput) Create(path string) (io.WriteCloser, error) {
+	output.paths = append(output.paths, normalizeName(filepath.ToSlash(path)))
+	return discardEntry{}, nil
+}
+
+func (output *dryRunOutput) MkdirAll(path string) error {
+	return nil
+}
+
+func (output *dryRunOutput) Remove(path string) error {
+	if IsArchivePath(output.path) {
+		return nil
+	}
+	fullPath := filepath.Join(output.path, filepath.FromSlash(path))
+	err := CheckPathError(fullPath)
+	if err == nil {
+		output.removals = append(output.removals, fullPath)
+	}
+	return err
+}
+
+func (entry discardEntry) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (entry discardEntry) Close() error {
+	return nil
+}
+
+func plannedAction(path string) string {
+	action := "create"
+	if CheckPathError(path) == nil {
+		action = "overwrite"
+	}
+	return action
+}
+
+func (output *dryRunOutput) Finish() error {
+	if IsArchivePath(output.path) {
+		fmt.Printf("%s %s\n", plannedAction(output.path), output.path)
+		for _, path := range output.paths {
+			fmt.Printf("  entry %s\n", path)
+		}
+	} else {
+		for _, path := range output.paths {
+			fullPath := filepath.Join(output.path, filepath.FromSlash(path))
+			fmt.Printf("%s %s\n", plannedAction(fullPath), fullPath)
+		}
+		for _, fullPath := range output.removals {
+			fmt.Printf("remove %s\n", fullPath)
+		}
+	}
+	return nil
+}
+
+func (output *MemoryOutput) Create(path string) (io.WriteCloser, error) {
+	name, err := entryName(path, "output")
+	if err != nil {
+		return nil, err
+	}
+	return &memoryEntry{path: name, output: output}, nil
+}
+
+func (entry *memoryEntry) Write(data []byte) (int, error) {
+	return entry.buffer.Write(data)
+}
+
+func (entry *memoryEntry) Close() error {
+	entry.output.mutex.Lock()
+	defer entry.output.mutex.Unlock()
+	entry.output.files[entry.path] = entry.buffer.Bytes()
+	return nil
+}
+
+func (output *MemoryOutput) MkdirAll(path string) error {
+	return nil
+}
+
+func (output *MemoryOutput) Remove(path string) error {
+	name, err := entryName(path, "output")
+	if err != nil {
+		return err
+	}
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	if _, found := output.files[name]; !found {
+		return missingEntry(path)
+	}
+	delete(output.files, name)
+	return nil
+}
+
+func (output *MemoryOutput) Finish() error {
+	return nil
+}
+
+func (output *MemoryOutput) Paths() []string {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	paths := make([]string, 0, len(output.files))
+	for path := range output.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (output *MemoryOutput) File(path string) ([]byte, bool) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	data, found := output.files[normalizeName(path)]
+	return data, found
+}
@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+type failingOutput struct {
+	OutputSink
+	fail string
+}
+
+func (output *failingOutput) Create(path string) (io.WriteCloser, error) {
+	if path == output.fail {
+		return nil, &os.PathError{Op: "open", Path: path, Err: syscall.ENOSPC}
+	}
+	return output.OutputSink.Create(path)
+}
+
+func emptyOutputs(t *testing.T, root string) []string {
+	var empty []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() && info.Size() == 0 {
+			empty = append(empty, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return empty
+}
+
+func TestFailedWritesRemoveTheFile(t *testing.T) {
+	if CheckPathError("/dev/full") != nil {
+		t.Skip("/dev/full is not available")
+	}
+	tests := []struct {
+		name string
+		full string
+	}{
+		{"page", "b.html"},
+		{"index", INDEX_FILE_NAME},
+		{"sitemap", SITEMAP_FILE_NAME},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.BaseURL = "https://example.com/"
+			site.page("a.md", `{"Title": "A"}`, "body\n")
+			site.page("b.md", `{"Title": "B"}`, "body\n")
+			site.page("c.md", `{"Title": "C"}`, "body\n")
+			full := filepath.Join(site.Configuration.Output, test.full)
+			err := os.Symlink("/dev/full", full)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = site.build()
+			if err == nil || !strings.Contains(err.Error(), "no space left on device") {
+				t.Fatalf("build error = %v, want the ENOSPC error for %s", err, test.full)
+			}
+			if _, err := os.Lstat(full); !os.IsNotExist(err) {
+				t.Errorf("%s was left behind after the failed write", test.full)
+			}
+			if empty := emptyOutputs(t, site.Configuration.Output); len(empty) > 0 {
+				t.Errorf("empty files were left behind: %v", empty)
+			}
+		})
+	}
+}
+
+func TestBuildFailsWhenCreateFails(t *testing.T) {
+	tests := []string{"a.html", INDEX_FILE_NAME, MANIFEST_FILE_NAME}
+	for _, fail := range tests {
+		t.Run(fail, func(t *testing.T) {
+			site := newTestSite(t)
+			site.page("a.md", `{"Title": "A"}`, "body\n")
+			site.page("b.md", `{"Title": "B"}`, "body\n")
+			output := &failingOutput{OutputSink: NewOutputWriter(site.Configuration.Output), fail: fail}
+			err := NewBuilder(site.Configuration).WithOutputSink(output).Build()
+			if err == nil || !strings.Contains(err.Error(), "no space left on device") {
+				t.Fatalf("build error = %v, want the ENOSPC error for %s", err, fail)
+			}
+			if site.exists(fail) {
+				t.Errorf("%s exists after its create failed", fail)
+			}
+			if empty := emptyOutputs(t, site.Configuration.Output); len(empty) > 0 {
+				t.Errorf("empty files were left behind: %v", empty)
+			}
+		})
+	}
+}
+
+func TestFileEntryClose(t *testing.T) {
+	output := NewOutputWriter(t.TempDir())
+	tests := []struct {
+		name   string
+		fail   bool
+		exists bool
+	}{
+		{"successful write", false, true},
+		{"failed write", true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writer, err := output.Create(test.name + ".html")
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry := writer.(*fileEntry)
+			path := entry.file.Name()
+			if test.fail {
+				entry.file.Close()
+			}
+			_, writeErr := entry.Write([]byte("content"))
+			closeErr := entry.Close()
+			if test.fail && (writeErr == nil || closeErr == nil) {
+				t.Errorf("write = %v, close = %v, want both to fail", writeErr, closeErr)
+			}
+			if !test.fail && (writeErr != nil || closeErr != nil) {
+				t.Errorf("write = %v, close = %v, want no error", writeErr, closeErr)
+			}
+			if exists := CheckPathError(path) == nil; exists != test.exists {
+				t.Errorf("file exists = %v, want %v", exists, test.exists)
+			}
+		})
+	}
+}
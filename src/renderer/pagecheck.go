@@ -0,0 +1,37 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func validatePage(configuration Configuration, page Page) error {
+	if len(strings.TrimSpace(page.Title)) == 0 && policyFor(configuration, CONDITION_MISSING_TITLE) == POLICY_ERROR {
+		return errors.New("page has no Title")
+	}
+	if len(page.Date) > 0 {
+		if _, err := time.Parse(DATE_DISPLAY_FORMAT, page.Date); err != nil {
+			msg := fmt.Sprintf("page date '%s' is not a %s date", page.Date, DATE_DISPLAY_FORMAT)
+			return errors.New(msg)
+		}
+	}
+	if len(page.Lang) > 0 {
+		err := checkLanguageCode("Lang", page.Lang)
+		if err != nil {
+			return err
+		}
+	}
+	if len(page.Kind) > 0 {
+		return checkKind(page.Kind)
+	}
+	return nil
+}
+
+func validateRenderedPage(configuration Configuration, page Page) error {
+	if page.Site == nil {
+		return errors.New("page was rendered without the site data")
+	}
+	return validatePage(configuration, page)
+}
@@ -0,0 +1,66 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePage(t *testing.T) {
+	strict := Configuration{Policies: map[string]string{CONDITION_MISSING_TITLE: POLICY_ERROR}}
+	tests := []struct {
+		name          string
+		configuration Configuration
+		page          Page
+		err           string
+	}{
+		{"valid page", strict, Page{Title: "A", Date: "2024-06-01", Lang: "pt-BR", Kind: KIND_POST}, ""},
+		{"page without optional fields", Configuration{}, Page{Title: "A"}, ""},
+		{"blank title when titles are optional", Configuration{}, Page{Title: "  "}, ""},
+		{"blank title when titles are required", strict, Page{Title: "  "}, "page has no Title"},
+		{"date with a time", Configuration{}, Page{Title: "A", Date: "2024-06-01T10:00:00Z"}, "page date '2024-06-01T10:00:00Z' is not a 2006-01-02 date"},
+		{"date that does not exist", Configuration{}, Page{Title: "A", Date: "2024-02-30"}, "page date '2024-02-30'"},
+		{"language that is not a code", Configuration{}, Page{Title: "A", Lang: "English"}, "Lang 'English' is not a language code"},
+		{"unknown kind", Configuration{}, Page{Title: "A", Kind: "note"}, "kind must be 'post' or 'page', not 'note'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePage(test.configuration, test.page)
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Errorf("validatePage = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("validatePage = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateRenderedPage(t *testing.T) {
+	page := Page{Title: "A"}
+	err := validateRenderedPage(Configuration{}, page)
+	if err == nil || !strings.Contains(err.Error(), "without the site data") {
+		t.Errorf("validateRenderedPage without a site = %v, want the site data error", err)
+	}
+	page.Site = &Site{}
+	err = validateRenderedPage(Configuration{}, page)
+	if err != nil {
+		t.Errorf("validateRenderedPage = %v, want no error", err)
+	}
+}
+
+func TestInvalidPageFailsTheBuild(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.Policies = map[string]string{CONDITION_MISSING_TITLE: POLICY_ERROR}
+	site.page("a.md", `{"Title": "A", "Kind": "note"}`, "body\n")
+	site.page("b.md", `{"Title": "B"}`, "body\n")
+	err := site.build()
+	if err == nil || !strings.Contains(err.Error(), "kind must be") {
+		t.Errorf("build error = %v, want the kind error of a.md", err)
+	}
+	if site.exists("a.html") {
+		t.Error("a.html was written for an invalid page")
+	}
+}
@@ -0,0 +1,268 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type PageSource struct {
+	Name     string
+	Markdown string
+	Meta     *MetaBlock
+	Page     *Page
+	Path     string
+}
+
+type extraText struct {
+	text        []byte
+	line        int
+	prerendered bool
+}
+
+func (builder *Builder) WithExtraPages(pages []PageSource) *Builder {
+	builder.extraPages = append(builder.extraPages, pages...)
+	return builder
+}
+
+func sourceText(source Source, configuration Configuration) ([]byte, int, error) {
+	if source.extra != nil {
+		return source.extra.text, source.extra.line, nil
+	}
+	_, text, line, err := readSource(source.Path, configuration)
+	if isMissingMeta(err) {
+		err = nil
+	}
+	return text, line, err
+}
+
+func isExtraSource(source Source) bool {
+	return source.extra != nil
+}
+
+func isPrerendered(source Source) bool {
+	return source.extra != nil && source.extra.prerendered
+}
+
+func fileSources(sources []Source) []Source {
+	var files []Source
+	for _, source := range sources {
+		if !isExtraSource(source) {
+			files = append(files, source)
+		}
+	}
+	return files
+}
+
+func extraPageName(extra PageSource) (string, error) {
+	name := extra.Name
+	if len(name) == 0 && len(extra.Path) > 0 {
+		name = strings.TrimSuffix(extra.Path, ".html") + MARKDOWN_FILE_ENDING
+	}
+	if len(name) == 0 {
+		return "", errors.New("extra page has neither a Name nor a Path")
+	}
+	if extra.Page != nil && (len(extra.Markdown) > 0 || extra.Meta != nil) {
+		msg := fmt.Sprintf("extra page '%s' has both a rendered Page and Markdown or Meta", name)
+		return name, errors.New(msg)
+	}
+	return normalizeName(name), nil
+}
+
+func (builder *Builder) extraMeta(extra PageSource, name string) (MetaBlock, *extraText, bool, error) {
+	configuration := builder.Configuration
+	if extra.Page != nil {
+		metaBlock := MetaBlock{
+			Title:    extra.Page.Title,
+			Category: extra.Page.Category,
+			Tags:     extra.Page.Tags,
+			Kind:     extra.Page.Kind,
+			Unlisted: extra.Page.Unlisted,
+			NoIndex:  extra.Page.NoIndex,
+			Summary:  extra.Page.Summary,
+			Params:   extra.Page.Params,
+			Lang:     extra.Page.Lang,
+		}
+		return metaBlock, &extraText{prerendered: true}, false, nil
+	}
+	if extra.Meta != nil {
+		return copyMetaBlock(*extra.Meta), &extraText{text: []byte(extra.Markdown), line: 1}, false, nil
+	}
+	data := []byte(extra.Markdown)
+	metaBlock, text, err := parseSource(data, configuration)
+	if isMissingMeta(err) {
+		if builder.report(CONDITION_MISSING_META, name, "extra page has no meta block") {
+			return metaBlock, nil, true, nil
+		}
+		err = nil
+	}
+	line := 1 + bytes.Count(data[:len(data)-len(text)], []byte("\n"))
+	return metaBlock, &extraText{text: text, line: line}, false, err
+}
+
+func (builder *Builder) extraSource(extra PageSource, name string, now time.Time) (Source, bool, error) {
+	var source Source
+	configuration := builder.Configuration
+	metaBlock, text, skip, err := builder.extraMeta(extra, name)
+	if err != nil || skip {
+		return source, skip, err
+	}
+	err = builder.runBeforePage(name, &metaBlock)
+	if err == ErrSkipPage {
+		log.Print("skipping: ", name)
+		return source, true, nil
+	}
+	if err != nil {
+		return source, false, err
+	}
+	metaBlock.Tags = normalizeTags(metaBlock.Tags, tagAliases(configuration))
+	for _, date := range []*MetaDate{&metaBlock.Date, &metaBlock.ExpiryDate} {
+		if len(date.Unparsed) > 0 {
+			if builder.report(CONDITION_UNPARSEABLE_DATE, name, checkDate(*date).Error()) {
+				return source, true, nil
+			}
+			*date = MetaDate{}
+		}
+	}
+	location, err := loadLocation(configuration)
+	if err != nil {
+		return source, false, err
+	}
+	expired := !metaBlock.ExpiryDate.IsZero() && !resolveDate(metaBlock.ExpiryDate, location).After(now)
+	if expired && configuration.UnpublishExpired {
+		log.Print("skipping expired: ", name)
+		return source, true, nil
+	}
+	if metaBlock.Draft {
+		log.Print("skipping draft: ", name)
+		return source, true, nil
+	}
+	if len(strings.TrimSpace(metaBlock.Title)) == 0 && builder.report(CONDITION_MISSING_TITLE, name, "extra page has no Title") {
+		return source, true, nil
+	}
+	page := Page{}
+	if extra.Page != nil {
+		page = *extra.Page
+		if len(page.TitleHTML) == 0 {
+			page.TitleHTML = renderTitle(page.Title, configuration)
+		}
+		page.Tags = metaBlock.Tags
+	} else {
+		page, err = buildPage(metaBlock, configuration)
+		if err != nil {
+			return source, false, err
+		}
+	}
+	page.Expired = expired
+	page.Unlisted = metaBlock.Unlisted
+	page.NoIndex = metaBlock.NoIndex || metaBlock.Unlisted
+	page.Lang = resolveLanguage(configuration, name, metaBlock)
+	page.SourcePath = name
+	outputPath := sourceOutputPath(name)
+	if len(extra.Path) > 0 {
+		outputPath, err = metaOutputPath(extra.Path)
+	} else if len(metaBlock.Path) > 0 {
+		outputPath, err = metaOutputPath(metaBlock.Path)
+	}
+	outputPath = truncateOutputPath(configuration, outputPath)
+	var formats []string
+	if err == nil {
+		formats, err = pageFormats(configuration, metaBlock)
+	}
+	if err == nil {
+		page.Kind, err = resolveKind(configuration, metaBlock, name)
+	}
+	if err == nil {
+		err = validatePage(configuration, page)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("extra page error: %s", err)
+		return source, false, errors.New(msg)
+	}
+	page.Change = CHANGE_CHANGED
+	source = Source{
+		Name:       name,
+		Path:       name,
+		OutputPath: outputPath,
+		Link: Link{
+			Title:     page.Title,
+			TitleHTML: page.TitleHTML,
+			Date:      page.Date,
+			Url:       outputUrl(outputPath),
+			Category:  page.Category,
+			Tags:      page.Tags,
+			Authors:   page.Authors,
+			Kind:      page.Kind,
+			Summary:   page.Summary,
+			Params:    linkParams(configuration, page.Params),
+			Lang:      page.Lang,
+		},
+		Published: resolveDate(metaBlock.Date, location),
+		Meta:      metaBlock,
+		Change:    CHANGE_CHANGED,
+		Formats:   formats,
+		Terms:     metaTerms(metaBlock, configuration),
+		Expired:   expired,
+		Unlisted:  metaBlock.Unlisted,
+		NoIndex:   metaBlock.NoIndex,
+		Page:      page,
+		extra:     text,
+	}
+	return source, false, nil
+}
+
+func extraConflicts(sources []Source, extras []Source) error {
+	owners := map[string]string{}
+	names := map[string]string{}
+	for _, source := range sources {
+		owners[strings.ToLower(source.OutputPath)] = source.Path
+		names[source.Name] = source.Path
+	}
+	for _, extra := range extras {
+		if owner, taken := names[extra.Name]; taken {
+			msg := fmt.Sprintf("extra page '%s' has the same name as %s", extra.Name, owner)
+			return errors.New(msg)
+		}
+		if owner, taken := owners[strings.ToLower(extra.OutputPath)]; taken {
+			msg := fmt.Sprintf("extra page '%s' writes '%s', which %s also writes", extra.Name, extra.OutputPath, owner)
+			return errors.New(msg)
+		}
+		names[extra.Name] = "extra page '" + extra.Name + "'"
+		owners[strings.ToLower(extra.OutputPath)] = "extra page '" + extra.Name + "'"
+	}
+	return nil
+}
+
+func collectExtraPages(builder *Builder, sources []Source) ([]Source, error) {
+	if len(builder.extraPages) == 0 {
+		return sources, nil
+	}
+	now, err := builder.now()
+	if err != nil {
+		return sources, err
+	}
+	var extras []Source
+	for _, extra := range builder.extraPages {
+		var name string
+		var source Source
+		skip := false
+		name, err = extraPageName(extra)
+		if err == nil {
+			log.Print("collecting extra page: ", name)
+			source, skip, err = builder.extraSource(extra, name, now)
+		}
+		if err != nil {
+			sources = builder.failPage(sources, FAILURE_COLLECT, name, name, nil, err)
+			err = nil
+			continue
+		}
+		if !skip {
+			extras = append(extras, source)
+		}
+	}
+	err = extraConflicts(sources, extras)
+	return append(sources, extras...), err
+}
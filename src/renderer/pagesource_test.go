@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExtraPages(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.BaseURL = "https://example.com/"
+	site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}}
+	site.page("a.md", `{"Title": "A", "Date": "2024-01-01"}`, "file body\n")
+	output := NewMemoryOutput()
+	builder := NewBuilder(site.Configuration).WithOutputSink(output).WithExtraPages([]PageSource{
+		{Name: "glossary/api.md", Markdown: "```json\n{\"Title\": \"API\", \"Date\": \"2024-02-01\"}\n```\nAn *interface*.\n"},
+		{Name: "glossary/cli.md", Meta: &MetaBlock{Title: "CLI", Tags: []string{"tools"}}, Markdown: "A command line.\n"},
+		{Path: "glossary/index.html", Page: &Page{Title: "Glossary", Content: "<p>All terms</p>"}},
+	})
+	if err := builder.Build(); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"glossary/api.html":   "<title>API</title>",
+		"glossary/cli.html":   "<p>A command line.</p>",
+		"glossary/index.html": "<p>All terms</p>",
+		"a.html":              "<p>file body</p>",
+	}
+	for name, want := range files {
+		data, found := output.File(name)
+		if !found || !strings.Contains(string(data), want) {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if data, _ := output.File("glossary/api.html"); !strings.Contains(string(data), "<em>interface</em>") {
+		t.Errorf("glossary/api.html = %q, want the rendered markdown", data)
+	}
+	index, _ := output.File(INDEX_FILE_NAME)
+	for _, link := range []string{`href="/a.html"`, `href="/glossary/api.html"`, `href="/glossary/cli.html"`, `href="/glossary/index.html"`} {
+		if !strings.Contains(string(index), link) {
+			t.Errorf("index = %q, want %s", index, link)
+		}
+	}
+	sitemap, _ := output.File(SITEMAP_FILE_NAME)
+	if !strings.Contains(string(sitemap), "https://example.com/glossary/api.html") {
+		t.Errorf("sitemap = %q, want the extra pages", sitemap)
+	}
+	var feed jsonFeed
+	data, _ := output.File(JSON_FEED_FILE_NAME)
+	if err := json.Unmarshal(data, &feed); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Items) != 4 {
+		t.Errorf("feed has %d items, want the file page and the 3 extra pages", len(feed.Items))
+	}
+	if site.exists("glossary/api.html") || site.exists(INDEX_FILE_NAME) {
+		t.Error("the build wrote to the output directory instead of the memory sink")
+	}
+}
+
+func TestExtraPageErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra PageSource
+		err   string
+	}{
+		{"same name as a file", PageSource{Name: "a.md", Markdown: "```json\n{\"Title\": \"Other A\"}\n```\n"}, "has the same name as"},
+		{"same output as a file", PageSource{Path: "A.html", Page: &Page{Title: "Other A"}}, "which "},
+		{"no name or path", PageSource{Markdown: "text\n"}, "neither a Name nor a Path"},
+		{"rendered page and markdown", PageSource{Name: "b.md", Markdown: "text\n", Page: &Page{Title: "B"}}, "both a rendered Page and Markdown"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.page("a.md", `{"Title": "A"}`, "body\n")
+			err := NewBuilder(site.Configuration).WithOutputSink(NewMemoryOutput()).WithExtraPages([]PageSource{test.extra}).Build()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("build = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
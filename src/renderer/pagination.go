@@ -0,0 +1,163 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const PAGER_DIRECTORY = "page"
+
+type Pager struct {
+	Links    []Link
+	Number   int
+	Pages    int
+	Size     int
+	Total    int
+	Url      string
+	FirstUrl string
+	LastUrl  string
+	PrevUrl  string
+	NextUrl  string
+}
+
+type pagination struct {
+	outputPath string
+	current    int
+	pages      int
+	size       int
+	total      int
+	used       bool
+	links      []Link
+}
+
+func pagedOutputPath(outputPath string, number int) string {
+	if number <= 1 {
+		return outputPath
+	}
+	base := strings.TrimSuffix(outputPath, ".html")
+	if path.Base(outputPath) == INDEX_FILE_NAME {
+		base = path.Dir(outputPath)
+	}
+	return path.Join(base, PAGER_DIRECTORY, strconv.Itoa(number)+".html")
+}
+
+func paginationUnavailable(links []Link, size int) (*Pager, error) {
+	return nil, errors.New("paginate: only the index and term listings can be paginated")
+}
+
+func (paging *pagination) url(number int) string {
+	return outputUrl(pagedOutputPath(paging.outputPath, number))
+}
+
+func (paging *pagination) paginate(links []Link, size int) (*Pager, error) {
+	if size <= 0 {
+		msg := fmt.Sprintf("paginate: the page size must be positive, not %d", size)
+		return nil, errors.New(msg)
+	}
+	if paging.used && (size != paging.size || len(links) != paging.total) {
+		return nil, errors.New("paginate: a listing can only be split one way, it is called with another list or size")
+	}
+	paging.used = true
+	paging.size = size
+	paging.total = len(links)
+	paging.pages = (len(links) + size - 1) / size
+	if paging.pages == 0 {
+		paging.pages = 1
+	}
+	start := (paging.current - 1) * size
+	end := start + size
+	if end > len(links) {
+		end = len(links)
+	}
+	paging.links = links[start:end]
+	pager := &Pager{
+		Links:    paging.links,
+		Number:   paging.current,
+		Pages:    paging.pages,
+		Size:     size,
+		Total:    len(links),
+		Url:      paging.url(paging.current),
+		FirstUrl: paging.url(1),
+		LastUrl:  paging.url(paging.pages),
+	}
+	if paging.current > 1 {
+		pager.PrevUrl = paging.url(paging.current - 1)
+	}
+	if paging.current < paging.pages {
+		pager.NextUrl = paging.url(paging.current + 1)
+	}
+	return pager, nil
+}
+
+func executeListing(writer io.Writer, outputPath string, templatePath string, index Index, configuration Configuration, paging *pagination) error {
+	templateObj := template.New(filepath.Base(templatePath)).
+		Funcs(templateFuncs(configuration, index.Site, outputPath)).
+		Funcs(template.FuncMap{"paginate": paging.paginate})
+	templateObj, err := templateObj.ParseFiles(templatePath)
+	if err == nil {
+		err = templateObj.Execute(writer, index)
+	}
+	return err
+}
+
+func checkPagedOutput(site *Site, outputPath string, pagePath string) error {
+	if site == nil {
+		return nil
+	}
+	var owners []string
+	url := outputUrl(pagePath)
+	for name, pageUrl := range site.pageUrls {
+		if pageUrl == url {
+			owners = append(owners, name)
+		}
+	}
+	sort.Strings(owners)
+	if len(owners) > 0 {
+		msg := fmt.Sprintf("paginate: page '%s' of %s is also written by %s", pagePath, outputPath, strings.Join(owners, ", "))
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func writeListing(output OutputWriter, outputPath string, templatePath string, index Index, configuration Configuration) error {
+	paging := &pagination{outputPath: outputPath, current: 1}
+	for {
+		pagePath := pagedOutputPath(outputPath, paging.current)
+		var err error
+		if paging.current > 1 {
+			err = checkPagedOutput(index.Site, outputPath, pagePath)
+		}
+		if err == nil {
+			buffer := getBuffer()
+			err = executeListing(buffer, pagePath, templatePath, index, configuration, paging)
+			if err == nil {
+				err = writeRendered(output, pagePath, buffer.Bytes(), index.Site, configuration)
+			}
+			putBuffer(buffer)
+		}
+		if err != nil {
+			return err
+		}
+		if paging.current > 1 && index.Site != nil {
+			_, newest := dateRange(paging.links)
+			index.Site.generated = append(index.Site.generated, GeneratedPage{
+				Path:      pagePath,
+				Url:       outputUrl(pagePath),
+				Owner:     fmt.Sprintf("page %d of %s", paging.current, outputPath),
+				Date:      newest,
+				Auxiliary: true,
+			})
+		}
+		if !paging.used || paging.current >= paging.pages {
+			return nil
+		}
+		paging.current++
+	}
+}
@@ -0,0 +1,173 @@
+package renderer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const TEST_PAGED_INDEX_TEMPLATE = "{{$pager := paginate .Links 1}}{{range $pager.Links}}<a href=\"{{.Url}}\">{{.Title}}</a>{{end}}{{$pager.NextUrl}}\n"
+
+func TestPagedOutputPath(t *testing.T) {
+	tests := []struct {
+		outputPath string
+		number     int
+		want       string
+	}{
+		{"index.html", 1, "index.html"},
+		{"index.html", 2, "page/2.html"},
+		{"tags/go.html", 3, "tags/go/page/3.html"},
+		{"docs/index.html", 2, "docs/page/2.html"},
+	}
+	for _, test := range tests {
+		got := pagedOutputPath(test.outputPath, test.number)
+		if got != test.want {
+			t.Errorf("pagedOutputPath(%q, %d) = %q, want %q", test.outputPath, test.number, got, test.want)
+		}
+	}
+}
+
+func TestPaginatedIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		paginated bool
+		want      string
+	}{
+		{"unpaginated site keeps the page", false, ""},
+		{"paginated site reports the collision", true, "paginate: page 'page/2.html' of index.html is also written by"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			if test.paginated {
+				site.writeFile(site.Configuration.TemplateIndex, TEST_PAGED_INDEX_TEMPLATE)
+			}
+			site.page("a.md", `{"Title": "A", "Date": "2024-01-02T00:00:00Z"}`, "body\n")
+			site.page("b.md", `{"Title": "B", "Date": "2024-01-01T00:00:00Z", "Path": "page/2.html"}`, "body\n")
+			err := site.build()
+			if len(test.want) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(site.read("page/2.html"), "<title>B</title>") {
+					t.Error("page/2.html does not hold the page")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestPaginatedIndexPages(t *testing.T) {
+	site := newTestSite(t)
+	site.writeFile(site.Configuration.TemplateIndex, TEST_PAGED_INDEX_TEMPLATE)
+	site.page("a.md", `{"Title": "A", "Date": "2024-01-02T00:00:00Z"}`, "body\n")
+	site.page("b.md", `{"Title": "B", "Date": "2024-01-01T00:00:00Z"}`, "body\n")
+	site.mustBuild()
+	first := site.read(INDEX_FILE_NAME)
+	if !strings.Contains(first, ">A<") || !strings.Contains(first, "/page/2.html") {
+		t.Errorf("index.html = %q, want A and a link to page 2", first)
+	}
+	if second := site.read("page/2.html"); !strings.Contains(second, ">B<") {
+		t.Errorf("page/2.html = %q, want B", second)
+	}
+}
+
+func TestPaginatedListingExclusions(t *testing.T) {
+	tests := []struct {
+		name     string
+		listings bool
+		sitemap  []string
+	}{
+		{"listings left out of the sitemap", false, []string{
+			"https://example.com/ ",
+			"https://example.com/a.html 2024-01-03",
+			"https://example.com/b.html 2024-01-02",
+			"https://example.com/c.html 2024-01-01",
+		}},
+		{"listings in the sitemap", true, []string{
+			"https://example.com/ ",
+			"https://example.com/a.html 2024-01-03",
+			"https://example.com/b.html 2024-01-02",
+			"https://example.com/c.html 2024-01-01",
+			"https://example.com/tags/go.html 2024-01-03",
+			"https://example.com/page/2.html 2024-01-02",
+			"https://example.com/page/3.html 2024-01-01",
+			"https://example.com/tags/go/page/2.html 2024-01-02",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.BaseURL = "https://example.com/"
+			site.Configuration.SitemapListings = test.listings
+			site.Configuration.SearchIndex = true
+			site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}}
+			site.Configuration.TemplateTerm = site.path("term.html")
+			site.writeFile(site.Configuration.TemplateTerm, TEST_PAGED_INDEX_TEMPLATE)
+			site.writeFile(site.Configuration.TemplateIndex, TEST_PAGED_INDEX_TEMPLATE)
+			site.page("a.md", `{"Title": "A", "Date": "2024-01-03", "Tags": ["go"]}`, "Alpha text\n")
+			site.page("b.md", `{"Title": "B", "Date": "2024-01-02", "Tags": ["go"]}`, "Beta text\n")
+			site.page("c.md", `{"Title": "C", "Date": "2024-01-01"}`, "Gamma text\n")
+			site.mustBuild()
+
+			var urlSet sitemapUrlSet
+			err := xml.Unmarshal([]byte(site.read(SITEMAP_FILE_NAME)), &urlSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sitemap []string
+			for _, url := range urlSet.Urls {
+				sitemap = append(sitemap, url.Location+" "+url.LastModified)
+			}
+			if !reflect.DeepEqual(sitemap, test.sitemap) {
+				t.Errorf("sitemap = %q, want %q", sitemap, test.sitemap)
+			}
+
+			var feed jsonFeed
+			err = json.Unmarshal([]byte(site.read(JSON_FEED_FILE_NAME)), &feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var items []string
+			for _, item := range feed.Items {
+				items = append(items, item.Url)
+			}
+			if want := []string{"https://example.com/a.html", "https://example.com/b.html", "https://example.com/c.html"}; !reflect.DeepEqual(items, want) {
+				t.Errorf("feed items = %q, want %q", items, want)
+			}
+
+			var search []SearchEntry
+			err = json.Unmarshal([]byte(site.read(SEARCH_FILE_NAME)), &search)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []SearchEntry{
+				{Title: "A", Url: "/a.html", Date: "2024-01-03", Tags: []string{"go"}, Text: "Alpha text"},
+				{Title: "B", Url: "/b.html", Date: "2024-01-02", Tags: []string{"go"}, Text: "Beta text"},
+				{Title: "C", Url: "/c.html", Date: "2024-01-01", Text: "Gamma text"},
+			}
+			if !reflect.DeepEqual(search, want) {
+				t.Errorf("search index = %+v, want %+v", search, want)
+			}
+
+			var manifest Manifest
+			err = json.Unmarshal([]byte(site.read(MANIFEST_FILE_NAME)), &manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range []string{INDEX_FILE_NAME, "a.html", "page/2.html", "page/3.html", "tags/go.html", "tags/go/page/2.html", SEARCH_FILE_NAME} {
+				entry, found := manifest.Files[path]
+				auxiliary := strings.Contains(path, "/")
+				if !found || entry.Auxiliary != auxiliary {
+					t.Errorf("manifest entry of %s = %+v, found = %v, want auxiliary = %v", path, entry, found, auxiliary)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package renderer
+
+func linkParams(configuration Configuration, params map[string]interface{}) map[string]interface{} {
+	if configuration.IndexParams == nil || len(params) == 0 {
+		return params
+	}
+	var kept map[string]interface{}
+	for _, key := range configuration.IndexParams {
+		value, found := params[key]
+		if !found {
+			continue
+		}
+		if kept == nil {
+			kept = map[string]interface{}{}
+		}
+		kept[key] = value
+	}
+	return kept
+}
@@ -0,0 +1,106 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const MAX_PATH_COMPONENT_BYTES = 255
+const MAX_PATH_BYTES = 4096
+const TRUNCATED_HASH_LENGTH = 8
+
+var WINDOWS_RESERVED_NAMES = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func checkPathComponent(component string) error {
+	if len(component) > MAX_PATH_COMPONENT_BYTES {
+		msg := fmt.Sprintf("'%.40s...' is %d bytes, longer than %d bytes", component, len(component), MAX_PATH_COMPONENT_BYTES)
+		return errors.New(msg)
+	}
+	stem := strings.ToUpper(component)
+	if index := strings.Index(stem, "."); index != -1 {
+		stem = stem[:index]
+	}
+	if WINDOWS_RESERVED_NAMES[strings.TrimRight(stem, " ")] {
+		msg := fmt.Sprintf("'%s' is a reserved file name on Windows", component)
+		return errors.New(msg)
+	}
+	if strings.HasSuffix(component, ".") || strings.HasSuffix(component, " ") {
+		msg := fmt.Sprintf("'%s' ends in a dot or a space, which Windows drops", component)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func checkOutputPath(configuration Configuration, outputPath string) error {
+	for _, component := range strings.Split(outputPath, "/") {
+		err := checkPathComponent(component)
+		if err != nil {
+			msg := fmt.Sprintf("output path '%s': %s", outputPath, err)
+			return errors.New(msg)
+		}
+	}
+	full := outputPath
+	if len(configuration.Output) > 0 && !IsArchivePath(configuration.Output) {
+		full = filepath.Join(configuration.Output, filepath.FromSlash(outputPath))
+		if absolute, err := filepath.Abs(full); err == nil {
+			full = absolute
+		}
+	}
+	if len(full) > MAX_PATH_BYTES {
+		msg := fmt.Sprintf("output path '%s' is %d bytes written out, longer than %d bytes", outputPath, len(full), MAX_PATH_BYTES)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func sourcePathError(configuration Configuration, source Source) error {
+	for _, outputPath := range variantPaths(source) {
+		err := checkOutputPath(configuration, outputPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func truncateComponent(component string, limit int) string {
+	if len(component) <= limit {
+		return component
+	}
+	extension := path.Ext(component)
+	if len(extension) > TRUNCATED_HASH_LENGTH {
+		extension = ""
+	}
+	keep := limit - len(extension) - TRUNCATED_HASH_LENGTH - 1
+	stem := component[:keep]
+	for len(stem) > 0 && !utf8.ValidString(stem) {
+		stem = stem[:len(stem)-1]
+	}
+	return stem + "-" + hashContent([]byte(component))[:TRUNCATED_HASH_LENGTH] + extension
+}
+
+func truncateOutputPath(configuration Configuration, outputPath string) string {
+	if !configuration.TruncateLongPaths {
+		return outputPath
+	}
+	components := strings.Split(outputPath, "/")
+	for index, component := range components {
+		components[index] = truncateComponent(component, MAX_PATH_COMPONENT_BYTES)
+	}
+	return strings.Join(components, "/")
+}
+
+func truncateSlug(configuration Configuration, slug string) string {
+	if !configuration.TruncateLongPaths {
+		return slug
+	}
+	return truncateComponent(slug, MAX_PATH_COMPONENT_BYTES-len(".html"))
+}
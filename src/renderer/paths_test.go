@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongOutputPaths(t *testing.T) {
+	long := strings.Repeat("a very long title ", 20)
+	tests := []struct {
+		name     string
+		meta     string
+		truncate bool
+		err      string
+	}{
+		{"long Path", `"Path": "` + strings.Replace(long, " ", "-", -1) + `.html"`, false, "longer than 255 bytes"},
+		{"long tag", `"Tags": ["` + long + `"]`, false, "longer than 255 bytes"},
+		{"truncated Path", `"Path": "` + strings.Replace(long, " ", "-", -1) + `.html"`, true, ""},
+		{"truncated tag", `"Tags": ["` + long + `"]`, true, ""},
+		{"short title", `"Tags": ["short"]`, false, ""},
+		{"reserved tag slug", `"Tags": ["Con"]`, false, "'con.html' is a reserved file name on Windows"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Taxonomies = map[string]string{"tags": "tags"}
+			site.Configuration.TruncateLongPaths = test.truncate
+			site.page("a.md", `{"Title": "`+long+`", `+test.meta+`}`, "body\n")
+			err := site.build()
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("build = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = filepath.Walk(site.Configuration.Output, func(path string, info os.FileInfo, err error) error {
+				if err == nil && len(info.Name()) > MAX_PATH_COMPONENT_BYTES {
+					t.Errorf("%s is %d bytes long", info.Name(), len(info.Name()))
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestReservedOutputNames(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		meta string
+		err  string
+	}{
+		{"reserved file name", "aux.md", `{"Title": "Aux"}`, "'aux.html' is a reserved file name on Windows"},
+		{"reserved directory", "nul/a.md", `{"Title": "A"}`, "'nul' is a reserved file name on Windows"},
+		{"reserved Path", "a.md", `{"Title": "A", "Path": "LPT1.html"}`, "'LPT1.html' is a reserved file name on Windows"},
+		{"trailing space", "a.md", `{"Title": "A", "Path": "notes /a.html"}`, "ends in a dot or a space"},
+		{"similar but allowed", "console.md", `{"Title": "Console"}`, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Recursive = true
+			site.page(test.file, test.meta, "body\n")
+			err := site.build()
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) || !strings.Contains(err.Error(), site.path("content/"+test.file)) {
+				t.Fatalf("build = %v, want %q naming %s", err, test.err, test.file)
+			}
+			if site.exists(strings.TrimSuffix(test.file, ".md") + ".html") {
+				t.Errorf("%s was written", test.file)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const POLICY_ERROR = "error"
+const POLICY_WARN = "warn"
+const POLICY_IGNORE = "ignore"
+
+const CONDITION_MISSING_META = "missing-meta"
+const CONDITION_MISSING_TITLE = "missing-title"
+const CONDITION_UNPARSEABLE_DATE = "unparseable-date"
+const CONDITION_BROKEN_INTERNAL_LINK = "broken-internal-link"
+const CONDITION_DUPLICATE_OUTPUT = "duplicate-output"
+const CONDITION_MISSING_ASSET = "missing-asset"
+const CONDITION_FUTURE_DATE = "future-date"
+const CONDITION_DUPLICATE_ID = "duplicate-id"
+const CONDITION_HEADING_OUTLINE = "heading-outline"
+const CONDITION_UNREADABLE_FILE = "unreadable-file"
+const CONDITION_HTML_STRUCTURE = "html-structure"
+const CONDITION_A11Y = "a11y"
+const CONDITION_EMBEDDED_UNLISTED = "embedded-unlisted"
+const CONDITION_OUTPUT_SIZE = "output-size"
+
+var DEFAULT_POLICIES = map[string]string{
+	CONDITION_MISSING_META:         POLICY_ERROR,
+	CONDITION_MISSING_TITLE:        POLICY_IGNORE,
+	CONDITION_UNPARSEABLE_DATE:     POLICY_ERROR,
+	CONDITION_BROKEN_INTERNAL_LINK: POLICY_IGNORE,
+	CONDITION_DUPLICATE_OUTPUT:     POLICY_IGNORE,
+	CONDITION_MISSING_ASSET:        POLICY_IGNORE,
+	CONDITION_FUTURE_DATE:          POLICY_IGNORE,
+	CONDITION_DUPLICATE_ID:         POLICY_ERROR,
+	CONDITION_HEADING_OUTLINE:      POLICY_WARN,
+	CONDITION_UNREADABLE_FILE:      POLICY_WARN,
+	CONDITION_HTML_STRUCTURE:       POLICY_WARN,
+	CONDITION_A11Y:                 POLICY_WARN,
+	CONDITION_EMBEDDED_UNLISTED:    POLICY_WARN,
+	CONDITION_OUTPUT_SIZE:          POLICY_WARN,
+}
+
+func checkPolicies(policies map[string]string) error {
+	for condition, policy := range policies {
+		if _, known := DEFAULT_POLICIES[condition]; !known {
+			msg := fmt.Sprintf("unknown policy condition '%s'", condition)
+			return errors.New(msg)
+		}
+		if policy != POLICY_ERROR && policy != POLICY_WARN && policy != POLICY_IGNORE {
+			msg := fmt.Sprintf("policy for '%s' must be error, warn or ignore, not '%s'", condition, policy)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func policyFor(configuration Configuration, condition string) string {
+	policy, found := configuration.Policies[condition]
+	if !found {
+		policy = DEFAULT_POLICIES[condition]
+	}
+	return policy
+}
+
+func (builder *Builder) report(condition string, path string, detail string) bool {
+	return builder.reportTo(nil, condition, path, detail)
+}
+
+func (builder *Builder) reportTo(entry *pageLog, condition string, path string, detail string) bool {
+	policy := policyFor(builder.Configuration, condition)
+	if policy == POLICY_IGNORE {
+		return false
+	}
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+	if builder.Summary.Conditions == nil {
+		builder.Summary.Conditions = map[string]int{}
+	}
+	builder.Summary.Conditions[condition]++
+	builder.findings = append(builder.findings, newFinding(policy, condition, path, detail))
+	line := fmt.Sprintf("%s: %s: %s: %s", policy, condition, path, detail)
+	if policy == POLICY_WARN {
+		builder.warnings.add(condition, path, detail, line)
+		return false
+	}
+	entry.Print(line)
+	if policy == POLICY_ERROR {
+		builder.Summary.Errors++
+		builder.failures = append(builder.failures, &FileError{Category: condition, Path: path, Message: detail})
+		return true
+	}
+	return false
+}
+
+func formatConditions(conditions map[string]int) string {
+	var names []string
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, conditions[name]))
+	}
+	return strings.Join(parts, ", ")
+}
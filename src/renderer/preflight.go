@@ -0,0 +1,108 @@
+package renderer
+
+func Preflight(configuration Configuration) error {
+	_, err := loadLocation(configuration)
+	if err == nil {
+		err = checkMarkdownOptions(configuration.Markdown)
+	}
+	if err == nil {
+		err = checkMenu(configuration)
+	}
+	if err == nil {
+		err = checkPolicies(configuration.Policies)
+	}
+	if err == nil {
+		err = checkMetaFences(configuration.MetaFences)
+	}
+	if err == nil {
+		err = checkOutputMode(configuration)
+	}
+	if err == nil {
+		err = checkShortcodes(configuration)
+	}
+	if err == nil {
+		err = checkTopHeadingLevel(configuration)
+	}
+	if err == nil {
+		err = checkFormats(configuration)
+	}
+	if err == nil {
+		err = checkLockTimeout(configuration)
+	}
+	if err == nil {
+		err = checkImages(configuration)
+	}
+	if err == nil {
+		err = checkTaxonomies(configuration)
+	}
+	if err == nil {
+		err = checkKindDirectories(configuration)
+	}
+	if err == nil {
+		err = checkCalendar(configuration)
+	}
+	if err == nil {
+		err = checkAbbreviations(configuration)
+	}
+	if err == nil {
+		err = checkCodeBlockWrapper(configuration)
+	}
+	if err == nil {
+		err = checkExtraOutputs(configuration)
+	}
+	if err == nil {
+		err = checkTagAliases(configuration)
+	}
+	if err == nil {
+		err = checkTemplateRules(configuration)
+	}
+	if err == nil {
+		err = checkEmptySite(configuration)
+	}
+	if err == nil {
+		err = checkSortLocale(configuration)
+	}
+	if err == nil {
+		err = checkFeed(configuration)
+	}
+	if err == nil {
+		err = checkIcons(configuration)
+	}
+	if err == nil {
+		err = checkAZ(configuration)
+	}
+	if err == nil {
+		err = checkIndexShards(configuration)
+	}
+	if err == nil {
+		err = checkFailedPages(configuration)
+	}
+	if err == nil {
+		err = checkInFlight(configuration)
+	}
+	if err == nil {
+		err = checkAuthorDisplay(configuration)
+	}
+	if err == nil {
+		err = checkOutputSizes(configuration)
+	}
+	if err == nil {
+		err = checkInline(configuration)
+	}
+	if err == nil {
+		err = checkLanguages(configuration)
+	}
+	if err == nil {
+		err = checkWarningLimit(configuration)
+	}
+	if err == nil {
+		err = checkCleanLimit(configuration)
+	}
+	if err == nil {
+		err = checkBundles(configuration)
+	}
+	if err == nil {
+		warnTemplateFields(configuration)
+	}
+	return err
+}
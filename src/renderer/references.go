@@ -0,0 +1,213 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const INDEX_FILE_NAME = "index.html"
+
+func referenceTarget(outputPath string, destination string) (string, bool) {
+	parsed, err := url.Parse(destination)
+	if err != nil || len(parsed.Scheme) > 0 || len(parsed.Host) > 0 || len(parsed.Path) == 0 {
+		return "", false
+	}
+	target := parsed.Path
+	if strings.HasPrefix(target, "/") {
+		target = path.Clean(target)
+	} else {
+		target = path.Join("/", path.Dir(outputPath), target)
+	}
+	if strings.HasSuffix(parsed.Path, "/") || target == "/" {
+		target = path.Join(target, INDEX_FILE_NAME)
+	}
+	return normalizeName(strings.TrimPrefix(target, "/")), true
+}
+
+func reservedOutputs(configuration Configuration, sources []Source) map[string]string {
+	reserved := map[string]string{INDEX_FILE_NAME: "the index"}
+	notFoundSource := filepath.Join(configuration.Input, NOT_FOUND_SOURCE_NAME)
+	if CheckPathError(notFoundSource) == nil || len(configuration.Template404) > 0 {
+		reserved[NOT_FOUND_FILE_NAME] = "the 404 page"
+	}
+	if len(configuration.BaseURL) > 0 {
+		reserved[SITEMAP_FILE_NAME] = "the sitemap"
+	}
+	if configuration.Robots != nil {
+		reserved[ROBOTS_FILE_NAME] = "robots.txt"
+	}
+	if len(configuration.Calendar) > 0 {
+		reserved[CALENDAR_FILE_NAME] = "the calendar"
+	}
+	if feedFormats(configuration)[FEED_FORMAT_JSON] {
+		reserved[JSON_FEED_FILE_NAME] = "the JSON feed"
+	}
+	if configuration.MediaIndex {
+		reserved[MEDIA_FILE_NAME] = "the media index"
+	}
+	if configuration.SearchIndex {
+		reserved[SEARCH_FILE_NAME] = "the search index"
+	}
+	if configuration.ContentStats {
+		reserved[CONTENT_STATS_FILE_NAME] = "the content stats"
+	}
+	for _, name := range iconOutputs(configuration) {
+		reserved[name] = "the icons"
+	}
+	listed, _ := sourceLinks(sources)
+	for _, name := range shardOutputs(configuration, listed) {
+		reserved[name] = "the index shards"
+	}
+	for _, extra := range configuration.ExtraOutputs {
+		reserved[extra.Output] = "ExtraOutputs"
+	}
+	return reserved
+}
+
+func buildStateOutputs() map[string]string {
+	return map[string]string{
+		MANIFEST_FILE_NAME: "the build manifest",
+		CACHE_FILE_NAME:    "the build cache",
+		LOCK_FILE_NAME:     "the build lock",
+	}
+}
+
+func knownOutputs(configuration Configuration, sources []Source) map[string]bool {
+	outputs := map[string]bool{}
+	for name := range reservedOutputs(configuration, sources) {
+		outputs[name] = true
+	}
+	for _, source := range sources {
+		outputs[source.OutputPath] = true
+		outputs[source.Name] = true
+		for _, path := range variantPaths(source) {
+			outputs[path] = true
+		}
+	}
+	listed, _ := sourceLinks(sources)
+	for _, page := range generatedPages(configuration, buildTaxonomies(sources, configuration), azLetters(configuration, listed)) {
+		outputs[page.Path] = true
+	}
+	return outputs
+}
+
+func isPageTarget(target string) bool {
+	extension := path.Ext(target)
+	return extension == ".html" || extension == MARKDOWN_FILE_ENDING || len(extension) == 0
+}
+
+func pageExists(outputs map[string]bool, target string) bool {
+	if len(path.Ext(target)) == 0 {
+		return outputs[target+".html"] || outputs[path.Join(target, INDEX_FILE_NAME)]
+	}
+	return outputs[target]
+}
+
+func assetExists(configuration Configuration, target string) bool {
+	if strings.HasPrefix(target, "../") {
+		return false
+	}
+	return CheckPathError(diskPath(configuration.Input, target)) == nil ||
+		CheckPathError(diskPath(configuration.Output, target)) == nil
+}
+
+func checkReferences(builder *Builder, entry *pageLog, source Source, outputs map[string]bool) {
+	configuration := builder.Configuration
+	for _, destination := range source.Links {
+		target, internal := referenceTarget(source.OutputPath, destination)
+		if !internal {
+			continue
+		}
+		if isPageTarget(target) {
+			if !pageExists(outputs, target) {
+				detail := fmt.Sprintf("link to '%s' does not resolve to a page", destination)
+				builder.reportTo(entry, CONDITION_BROKEN_INTERNAL_LINK, source.Path, detail)
+			}
+		} else if !outputs[target] && !assetExists(configuration, target) {
+			detail := fmt.Sprintf("linked file '%s' does not exist", destination)
+			builder.reportTo(entry, CONDITION_MISSING_ASSET, source.Path, detail)
+		}
+	}
+	for _, destination := range source.Assets {
+		target, internal := referenceTarget(source.OutputPath, destination)
+		if internal && !outputs[target] && !assetExists(configuration, target) {
+			detail := fmt.Sprintf("image '%s' does not exist", destination)
+			builder.reportTo(entry, CONDITION_MISSING_ASSET, source.Path, detail)
+		}
+	}
+}
+
+func checkOutputs(builder *Builder, sources []Source) (map[string]bool, error) {
+	dropped := map[string]bool{}
+	pages := map[string]string{}
+	reserved := map[string]string{}
+	for _, outputs := range []map[string]string{reservedOutputs(builder.Configuration, sources), buildStateOutputs()} {
+		for name, owner := range outputs {
+			reserved[strings.ToLower(name)] = owner
+		}
+	}
+	owners := map[string]string{}
+	explicit := map[string]bool{}
+	listed, _ := sourceLinks(sources)
+	generated := generatedPages(builder.Configuration, buildTaxonomies(sources, builder.Configuration), azLetters(builder.Configuration, listed))
+	for _, page := range generated {
+		owners[strings.ToLower(page.Path)] = page.Owner
+	}
+	for _, source := range sources {
+		if err := sourcePathError(builder.Configuration, source); err != nil {
+			builder.failPage(nil, FAILURE_COLLECT, source.Path, source.Name, nil, err)
+			dropped[source.Name] = true
+			continue
+		}
+		other, taken := pages[source.OutputPath]
+		if taken {
+			msg := fmt.Sprintf("output '%s' is written by both %s and %s", source.OutputPath, other, source.Path)
+			return dropped, errors.New(msg)
+		}
+		pages[source.OutputPath] = source.Path
+		key := strings.ToLower(source.OutputPath)
+		if owner, taken := reserved[key]; taken {
+			msg := fmt.Sprintf("%s: output '%s' is a reserved output, written by %s", source.Path, source.OutputPath, owner)
+			return dropped, errors.New(msg)
+		}
+		owner, taken := owners[key]
+		if taken && (len(source.Meta.Path) > 0 || explicit[key]) {
+			msg := fmt.Sprintf("output '%s' of %s collides with the output of %s when case is ignored", source.OutputPath, source.Path, owner)
+			return dropped, errors.New(msg)
+		}
+		if taken {
+			detail := fmt.Sprintf("output '%s' is also written by %s", source.OutputPath, owner)
+			if builder.report(CONDITION_DUPLICATE_OUTPUT, source.Path, detail) {
+				dropped[source.Name] = true
+			}
+			continue
+		}
+		owners[key] = source.Path
+		explicit[key] = len(source.Meta.Path) > 0
+	}
+	for _, page := range generated {
+		err := checkOutputPath(builder.Configuration, page.Path)
+		if err != nil {
+			msg := fmt.Sprintf("%s: %s", page.Owner, err)
+			return dropped, errors.New(msg)
+		}
+	}
+	return dropped, nil
+}
+
+func withoutSources(sources []Source, dropped map[string]bool) []Source {
+	if len(dropped) == 0 {
+		return sources
+	}
+	var kept []Source
+	for _, source := range sources {
+		if !dropped[source.Name] {
+			kept = append(kept, source)
+		}
+	}
+	return kept
+}
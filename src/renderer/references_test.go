@@ -0,0 +1,134 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReservedOutputPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		configure func(configuration *Configuration)
+		owner     string
+	}{
+		{"index", "index.html", nil, "the index"},
+		{"index in another case", "/Index.html", nil, "the index"},
+		{"manifest", "manifest.json", nil, "the build manifest"},
+		{"cache", CACHE_FILE_NAME, nil, "the build cache"},
+		{"lock", LOCK_FILE_NAME, nil, "the build lock"},
+		{"sitemap", SITEMAP_FILE_NAME, func(configuration *Configuration) {
+			configuration.BaseURL = "https://example.com/"
+		}, "the sitemap"},
+		{"feed", JSON_FEED_FILE_NAME, func(configuration *Configuration) {
+			configuration.BaseURL = "https://example.com/"
+			configuration.Feed = &Feed{Formats: []string{FEED_FORMAT_JSON}}
+		}, "the JSON feed"},
+		{"robots", ROBOTS_FILE_NAME, func(configuration *Configuration) {
+			configuration.Robots = &Robots{}
+		}, "robots.txt"},
+		{"stats", CONTENT_STATS_FILE_NAME, func(configuration *Configuration) {
+			configuration.ContentStats = true
+		}, "the content stats"},
+		{"search index", SEARCH_FILE_NAME, func(configuration *Configuration) {
+			configuration.SearchIndex = true
+		}, "the search index"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			if test.configure != nil {
+				test.configure(&site.Configuration)
+			}
+			site.page("a.md", `{"Title": "A", "Path": "`+test.path+`"}`, "body\n")
+			site.page("b.md", `{"Title": "B"}`, "body\n")
+			err := site.build()
+			if err == nil {
+				t.Fatalf("build with Path '%s' succeeded", test.path)
+			}
+			if !strings.Contains(err.Error(), "reserved output") || !strings.Contains(err.Error(), test.owner) {
+				t.Errorf("error = %q, want a reserved output error naming %s", err, test.owner)
+			}
+			if strings.Contains(err.Error(), "paginate") {
+				t.Errorf("error = %q mentions pagination", err)
+			}
+			for _, name := range []string{INDEX_FILE_NAME, "b.html", MANIFEST_FILE_NAME} {
+				if site.exists(name) {
+					t.Errorf("%s was written before the collision was reported", name)
+				}
+			}
+		})
+	}
+}
+
+func TestUnreservedOutputPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"404 without a 404 page", NOT_FOUND_FILE_NAME, NOT_FOUND_FILE_NAME},
+		{"sitemap without a BaseURL", SITEMAP_FILE_NAME, SITEMAP_FILE_NAME},
+		{"index in a directory", "docs/", "docs/index.html"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.page("a.md", `{"Title": "A", "Path": "`+test.path+`"}`, "body\n")
+			site.mustBuild()
+			if !strings.Contains(site.read(test.want), "<title>A</title>") {
+				t.Errorf("%s does not hold the page", test.want)
+			}
+		})
+	}
+}
+
+func TestExplicitPathCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  string
+		second string
+		err    string
+	}{
+		{"same Path", `"Path": "legal/imprint.html"`, `"Path": "legal/imprint.html"`, "is written by both"},
+		{"Path differing in case", `"Path": "legal/Imprint.html"`, `"Path": "legal/imprint.html"`, "when case is ignored"},
+		{"Path and a file name", `"Path": "B.html"`, ``, "when case is ignored"},
+		{"Path and a tag listing", `"Path": "tags/Go.html"`, `"Tags": ["go"]`, "when case is ignored"},
+		{"file names differing in case", ``, ``, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Taxonomies = map[string]string{"tags": "tags"}
+			first, second := `{"Title": "A"}`, `{"Title": "B"}`
+			if len(test.first) > 0 {
+				first = `{"Title": "A", ` + test.first + `}`
+			}
+			if len(test.second) > 0 {
+				second = `{"Title": "B", ` + test.second + `}`
+			}
+			site.page("a.md", first, "body\n")
+			site.page("b.md", second, "body\n")
+			if len(test.err) == 0 {
+				site.page("B.md", `{"Title": "Other B"}`, "body\n")
+			}
+			site.Configuration.Policies = map[string]string{CONDITION_DUPLICATE_OUTPUT: POLICY_IGNORE}
+			err := site.build()
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatalf("build = %v, want the duplicate-output policy to decide", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("build = %v, want %q", err, test.err)
+			}
+			if !strings.Contains(err.Error(), site.path("content/a.md")) {
+				t.Errorf("error = %q, want it to name content/a.md", err)
+			}
+			if !strings.Contains(err.Error(), site.path("content/b.md")) && !strings.Contains(err.Error(), "tags") {
+				t.Errorf("error = %q, want it to name the other source", err)
+			}
+		})
+	}
+}
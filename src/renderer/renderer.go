@@ -0,0 +1,1200 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const ENVIRONMENTAL_VARIABLE = "CONFIG"
+const MARKDOWN_FILE_ENDING = ".md"
+
+type Configuration struct {
+	Input                 string
+	Output                string
+	TemplatePage          string
+	TemplateIndex         string
+	Authors               map[string]Author
+	GroupOrder            []string
+	DefaultGroup          string
+	Inject                Inject
+	RecentCount           int
+	BaseURL               string
+	Title                 string
+	Robots                *Robots
+	Template404           string
+	MaxFileSize           int64
+	SkipLarge             bool
+	CacheFile             string
+	Recursive             bool
+	Include               []string
+	Exclude               []string
+	IncludeRawSource      bool
+	EditURLPattern        string
+	Timezone              string
+	Markdown              MarkdownOptions
+	Menu                  []MenuEntry
+	Policies              map[string]string
+	SmartPunctuation      *bool
+	AngledQuotes          bool
+	FootnoteReturnLink    FootnoteReturnLink
+	OutputText            bool
+	RenderTitles          bool
+	MetaFences            []string
+	UnpublishExpired      bool
+	OutputMode            string
+	TemplateBook          string
+	BookInlineImages      bool
+	GeneratorComment      bool
+	Shortcodes            string
+	OutlineLint           bool
+	TopHeadingLevel       int
+	NormalizeHeadings     bool
+	Outputs               []string
+	LockTimeout           string
+	Images                *ImageOptions
+	Taxonomies            map[string]string
+	TemplateTerm          string
+	TemplateAZ            string
+	AZ                    *AZIndex
+	IndexShards           *IndexShards
+	KindDirectories       map[string]string
+	Calendar              string
+	Abbreviations         string
+	DisallowUnknownFields bool
+	CodeBlockWrapper      *CodeBlockWrapper
+	ContentStats          bool
+	ExtraOutputs          []ExtraOutput
+	TagAliases            map[string]string
+	SlugifyFileNames      bool
+	FirstBuildAsNew       bool
+	ChangeIgnoreFields    []string
+	BuildMeta             map[string]string
+	StrictEnvironment     bool
+	TemplateRules         []TemplateRule
+	EmptySite             string
+	CopyAssets            bool
+	AssetWorkers          int
+	StripComments         bool
+	SortLocale            string
+	SortIgnoreArticles    bool
+	SortArticles          []string
+	Feed                  *Feed
+	Icons                 *Icons
+	FailedPages           string
+	MediaIndex            bool
+	SitemapListings       bool
+	SortAuthors           bool
+	JekyllStyleNames      bool
+	TruncateLongPaths     bool
+	AuthorDisplay         *AuthorDisplay
+	MaxInFlightBytes      int64
+	MaxPageBytes          int64
+	MaxAssetBytes         int64
+	IndexParams           []string
+	VerifyIgnore          []string
+	InlineDirectory       string
+	MaxInlineBytes        int64
+	Language              string
+	Languages             []string
+	Bundles               *Bundles
+	WarningLimit          int
+	CleanLimit            int
+	ValidationWorkers     int
+	PageWorkers           int
+	SearchIndex           bool
+}
+
+type Author struct {
+	Name         string
+	Mail         string
+	Organization string
+	ORCID        string
+}
+type MetaBlock struct {
+	Title        string
+	Date         MetaDate
+	Authors      AuthorList
+	Category     string
+	Tags         []string
+	Path         string
+	ExpiryDate   MetaDate
+	Outputs      []string
+	Kind         string
+	Unlisted     bool
+	Draft        bool
+	NoIndex      bool
+	MaxPageBytes int64
+	Summary      string                 `json:",omitempty"`
+	Lang         string                 `json:",omitempty"`
+	Params       map[string]interface{} `json:",omitempty"`
+	Fields       map[string][]string    `json:"-"`
+
+	raw []byte
+}
+type Page struct {
+	Title        string
+	TitleHTML    htmlTemplate.HTML
+	Date         string
+	Authors      []Author
+	Content      string
+	Category     string
+	Tags         []string
+	Site         *Site
+	SourcePath   string
+	RawMarkdown  string
+	EditURL      string
+	Menu         []MenuItem
+	Expired      bool
+	Kind         string
+	Taxonomies   map[string][]Term
+	WordCount    int
+	Change       string
+	Unlisted     bool
+	NoIndex      bool
+	Summary      string
+	Params       map[string]interface{}
+	Lang         string
+	Translations []Translation
+
+	authorDisplay AuthorDisplay
+}
+
+type Link struct {
+	Title     string
+	TitleHTML htmlTemplate.HTML
+	Date      string
+	Url       string
+	Category  string
+	Tags      []string
+	Authors   []Author
+	Kind      string
+	Summary   string
+	Params    map[string]interface{}
+	Lang      string
+}
+
+type Index struct {
+	Links  []Link
+	Groups []Group
+	Site   *Site
+	Menu   []MenuItem
+	Recent []Link
+	Total  int
+	Oldest string
+	Newest string
+
+	Calendar     map[string]int
+	ChangedPages []PageChange
+
+	Taxonomy string
+	Term     Term
+
+	Letters []AZLetter
+	Letter  string
+
+	Shards []IndexShard
+	Shard  string
+}
+
+type Source struct {
+	Name        string
+	Path        string
+	OutputPath  string
+	Link        Link
+	Published   time.Time
+	Hash        string
+	Size        int64
+	ModTime     time.Time
+	Meta        MetaBlock
+	MissingMeta bool
+	Links       []string
+	Assets      []string
+	Headings    []Heading
+	Formats     []string
+	Fields      map[string][]string
+	Terms       map[string][]string
+	Expired     bool
+	Unlisted    bool
+	NoIndex     bool
+	Words       int
+	Media       []MediaReference
+	ChangeHash  string
+	Change      string
+	Updated     time.Time
+	Embeds      map[string]string `json:",omitempty"`
+	Page        Page              `json:"-"`
+	Unchanged   bool              `json:"-"`
+	Stale       bool              `json:"-"`
+
+	extra *extraText
+}
+
+func LoadConfig() (Configuration, error) {
+	var configuration Configuration
+	path, err := configPath()
+	if err == nil {
+		var data []byte
+		data, err = ioutil.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal([]byte(data), &configuration)
+		}
+	}
+	if err == nil {
+		err = resolveConfigPaths(&configuration, filepath.Dir(path))
+	}
+	if err == nil {
+		err = expandBuildMeta(&configuration)
+	}
+	return configuration, err
+}
+
+func CheckPathError(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+func parseSource(data []byte, configuration Configuration) (MetaBlock, []byte, error) {
+	var metaBlock MetaBlock
+	var err error
+	text := data
+	if len(text) > 0 {
+		var contentStart int
+		metaBlock, contentStart, err = getMetaBlock(text, configuration)
+		if err == nil {
+			text = text[contentStart:]
+		} else if err == errMissingMetaStart {
+			err = errMissingMeta
+		} else {
+			msg := fmt.Sprintf("meta block error: %s", err)
+			err = errors.New(msg)
+		}
+	} else {
+		err = errEmptySource
+	}
+	return metaBlock, text, err
+}
+
+func readSource(path string, configuration Configuration) (MetaBlock, []byte, int, error) {
+	var metaBlock MetaBlock
+	var text []byte
+	line := 1
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = checkSourceText(data)
+	}
+	if err == nil {
+		metaBlock, text, err = parseSource(data, configuration)
+		line += bytes.Count(data[:len(data)-len(text)], []byte("\n"))
+	}
+	return metaBlock, text, line, err
+}
+
+func buildPage(metaBlock MetaBlock, configuration Configuration) (Page, error) {
+	var page Page
+	var authors []Author
+	var location *time.Location
+	err := checkDate(metaBlock.Date)
+	if err == nil {
+		err = checkDate(metaBlock.ExpiryDate)
+	}
+	if err == nil && len(metaBlock.Lang) > 0 {
+		err = checkLanguageCode("Lang", metaBlock.Lang)
+	}
+	if err == nil {
+		authors, err = resolveAuthors(metaBlock.Authors, configuration.Authors)
+	}
+	if err == nil && configuration.SortAuthors {
+		sortAuthors(authors, collatorFor(configuration))
+	}
+	if err == nil {
+		location, err = loadLocation(configuration)
+	}
+	if err == nil {
+		page = Page{
+			Title:     metaBlock.Title,
+			TitleHTML: renderTitle(metaBlock.Title, configuration),
+			Date:      formatDate(metaBlock.Date, location),
+			Authors:   authors,
+			Category:  metaBlock.Category,
+			Tags:      metaBlock.Tags,
+			Summary:   metaBlock.Summary,
+			Params:    metaBlock.Params,
+
+			authorDisplay: authorDisplay(configuration),
+		}
+	}
+	return page, err
+}
+
+func renderFile(path string, configuration Configuration, abbreviations map[string]string) (Page, error) {
+	var page Page
+	metaBlock, text, line, err := readSource(path, configuration)
+	if err == nil {
+		page, err = buildPage(metaBlock, configuration)
+	}
+	if err == nil {
+		page.Content, _, err = renderDocument(text, configuration, abbreviations, filepath.Base(path), line)
+	}
+	if err == nil {
+		err = validatePage(configuration, page)
+	}
+	if err != nil {
+		return Page{}, err
+	}
+	return page, nil
+}
+
+func executeTemplate(writer io.Writer, outputPath string, templatePath string, data interface{}, site *Site, configuration Configuration) error {
+	templateObj := template.New(filepath.Base(templatePath)).Funcs(templateFuncs(configuration, site, outputPath))
+	templateObj, err := templateObj.ParseFiles(templatePath)
+	if err == nil {
+		err = templateObj.Execute(writer, data)
+	}
+	return err
+}
+
+func writeHtml(output OutputWriter, outputPath string, templatePath string, data interface{}, site *Site, configuration Configuration) error {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	err := executeTemplate(buffer, outputPath, templatePath, data, site, configuration)
+	if err == nil {
+		err = writeRendered(output, outputPath, buffer.Bytes(), site, configuration)
+	}
+	return err
+}
+
+func writeRendered(output OutputWriter, outputPath string, rendered []byte, site *Site, configuration Configuration) error {
+	html := injectSnippets(rendered, configuration.Inject, outputPath)
+	if configuration.GeneratorComment && site != nil {
+		html = generatorComment(html, site)
+	}
+	return writeFile(output, outputPath, html)
+}
+
+func doTemplating(output OutputWriter, outputPath string, templatePath string, page Page, configuration Configuration) error {
+	return writeHtml(output, outputPath, templatePath, page, page.Site, configuration)
+}
+
+func doIndex(output OutputWriter, outputPath string, templatePath string, index Index, configuration Configuration) error {
+	return writeListing(output, outputPath, templatePath, index, configuration)
+}
+
+func listDirectory(configuration Configuration, directory string, summary *Summary) ([]string, error) {
+	var names []string
+	inputFiles, err := ioutil.ReadDir(filepath.Join(configuration.Input, filepath.FromSlash(directory)))
+	count := len(inputFiles)
+	for index := 0; err == nil && index < count; index++ {
+		inputFile := inputFiles[index]
+		name := path.Join(directory, inputFile.Name())
+		if inputFile.IsDir() {
+			if !configuration.Recursive {
+				continue
+			}
+			if isExcluded(configuration, name, true) {
+				summary.Excluded++
+				continue
+			}
+			if isBundleDirectory(configuration, name) {
+				bundleIndex := path.Join(name, BUNDLE_INDEX_NAME)
+				if isExcluded(configuration, bundleIndex, false) {
+					summary.Excluded++
+				} else {
+					names = append(names, bundleIndex)
+				}
+				continue
+			}
+			var nested []string
+			nested, err = listDirectory(configuration, name, summary)
+			names = append(names, nested...)
+		} else if strings.HasSuffix(name, MARKDOWN_FILE_ENDING) {
+			if isExcluded(configuration, name, false) {
+				summary.Excluded++
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	return names, err
+}
+
+func listPages(configuration Configuration, summary *Summary) ([]string, error) {
+	return listDirectory(configuration, "", summary)
+}
+
+func sourceOutputPath(name string) string {
+	return strings.TrimSuffix(name, MARKDOWN_FILE_ENDING) + ".html"
+}
+
+func collectPages(builder *Builder, names []string, cached map[string]Source) ([]Source, error) {
+	var sources []Source
+	var location *time.Location
+	configuration := builder.Configuration
+	now, err := builder.now()
+	if err == nil {
+		location, err = loadLocation(configuration)
+	}
+	if err != nil {
+		return sources, err
+	}
+	aliases := tagAliases(configuration)
+	for _, fileName := range names {
+		if fileName == NOT_FOUND_SOURCE_NAME {
+			continue
+		}
+		inputFilePath := filepath.Join(configuration.Input, filepath.FromSlash(fileName))
+		fileName = normalizeName(fileName)
+		var info os.FileInfo
+		info, err = os.Stat(inputFilePath)
+		if err == nil && configuration.MaxFileSize > 0 && info.Size() > configuration.MaxFileSize {
+			msg := fmt.Sprintf("%s is %d bytes, larger than MaxFileSize %d", inputFilePath, info.Size(), configuration.MaxFileSize)
+			if configuration.SkipLarge {
+				log.Print("skipping: ", msg)
+				continue
+			}
+			err = errors.New(msg)
+		}
+		if err != nil {
+			sources = builder.failPage(sources, FAILURE_COLLECT, inputFilePath, fileName, cached, err)
+			err = nil
+			continue
+		}
+		log.Print("collecting: ", inputFilePath)
+		var page Page
+		previous, known := cached[fileName]
+		change := CHANGE_UNCHANGED
+		unchanged := known && previous.Size == info.Size() && previous.ModTime.Equal(info.ModTime())
+		if unchanged {
+			err = checkReadable(inputFilePath)
+		}
+		var data []byte
+		if err == nil && !unchanged {
+			data, err = ioutil.ReadFile(inputFilePath)
+			if err == nil {
+				err = checkSourceText(data)
+			}
+		}
+		if err != nil {
+			builder.skipUnreadable(inputFilePath, err)
+			err = nil
+			continue
+		}
+		if !unchanged {
+			hash := hashContent(data)
+			unchanged = hash == previous.Hash
+			if !unchanged {
+				var text []byte
+				previous.Meta, text, err = parseSource(data, configuration)
+				previous.Fields = previous.Meta.Fields
+				previous.MissingMeta = isMissingMeta(err)
+				if previous.MissingMeta {
+					err = nil
+				}
+				contentHash := changeHash(previous.Meta, text, configuration.ChangeIgnoreFields)
+				change = pageChange(configuration, len(cached) == 0, previous, known, contentHash)
+				previous.ChangeHash = contentHash
+			}
+			previous.Hash = hash
+		}
+		if err == nil && previous.MissingMeta && builder.report(CONDITION_MISSING_META, inputFilePath, "file has no meta block") {
+			continue
+		}
+		previous.Meta.Fields = previous.Fields
+		metaBlock := copyMetaBlock(previous.Meta)
+		if err == nil {
+			err = builder.runBeforePage(inputFilePath, &metaBlock)
+			if err == ErrSkipPage {
+				log.Print("skipping: ", inputFilePath)
+				err = nil
+				continue
+			}
+		}
+		metaBlock.Tags = normalizeTags(metaBlock.Tags, aliases)
+		if err == nil && len(metaBlock.Date.Unparsed) > 0 {
+			if builder.report(CONDITION_UNPARSEABLE_DATE, inputFilePath, checkDate(metaBlock.Date).Error()) {
+				continue
+			}
+			metaBlock.Date = MetaDate{}
+		}
+		var datePrefix string
+		if configuration.JekyllStyleNames {
+			date, prefix, found := nameDate(fileName)
+			if found && metaBlock.Date.IsZero() {
+				metaBlock.Date = date
+			}
+			datePrefix = prefix
+		}
+		if err == nil && len(metaBlock.ExpiryDate.Unparsed) > 0 {
+			if builder.report(CONDITION_UNPARSEABLE_DATE, inputFilePath, checkDate(metaBlock.ExpiryDate).Error()) {
+				continue
+			}
+			metaBlock.ExpiryDate = MetaDate{}
+		}
+		expired := !metaBlock.ExpiryDate.IsZero() && !resolveDate(metaBlock.ExpiryDate, location).After(now)
+		if err == nil && expired && configuration.UnpublishExpired {
+			log.Print("skipping expired: ", inputFilePath)
+			continue
+		}
+		if err == nil && metaBlock.Draft {
+			log.Print("skipping draft: ", inputFilePath)
+			continue
+		}
+		if err == nil && len(strings.TrimSpace(metaBlock.Title)) == 0 && builder.report(CONDITION_MISSING_TITLE, inputFilePath, "meta block has no Title") {
+			continue
+		}
+		if err == nil {
+			page, err = buildPage(metaBlock, configuration)
+		}
+		if err == nil {
+			page.Expired = expired
+			page.Unlisted = metaBlock.Unlisted
+			page.NoIndex = metaBlock.NoIndex || metaBlock.Unlisted
+			page.Lang = resolveLanguage(configuration, fileName, metaBlock)
+			applySource(&page, fileName, configuration)
+		}
+		if err == nil && !metaBlock.Date.IsZero() {
+			date := resolveDate(metaBlock.Date, location)
+			if date.After(now) {
+				detail := fmt.Sprintf("date %s is in the future", date.Format(time.RFC3339))
+				if builder.report(CONDITION_FUTURE_DATE, inputFilePath, detail) {
+					continue
+				}
+			}
+		}
+		if err != nil {
+			sources = builder.failPage(sources, FAILURE_COLLECT, inputFilePath, fileName, cached, err)
+			err = nil
+			continue
+		}
+		htmlFileName := jekyllOutputPath(sourceOutputPath(fileName), datePrefix)
+		if len(metaBlock.Path) > 0 {
+			htmlFileName, err = metaOutputPath(metaBlock.Path)
+			if err != nil {
+				msg := fmt.Sprintf("meta block error: %s", err)
+				sources = builder.failPage(sources, FAILURE_COLLECT, inputFilePath, fileName, cached, errors.New(msg))
+				err = nil
+				continue
+			}
+		} else if configuration.SlugifyFileNames && outputUrl(htmlFileName) != "/"+htmlFileName {
+			slugged := slugOutputPath(htmlFileName)
+			builder.warn(WARNING_UNSAFE_FILE_NAME, inputFilePath, "%s is written as %s, its file name is not URL safe", inputFilePath, slugged)
+			htmlFileName = slugged
+		}
+		htmlFileName = truncateOutputPath(configuration, htmlFileName)
+		var formats []string
+		formats, err = pageFormats(configuration, metaBlock)
+		if err == nil {
+			page.Kind, err = resolveKind(configuration, metaBlock, fileName)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("meta block error: %s", err)
+			sources = builder.failPage(sources, FAILURE_COLLECT, inputFilePath, fileName, cached, errors.New(msg))
+			err = nil
+			continue
+		}
+		err = validatePage(configuration, page)
+		if err != nil {
+			sources = builder.failPage(sources, FAILURE_COLLECT, inputFilePath, fileName, cached, err)
+			err = nil
+			continue
+		}
+		page.Change = change
+		source := Source{
+			Name:       fileName,
+			Path:       inputFilePath,
+			OutputPath: htmlFileName,
+			Link: Link{
+				Title:     page.Title,
+				TitleHTML: page.TitleHTML,
+				Date:      page.Date,
+				Url:       pageOutputUrl(configuration, fileName, htmlFileName),
+				Category:  page.Category,
+				Tags:      page.Tags,
+				Authors:   page.Authors,
+				Kind:      page.Kind,
+				Summary:   page.Summary,
+				Params:    linkParams(configuration, page.Params),
+				Lang:      page.Lang,
+			},
+			Published:   resolveDate(metaBlock.Date, location),
+			Hash:        previous.Hash,
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+			Meta:        previous.Meta,
+			MissingMeta: previous.MissingMeta,
+			Links:       previous.Links,
+			Assets:      previous.Assets,
+			Headings:    previous.Headings,
+			Words:       previous.Words,
+			Media:       previous.Media,
+			ChangeHash:  previous.ChangeHash,
+			Change:      change,
+			Updated:     previous.Updated,
+			Embeds:      previous.Embeds,
+			Formats:     formats,
+			Fields:      previous.Fields,
+			Terms:       metaTerms(metaBlock, configuration),
+			Expired:     expired,
+			Unlisted:    metaBlock.Unlisted,
+			NoIndex:     metaBlock.NoIndex,
+			Page:        page,
+			Unchanged:   unchanged && previous.Change == change,
+		}
+		sources = append(sources, source)
+	}
+	return sources, err
+}
+
+func sourceContent(configuration Configuration, source Source, site *Site) (string, error) {
+	if len(source.Page.Content) > 0 {
+		return source.Page.Content, nil
+	}
+	var content string
+	text, line, err := sourceText(source, configuration)
+	if err == nil {
+		engine := newMarkdownEngine(configuration, site.abbreviations)
+		engine.site = site
+		content, _, err = engine.renderPage(text, configuration, source.Name, line)
+	}
+	return content, err
+}
+
+func renderSource(builder *Builder, entry *pageLog, output OutputWriter, source Source, site *Site, images *imageProcessor) (markdownReferences, error) {
+	var references markdownReferences
+	page := source.Page
+	text, line, err := sourceText(source, builder.Configuration)
+	if err == nil && !isPrerendered(source) {
+		engine := newMarkdownEngine(builder.Configuration, site.abbreviations)
+		engine.site = site
+		if images != nil {
+			engine.sourceSet = images.sourceSet(source.OutputPath)
+			engine.imageSizes = builder.Configuration.Images.Sizes
+		}
+		err = site.embeds.enter(source.Name)
+		if err == nil {
+			page.Content, references, err = engine.renderPage(text, builder.Configuration, source.Name, line)
+			site.embeds.leave()
+		}
+	}
+	if err == nil {
+		page.WordCount = WordCount(page.Content)
+		references.Words = page.WordCount
+		if builder.Configuration.IncludeRawSource {
+			page.RawMarkdown = string(text)
+		}
+		page.Site = site
+		page.Menu = resolveMenu(builder.Configuration.Menu, source.Link.Url, site)
+		err = builder.runAfterRender(&page)
+		references.Content = page.Content
+	}
+	if err == nil {
+		err = validateRenderedPage(builder.Configuration, page)
+	}
+	for _, format := range source.Formats {
+		if err != nil {
+			break
+		}
+		configuration := builder.Configuration
+		outputPath := formatOutputPath(source.OutputPath, format)
+		switch format {
+		case FORMAT_HTML:
+			templatePath, rule := pageTemplate(configuration, source.Name)
+			if rule >= 0 {
+				entry.Printf("template: %s matches rule #%d '%s', using %s", source.Path, rule+1, configuration.TemplateRules[rule].Pattern, templatePath)
+			}
+			err = doTemplating(output, outputPath, templatePath, page, configuration)
+		case FORMAT_TEXT:
+			err = writeFile(output, outputPath, renderText(page))
+		case FORMAT_JSON:
+			var data []byte
+			data, err = renderJson(page, source.Link.Url)
+			if err == nil {
+				err = writeFile(output, outputPath, data)
+			}
+		}
+	}
+	return references, err
+}
+
+func renderFiles(builder *Builder) error {
+	var content Index
+	var names []string
+	var cache BuildCache
+	configuration := builder.Configuration
+	abbreviations, err := loadAbbreviations(configuration.Abbreviations)
+	if err != nil {
+		return err
+	}
+	cacheFile := cachePath(configuration)
+	manifestFile := manifestPath(configuration)
+	if len(manifestFile) > 0 {
+		previousManifest, manifestErr := loadManifest(manifestFile)
+		if manifestErr == nil {
+			builder.PreviousManifest = previousManifest
+		}
+	}
+	base := Manifest{}
+	if len(builder.Only) > 0 {
+		base = builder.PreviousManifest
+	}
+	var checks *idCheckOutput
+	var checked OutputWriter = builder.Output
+	if builder.Check {
+		checks = newIdCheckOutput(builder.Output)
+		checked = checks
+	}
+	var validations *validationOutput
+	if builder.Validate {
+		validations = newValidationOutput(checked, !builder.Check, builder.Configuration)
+		checked = validations
+	}
+	output := newManifestOutput(checked, base)
+
+	if len(builder.Only) > 0 {
+		if configuration.OutputMode == OUTPUT_MODE_BOOK {
+			return errors.New("book output is always rebuilt as a whole, single files cannot be rebuilt")
+		}
+		if len(cacheFile) == 0 {
+			return errors.New("rebuilding single files needs a build cache, which archive output does not keep")
+		}
+		if builder.NoCache {
+			return errors.New("rebuilding single files needs the build cache, which -no-cache disables")
+		}
+		cache, err = loadCache(cacheFile)
+		if err != nil {
+			msg := fmt.Sprintf("rebuilding single files needs the build cache of a previous full build: %s", err)
+			return errors.New(msg)
+		}
+		for _, argument := range builder.Only {
+			var name string
+			name, err = resolvePageArgument(configuration, argument)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+	} else {
+		names, err = listPages(configuration, &builder.Summary)
+		if err != nil {
+			return err
+		}
+		if len(cacheFile) > 0 && !builder.NoCache {
+			var cacheErr error
+			cache, cacheErr = loadCache(cacheFile)
+			if cacheErr != nil {
+				cache = BuildCache{}
+			}
+		}
+	}
+	previous := cachedSources(cache)
+	rendered, err := collectPages(builder, names, previous)
+	if err == nil {
+		rendered, err = collectExtraPages(builder, rendered)
+	}
+	if err != nil {
+		return err
+	}
+	if builder.pagesFailed() && !builder.Check {
+		return finishSummary(builder, nil)
+	}
+	sources := rendered
+	if len(builder.Only) > 0 {
+		sources = mergeSources(cache.Sources, rendered)
+	}
+	dropped, err := checkOutputs(builder, sources)
+	if err != nil {
+		return err
+	}
+	if builder.pagesFailed() && !builder.Check {
+		return finishSummary(builder, nil)
+	}
+	rendered = withoutSources(rendered, dropped)
+	sources = withoutSources(sources, dropped)
+	bundled, err := bundleAssets(configuration, sources)
+	if err != nil {
+		msg := fmt.Sprintf("bundle error: %s", err)
+		return errors.New(msg)
+	}
+	tagNames := tagDisplayNames(sources, configuration)
+	applyTagNames(sources, tagNames, configuration)
+	applyTagNames(rendered, tagNames, configuration)
+	fingerprint, fingerprintErr := buildFingerprint(configuration)
+	if fingerprintErr != nil {
+		fingerprint = ""
+	}
+	_, isFileOutput := builder.Output.(*fileOutput)
+	reuse := len(builder.Only) == 0 && !builder.DryRun && !builder.Check && !builder.ValidateAll && isFileOutput && len(builder.afterRender) == 0 &&
+		len(fingerprint) > 0 && fingerprint == cache.Fingerprint && sameLinks(previous, sources)
+	if len(builder.Only) > 0 {
+		fingerprint = ""
+		if sameLinks(previous, sources) {
+			fingerprint = cache.Fingerprint
+		}
+	}
+	var published []Link
+	content.Links, published = sourceLinks(sources)
+	if len(published) == 0 && len(builder.Only) == 0 {
+		keep, emptyErr := emptySite(configuration)
+		if emptyErr != nil || keep {
+			return finishSummary(builder, emptyErr)
+		}
+	}
+	site := buildSite(sources, configuration)
+	site.embeds.report = builder.report
+	site.abbreviations = abbreviations
+	buildTime, err := builder.buildTime()
+	if err != nil {
+		return err
+	}
+	for index := range rendered {
+		rendered[index].Page.Taxonomies = pageTaxonomies(rendered[index], site)
+		rendered[index].Page.Translations = site.translations[rendered[index].Link.Url]
+	}
+	site.Generator = Generator()
+	site.BuildTime = buildTime.Format(time.RFC3339)
+	stampUpdated(rendered, buildTime)
+	stampUpdated(sources, buildTime)
+
+	if len(manifestFile) > 0 && !builder.DryRun {
+		err = removeStaleManifest(manifestFile)
+		if err != nil {
+			msg := fmt.Sprintf("manifest error: %s", err)
+			return errors.New(msg)
+		}
+	}
+	if configuration.OutputMode == OUTPUT_MODE_BOOK {
+		err = renderBook(builder, output, sources, site)
+		if err == nil {
+			checkRemainingIds(builder, checks)
+			checkValidation(builder, validations)
+			checkSizes(builder, output, sources)
+			err = cleanOutput(builder, output)
+		}
+		if err == nil {
+			err = finishOutput(builder, output)
+		}
+		return finishSummary(builder, err)
+	}
+	var images *imageProcessor
+	if configuration.Images != nil {
+		images = newImageProcessor(configuration, output, isFileOutput && !builder.DryRun, cache.Images, builder.warnings, bundled)
+	}
+	outputs := knownOutputs(configuration, sources)
+	copies, err := startAssetCopies(builder, output, outputs, bundled, isFileOutput && !builder.DryRun)
+	if err != nil {
+		msg := fmt.Sprintf("asset copy error: %s", err)
+		return errors.New(msg)
+	}
+	for _, asset := range bundled {
+		outputs[asset.output] = true
+	}
+	logs := newLogCoordinator(builder.OrderedLogs)
+	failed := map[string]bool{}
+	relink := false
+	entries := make([]*pageLog, len(rendered))
+	reused := make([]bool, len(rendered))
+	var jobs []pageJob
+	for index, source := range rendered {
+		entries[index] = logs.page(index)
+		reused[index] = source.Stale || (reuse && source.Unchanged && outputsExist(configuration, source) && sameEmbeds(source, site.embeds.sources))
+		if !reused[index] {
+			jobs = append(jobs, pageJob{index: index, size: source.Size})
+		}
+	}
+	results := make([]markdownReferences, len(rendered))
+	errs := make([]error, len(rendered))
+	renders := startPageRenders(pageWorkers(configuration), newByteBudget(maxInFlightBytes(configuration)), jobs, func(index int) {
+		entries[index].Print("processing: ", rendered[index].Path)
+		results[index], errs[index] = renderSource(builder, entries[index], output, rendered[index], site.worker(), images)
+	})
+	var order []int
+	for index := range rendered {
+		if builder.OrderedLogs || reused[index] {
+			order = append(order, index)
+		}
+	}
+	for position := range rendered {
+		index := position
+		if position < len(order) {
+			index = order[position]
+		} else {
+			index = renders.next()
+		}
+		source := &rendered[index]
+		entry := entries[index]
+		if source.Stale {
+			entry.Printf("stale: %s is served from the previous build", source.Path)
+			entry.flush()
+			continue
+		}
+		if reused[index] {
+			builder.Summary.Unchanged++
+			if images != nil {
+				images.refresh(*source)
+			}
+		} else {
+			renders.wait(index)
+			references := results[index]
+			err = errs[index]
+			if err != nil {
+				entry.flush()
+				kept := builder.failPage(nil, FAILURE_RENDER, source.Path, source.Name, previous, err)
+				if len(kept) > 0 {
+					*source = kept[0]
+				} else if failedPagesPolicy(configuration) == FAILED_PAGES_EXCLUDE {
+					builder.excluded = append(builder.excluded, *source)
+					failed[source.Name] = true
+				}
+				relink = relink || len(kept) > 0 || failed[source.Name]
+				err = nil
+				continue
+			}
+			source.Links = references.Links
+			source.Assets = references.Images
+			source.Headings = references.Headings
+			source.Words = references.Words
+			source.Page.Content = references.Content
+			source.Media = references.Media
+			source.Embeds = site.embeds.dependencies(*source)
+			builder.Summary.Rendered++
+			checkIds(builder, entry, checks, source.OutputPath)
+		}
+		checkReferences(builder, entry, *source, outputs)
+		checkMedia(builder, entry, *source)
+		checkOutline(builder, entry, *source)
+		entry.flush()
+	}
+	copies.wait(builder)
+	sort.Strings(builder.Summary.Stale)
+	if builder.pagesFailed() {
+		return finishSummary(builder, nil)
+	}
+	rendered = withoutSources(rendered, failed)
+	sources = rendered
+	if len(builder.Only) > 0 {
+		sources = withoutSources(withoutSources(mergeSources(cache.Sources, rendered), dropped), failed)
+	}
+	if relink {
+		content.Links, _ = sourceLinks(sources)
+		generator, built := site.Generator, site.BuildTime
+		site = buildSite(sources, configuration)
+		site.embeds.report = builder.report
+		site.abbreviations = abbreviations
+		site.Generator, site.BuildTime = generator, built
+	}
+
+	content.Groups = groupLinks(content.Links, configuration.GroupOrder, configuration.DefaultGroup)
+	content.Site = site
+	content.Recent = site.Recent
+	content.Total = site.Total
+	content.Oldest = site.Oldest
+	content.Newest = site.Newest
+	content.Calendar = site.Calendar
+	content.ChangedPages = changedPages(sources)
+	content.Menu = resolveMenu(configuration.Menu, INDEX_URL, site)
+	err = builder.runBeforeIndex(&content)
+	if err == nil && configuration.IndexShards != nil {
+		err = renderShards(output, content, configuration)
+	} else if err == nil {
+		err = doIndex(
+			output,
+			INDEX_FILE_NAME,
+			configuration.TemplateIndex,
+			content,
+			configuration,
+		)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("index render error: %s", err)
+		return errors.New(msg)
+	}
+	err = renderExtras(configuration, output, indexedLinks(sources), site)
+	if err == nil {
+		err = renderExtraOutputs(configuration, output, content)
+	}
+	if err == nil {
+		err = renderFeeds(configuration, output, sources, site)
+	}
+	var iconsHash string
+	if err == nil {
+		iconsHash, err = renderIcons(configuration, output, isFileOutput && !builder.DryRun, cache.IconsHash)
+	}
+	checkValidation(builder, validations)
+	checkSizes(builder, output, sources)
+	if err == nil && configuration.ContentStats {
+		var data []byte
+		stats := buildContentStats(sources, buildTime.Format(DATE_DISPLAY_FORMAT), collatorFor(configuration))
+		stats.Validation = validationCounts(builder.Summary.Conditions)
+		stats.Oversized = builder.Summary.Oversized
+		stats.Warnings = builder.warnings.all()
+		stats.Failures = sortFailures(append([]*FileError(nil), builder.failures...))
+		builder.stats = &stats
+		data, err = renderContentStats(stats)
+		if err == nil {
+			err = writeFile(output, CONTENT_STATS_FILE_NAME, data)
+		}
+	}
+	if err == nil && configuration.MediaIndex {
+		var data []byte
+		data, err = renderMediaIndex(buildMediaIndex(sources))
+		if err == nil {
+			err = writeFile(output, MEDIA_FILE_NAME, data)
+		}
+	}
+	if err == nil && configuration.SearchIndex {
+		var entries []SearchEntry
+		entries, err = buildSearchIndex(configuration, sources, site)
+		if err == nil {
+			var data []byte
+			data, err = renderSearchIndex(entries)
+			if err == nil {
+				err = writeFile(output, SEARCH_FILE_NAME, data)
+			}
+		}
+	}
+	if err != nil {
+		msg := fmt.Sprintf("extra output error: %s", err)
+		return errors.New(msg)
+	}
+	checkRemainingIds(builder, checks)
+	err = removeExcluded(builder, sources)
+	if err == nil && isFileOutput && !builder.DryRun {
+		var paths []string
+		for _, source := range sources {
+			paths = append(paths, variantPaths(source)...)
+		}
+		for _, path := range paths {
+			if _, written := output.manifest.Files[path]; !written {
+				err = output.addExisting(configuration.Output, path)
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	markAuxiliary(output, site.generated)
+	if err == nil {
+		err = cleanOutput(builder, output)
+	}
+	if err == nil {
+		err = finishOutput(builder, output)
+	}
+	if err != nil {
+		return err
+	}
+	if len(cacheFile) > 0 && !builder.DryRun && !builder.NoCache {
+		var imageSets map[string]ImageSet
+		if images != nil {
+			imageSets = images.cache(len(builder.Only) > 0)
+		}
+		err = saveCache(cacheFile, BuildCache{Fingerprint: fingerprint, Sources: fileSources(sources), Images: imageSets, IconsHash: iconsHash})
+		if err != nil {
+			msg := fmt.Sprintf("build cache error: %s", err)
+			err = errors.New(msg)
+		}
+	}
+	return finishSummary(builder, err)
+}
+
+func sourceLinks(sources []Source) ([]Link, []Link) {
+	var listed []Link
+	var published []Link
+	for _, source := range sources {
+		if isListed(source) {
+			listed = append(listed, source.Link)
+		}
+		if isPublic(source) {
+			published = append(published, source.Link)
+		}
+	}
+	return listed, published
+}
+
+func finishOutput(builder *Builder, output *manifestOutput) error {
+	err := output.write()
+	if err != nil {
+		msg := fmt.Sprintf("manifest error: %s", err)
+		return errors.New(msg)
+	}
+	builder.Manifest = output.manifest
+	err = output.Finish()
+	if err != nil {
+		msg := fmt.Sprintf("output finish error: %s", err)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func finishSummary(builder *Builder, err error) error {
+	builder.warnings.flush()
+	logSummary(builder.Summary)
+	return err
+}
+
+func renderNotFound(configuration Configuration, output OutputWriter, site *Site) error {
+	var err error
+	var page Page
+	sourcePath := fmt.Sprintf("%s/%s", configuration.Input, NOT_FOUND_SOURCE_NAME)
+	hasSource := CheckPathError(sourcePath) == nil
+	if hasSource || len(configuration.Template404) > 0 {
+		templatePath := configuration.Template404
+		if len(templatePath) == 0 {
+			templatePath = configuration.TemplatePage
+		}
+		if hasSource {
+			log.Print("processing: ", sourcePath)
+			page, err = renderFile(sourcePath, configuration, site.abbreviations)
+			if err == nil {
+				applySource(&page, NOT_FOUND_SOURCE_NAME, configuration)
+			}
+		} else {
+			page = Page{Title: NOT_FOUND_TITLE}
+		}
+		if err == nil {
+			page.Site = site
+			page.Menu = resolveMenu(configuration.Menu, "/"+NOT_FOUND_FILE_NAME, site)
+			err = doTemplating(output, NOT_FOUND_FILE_NAME, templatePath, page, configuration)
+		}
+	}
+	return err
+}
+
+func renderExtras(configuration Configuration, output OutputWriter, links []Link, site *Site) error {
+	err := renderNotFound(configuration, output, site)
+	if err == nil {
+		err = renderTerms(configuration, output, site)
+	}
+	if err == nil {
+		err = renderAZ(configuration, output, site)
+	}
+	if err == nil && len(configuration.BaseURL) > 0 {
+		var data []byte
+		data, err = renderSitemap(configuration.BaseURL, sitemapLinks(configuration, links, site.generated), site.translations)
+		if err == nil {
+			err = writeFile(output, SITEMAP_FILE_NAME, data)
+		}
+	}
+	if err == nil && len(configuration.Calendar) > 0 {
+		var data []byte
+		data, err = renderCalendar(site.Calendar)
+		if err == nil {
+			err = writeFile(output, CALENDAR_FILE_NAME, data)
+		}
+	}
+	if err == nil && configuration.Robots != nil {
+		err = writeFile(output, ROBOTS_FILE_NAME, renderRobots(*configuration.Robots, configuration.BaseURL))
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const ROBOTS_FILE_NAME = "robots.txt"
+const NOT_FOUND_FILE_NAME = "404.html"
+const NOT_FOUND_SOURCE_NAME = "404.md"
+const NOT_FOUND_TITLE = "Page not found"
+const DEFAULT_USER_AGENT = "*"
+
+type Robots struct {
+	UserAgent string
+	Allow     []string
+	Disallow  []string
+}
+
+func renderRobots(robots Robots, baseUrl string) []byte {
+	var builder strings.Builder
+	userAgent := robots.UserAgent
+	if len(userAgent) == 0 {
+		userAgent = DEFAULT_USER_AGENT
+	}
+	fmt.Fprintf(&builder, "User-agent: %s\n", userAgent)
+	for _, path := range robots.Allow {
+		fmt.Fprintf(&builder, "Allow: %s\n", path)
+	}
+	for _, path := range robots.Disallow {
+		fmt.Fprintf(&builder, "Disallow: %s\n", path)
+	}
+	if len(robots.Allow) == 0 && len(robots.Disallow) == 0 {
+		builder.WriteString("Disallow:\n")
+	}
+	if len(baseUrl) > 0 {
+		fmt.Fprintf(&builder, "\nSitemap: %s\n", absoluteUrl(baseUrl, SITEMAP_FILE_NAME))
+	}
+	return []byte(builder.String())
+}
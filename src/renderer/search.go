@@ -0,0 +1,54 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const SEARCH_FILE_NAME = "search.json"
+
+type SearchEntry struct {
+	Title   string
+	Url     string
+	Date    string   `json:",omitempty"`
+	Tags    []string `json:",omitempty"`
+	Summary string   `json:",omitempty"`
+	Text    string
+}
+
+func buildSearchIndex(configuration Configuration, sources []Source, site *Site) ([]SearchEntry, error) {
+	entries := []SearchEntry{}
+	for _, source := range sources {
+		if !isPublic(source) || source.Stale {
+			continue
+		}
+		content, err := sourceContent(configuration, source, site)
+		if err != nil {
+			msg := fmt.Sprintf("%s: %s", source.Path, err)
+			return nil, errors.New(msg)
+		}
+		entry := SearchEntry{
+			Title:   source.Link.Title,
+			Url:     source.Link.Url,
+			Tags:    source.Link.Tags,
+			Summary: source.Link.Summary,
+			Text:    StripHTML(content),
+		}
+		if len(source.Link.Date) > 0 && source.Link.Date != ZERO_DATE {
+			entry.Date = source.Link.Date
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func renderSearchIndex(entries []SearchEntry) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(entries)
+	return buffer.Bytes(), err
+}
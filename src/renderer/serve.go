@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"bytes"
+	htmlTemplate "html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const SERVE_ADDRESS = "localhost:8080"
+const WATCH_INTERVAL = 500 * time.Millisecond
+const ERROR_OVERLAY_ID = "mdbuild-error"
+
+var ERROR_OVERLAY = htmlTemplate.Must(htmlTemplate.New("overlay").Parse(`<div id="` + ERROR_OVERLAY_ID + `" style="position:fixed;top:0;left:0;right:0;z-index:2147483647;max-height:60vh;overflow:auto;margin:0;padding:1em;background:#b00020;color:#fff;font:14px/1.5 monospace;white-space:pre-wrap">` +
+	`<strong>Build failed{{if .File}} in {{.File}}{{if .Line}} line {{.Line}}{{end}}{{end}}</strong>
+{{.Error}}
+<em>{{if .Page}}This is the output of the last good build.{{else}}The last good build has no page here.{{end}} It reloads once a build succeeds.</em></div>` +
+	`<script>setInterval(function(){fetch(location.href,{cache:"no-store"}).then(function(r){if(r.ok)location.reload()})},1000)</script>`))
+
+type watchedFile struct {
+	size     int64
+	modified time.Time
+}
+
+type Server struct {
+	builder *Builder
+	files   http.Handler
+	mutex   sync.Mutex
+	watched map[string]watchedFile
+}
+
+type errorOverlay struct {
+	BuildStatus
+	Page bool
+}
+
+func NewServer(builder *Builder) *Server {
+	server := &Server{builder: builder, files: http.FileServer(http.Dir(builder.Configuration.Output))}
+	server.watched = watchSnapshot(builder.Configuration)
+	return server
+}
+
+func watchSnapshot(configuration Configuration) map[string]watchedFile {
+	files := map[string]watchedFile{}
+	output := filepath.Clean(configuration.Output)
+	cache := filepath.Clean(cachePath(configuration))
+	for _, field := range configPathFields(&configuration) {
+		root := *field.value
+		if field.name == "Output" || field.name == "CacheFile" || len(root) == 0 {
+			continue
+		}
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			cleaned := filepath.Clean(path)
+			if info.IsDir() && cleaned == output {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && cleaned != cache {
+				files[cleaned] = watchedFile{size: info.Size(), modified: info.ModTime()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func (server *Server) changed() bool {
+	current := watchSnapshot(server.builder.Configuration)
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	changed := len(current) != len(server.watched)
+	for path, file := range current {
+		if !changed {
+			previous, found := server.watched[path]
+			changed = !found || previous.size != file.size || !previous.modified.Equal(file.modified)
+		}
+	}
+	server.watched = current
+	return changed
+}
+
+func (server *Server) Rebuild() error {
+	err := server.builder.Build()
+	if err != nil {
+		log.Print("render error: ", err)
+	} else {
+		log.Print("serve: build succeeded")
+	}
+	return err
+}
+
+func (server *Server) Watch(interval time.Duration, stop <-chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if server.changed() {
+				log.Print("serve: change detected, rebuilding")
+				server.Rebuild()
+			}
+		}
+	}
+}
+
+func pageRequest(requestPath string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+requestPath), "/")
+	if strings.HasSuffix(requestPath, "/") || len(name) == 0 {
+		return path.Join(name, INDEX_FILE_NAME), true
+	}
+	extension := path.Ext(name)
+	return name, extension == ".html" || extension == ".htm"
+}
+
+func withOverlay(page []byte, overlay []byte) []byte {
+	lower := bytes.ToLower(page)
+	bodyStart := bytes.Index(lower, []byte("<body"))
+	if bodyStart == -1 {
+		return append(overlay, page...)
+	}
+	bodyEnd := bytes.IndexByte(lower[bodyStart:], '>')
+	if bodyEnd == -1 {
+		return append(overlay, page...)
+	}
+	insert := bodyStart + bodyEnd + 1
+	html := append([]byte(nil), page[:insert]...)
+	html = append(html, overlay...)
+	return append(html, page[insert:]...)
+}
+
+func (server *Server) serveOverlay(writer http.ResponseWriter, name string, status BuildStatus) {
+	page, err := ioutil.ReadFile(filepath.Join(server.builder.Configuration.Output, filepath.FromSlash(name)))
+	var overlay bytes.Buffer
+	ERROR_OVERLAY.Execute(&overlay, errorOverlay{BuildStatus: status, Page: err == nil})
+	if err != nil {
+		page = []byte("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Build failed</title></head><body></body></html>\n")
+	}
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(http.StatusInternalServerError)
+	writer.Write(withOverlay(page, overlay.Bytes()))
+}
+
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	status := server.builder.Status()
+	name, isPage := pageRequest(request.URL.Path)
+	if len(status.Error) > 0 && isPage {
+		server.serveOverlay(writer, name, status)
+		return
+	}
+	writer.Header().Set("Cache-Control", "no-store")
+	server.files.ServeHTTP(writer, request)
+}
@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, server *Server, path string) (int, string) {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	body, err := ioutil.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, string(body)
+}
+
+func TestServeTemplateErrors(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.CopyAssets = true
+	site.page("a.md", `{"Title": "A"}`, "Good body\n")
+	site.write("style.css", "body {}\n")
+	server := NewServer(NewBuilder(site.Configuration))
+	if err := server.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name     string
+		template string
+		path     string
+		code     int
+		contains []string
+		missing  []string
+	}{
+		{"good build", TEST_PAGE_TEMPLATE, "/a.html", http.StatusOK, []string{"Good body"}, []string{ERROR_OVERLAY_ID}},
+		{"parse error", "<html><body>\n{{.Content}\n</body></html>\n", "/a.html", http.StatusInternalServerError,
+			[]string{ERROR_OVERLAY_ID, "Build failed in page.html line 2", "bad character U&#43;007D", "Good body", "last good build"}, nil},
+		{"execute error", "<html><body>{{.Content}}{{.Missing.Field}}</body></html>\n", "/a.html", http.StatusInternalServerError,
+			[]string{ERROR_OVERLAY_ID, "Build failed in page.html line 1", "Good body"}, nil},
+		{"index of a failed build", "<html><body>{{.Content}\n</body></html>\n", "/", http.StatusInternalServerError,
+			[]string{ERROR_OVERLAY_ID, "<a href=\"/a.html\">A</a>"}, nil},
+		{"page that was never built", "<html><body>{{.Content}\n</body></html>\n", "/b.html", http.StatusInternalServerError,
+			[]string{ERROR_OVERLAY_ID, "no page here"}, []string{"Good body"}},
+		{"assets are still served", "<html><body>{{.Content}\n</body></html>\n", "/style.css", http.StatusOK,
+			[]string{"body {}"}, []string{ERROR_OVERLAY_ID}},
+		{"fixed template", "<html><body>Fixed {{.Content}}</body></html>\n", "/a.html", http.StatusOK,
+			[]string{"Fixed <p>Good body</p>"}, []string{ERROR_OVERLAY_ID}},
+	}
+	for _, step := range steps {
+		site.writeFile(site.Configuration.TemplatePage, step.template)
+		server.Rebuild()
+		code, body := get(t, server, step.path)
+		if code != step.code {
+			t.Errorf("%s: %s answered %d, want %d", step.name, step.path, code, step.code)
+		}
+		for _, text := range step.contains {
+			if !strings.Contains(body, text) {
+				t.Errorf("%s: %s = %q, want %q", step.name, step.path, body, text)
+			}
+		}
+		for _, text := range step.missing {
+			if strings.Contains(body, text) {
+				t.Errorf("%s: %s = %q, contains %q", step.name, step.path, body, text)
+			}
+		}
+	}
+}
+
+func TestWithOverlay(t *testing.T) {
+	tests := []struct {
+		page string
+		want string
+	}{
+		{"<html><body>page</body></html>", "<html><body>OVERLAYpage</body></html>"},
+		{"<HTML><BODY class=\"post\">page</BODY></HTML>", "<HTML><BODY class=\"post\">OVERLAYpage</BODY></HTML>"},
+		{"page without a body", "OVERLAYpage without a body"},
+		{"<body", "OVERLAY<body"},
+	}
+	for _, test := range tests {
+		if got := string(withOverlay([]byte(test.page), []byte("OVERLAY"))); got != test.want {
+			t.Errorf("withOverlay(%q) = %q, want %q", test.page, got, test.want)
+		}
+	}
+}
+
+func TestPageRequest(t *testing.T) {
+	tests := []struct {
+		path   string
+		name   string
+		isPage bool
+	}{
+		{"/", INDEX_FILE_NAME, true},
+		{"", INDEX_FILE_NAME, true},
+		{"/posts/", "posts/" + INDEX_FILE_NAME, true},
+		{"/posts/a.html", "posts/a.html", true},
+		{"/old.htm", "old.htm", true},
+		{"/../../etc/passwd.html", "etc/passwd.html", true},
+		{"/style.css", "style.css", false},
+		{"/feed.xml", "feed.xml", false},
+	}
+	for _, test := range tests {
+		name, isPage := pageRequest(test.path)
+		if name != test.name || isPage != test.isPage {
+			t.Errorf("pageRequest(%q) = %q, %v, want %q, %v", test.path, name, isPage, test.name, test.isPage)
+		}
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	site := newTestSite(t)
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	server := NewServer(NewBuilder(site.Configuration))
+	later := time.Now().Add(time.Hour)
+	steps := []struct {
+		name    string
+		change  func()
+		changed bool
+	}{
+		{"nothing", func() {}, false},
+		{"build output", func() { server.Rebuild() }, false},
+		{"new page", func() { site.page("b.md", `{"Title": "B"}`, "body\n") }, true},
+		{"edited page", func() { site.page("b.md", `{"Title": "B"}`, "new body\n") }, true},
+		{"touched template", func() { os.Chtimes(site.Configuration.TemplatePage, later, later) }, true},
+		{"removed page", func() { site.remove("b.md") }, true},
+		{"nothing again", func() {}, false},
+	}
+	for _, step := range steps {
+		step.change()
+		if changed := server.changed(); changed != step.changed {
+			t.Errorf("%s: changed = %v, want %v", step.name, changed, step.changed)
+		}
+	}
+}
+
+func TestWatchRebuilds(t *testing.T) {
+	site := newTestSite(t)
+	site.page("a.md", `{"Title": "A"}`, "first\n")
+	server := NewServer(NewBuilder(site.Configuration))
+	server.Rebuild()
+	stop := make(chan bool)
+	stopped := make(chan bool)
+	go func() {
+		server.Watch(10*time.Millisecond, stop)
+		close(stopped)
+	}()
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+	site.page("a.md", `{"Title": "A"}`, "second\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, body := get(t, server, "/a.html"); strings.Contains(body, "second") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("a.html was not rebuilt after a.md changed")
+}
@@ -0,0 +1,166 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+)
+
+const DEFAULT_SHARD_DIRECTORY = "index"
+const SHARD_BY_TITLE = "title"
+const SHARD_BY_YEAR = "year"
+const SHARD_BY_MONTH = "month"
+const UNDATED_SHARD = "undated"
+
+type IndexShards struct {
+	By        string
+	Directory string
+}
+
+type IndexShard struct {
+	Name   string
+	Slug   string
+	Url    string
+	Total  int
+	Oldest string
+	Newest string
+
+	links []Link
+}
+
+func indexShardOptions(configuration Configuration) IndexShards {
+	options := *configuration.IndexShards
+	if len(options.Directory) == 0 {
+		options.Directory = DEFAULT_SHARD_DIRECTORY
+	}
+	return options
+}
+
+func checkIndexShards(configuration Configuration) error {
+	if configuration.IndexShards == nil {
+		return nil
+	}
+	if configuration.OutputMode == OUTPUT_MODE_BOOK {
+		return errors.New("the index is not sharded in book output mode")
+	}
+	options := indexShardOptions(configuration)
+	switch options.By {
+	case SHARD_BY_TITLE, SHARD_BY_YEAR, SHARD_BY_MONTH:
+	default:
+		msg := fmt.Sprintf("IndexShards By must be '%s', '%s' or '%s', not '%s'", SHARD_BY_TITLE, SHARD_BY_YEAR, SHARD_BY_MONTH, options.By)
+		return errors.New(msg)
+	}
+	_, err := metaOutputPath(options.Directory + "/")
+	if err != nil {
+		msg := fmt.Sprintf("IndexShards Directory: %s", err)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func shardPath(options IndexShards, slug string) string {
+	return path.Join(options.Directory, slug+".html")
+}
+
+func dateShards(links []Link, length int) []IndexShard {
+	positions := map[string]int{}
+	var shards []IndexShard
+	for _, link := range links {
+		name := UNDATED_SHARD
+		if len(link.Date) >= length && link.Date != ZERO_DATE {
+			name = link.Date[:length]
+		}
+		position, found := positions[name]
+		if !found {
+			position = len(shards)
+			positions[name] = position
+			shards = append(shards, IndexShard{Name: name, Slug: name})
+		}
+		shards[position].links = append(shards[position].links, link)
+	}
+	sort.SliceStable(shards, func(i, j int) bool {
+		if shards[i].Name == UNDATED_SHARD || shards[j].Name == UNDATED_SHARD {
+			return shards[j].Name == UNDATED_SHARD && shards[i].Name != UNDATED_SHARD
+		}
+		return shards[i].Name > shards[j].Name
+	})
+	return shards
+}
+
+func indexShards(configuration Configuration, links []Link) []IndexShard {
+	if configuration.IndexShards == nil {
+		return nil
+	}
+	options := indexShardOptions(configuration)
+	var shards []IndexShard
+	switch options.By {
+	case SHARD_BY_TITLE:
+		for _, letter := range initialBuckets(configuration, links, AZ_NON_LATIN_OTHER) {
+			shards = append(shards, IndexShard{Name: letter.Letter, Slug: letter.Slug, links: letter.Links})
+		}
+	case SHARD_BY_YEAR:
+		shards = dateShards(links, len("2006"))
+	case SHARD_BY_MONTH:
+		shards = dateShards(links, len("2006-01"))
+	}
+	for index := range shards {
+		shard := &shards[index]
+		shard.Url = outputUrl(shardPath(options, shard.Slug))
+		shard.Total = len(shard.links)
+		shard.Oldest, shard.Newest = dateRange(shard.links)
+	}
+	return shards
+}
+
+func shardOutputs(configuration Configuration, links []Link) []string {
+	if configuration.IndexShards == nil {
+		return nil
+	}
+	options := indexShardOptions(configuration)
+	var outputs []string
+	for _, shard := range indexShards(configuration, links) {
+		outputs = append(outputs, shardPath(options, shard.Slug))
+	}
+	return outputs
+}
+
+func renderShards(output OutputWriter, content Index, configuration Configuration) error {
+	options := indexShardOptions(configuration)
+	shards := indexShards(configuration, content.Links)
+	top := content
+	top.Links = nil
+	top.Groups = nil
+	top.Shards = shards
+	err := doIndex(output, INDEX_FILE_NAME, configuration.TemplateIndex, top, configuration)
+	for _, shard := range shards {
+		if err != nil {
+			break
+		}
+		shardOutput := shardPath(options, shard.Slug)
+		listing := content
+		listing.Links = shard.links
+		listing.Groups = groupLinks(shard.links, configuration.GroupOrder, configuration.DefaultGroup)
+		listing.Menu = resolveMenu(configuration.Menu, shard.Url, content.Site)
+		listing.Recent = recentLinks(shard.links, configuration.RecentCount)
+		listing.Total = shard.Total
+		listing.Oldest = shard.Oldest
+		listing.Newest = shard.Newest
+		listing.Shards = shards
+		listing.Shard = shard.Name
+		err = doIndex(output, shardOutput, configuration.TemplateIndex, listing, configuration)
+		if err != nil {
+			msg := fmt.Sprintf("index shard '%s': %s", shard.Name, err)
+			err = errors.New(msg)
+		} else if content.Site != nil {
+			content.Site.generated = append(content.Site.generated, GeneratedPage{
+				Path:    shardOutput,
+				Url:     shard.Url,
+				Owner:   fmt.Sprintf("the index shard '%s'", shard.Name),
+				Date:    shard.Newest,
+				Sitemap: true,
+			})
+		}
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package renderer
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIndexShards(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.BaseURL = "https://example.com/"
+	site.Configuration.IndexShards = &IndexShards{By: SHARD_BY_YEAR}
+	site.Configuration.Feed = &Feed{Title: "Feed", Formats: []string{FEED_FORMAT_JSON}}
+	site.writeFile(site.Configuration.TemplateIndex, "{{.Shard}}:{{range .Shards}}{{.Name}} {{.Total}},{{end}}:{{range .Links}}{{.Title}},{{end}}")
+	site.page("a.md", `{"Title": "A", "Date": "2024-05-02"}`, "body\n")
+	site.page("b.md", `{"Title": "B", "Date": "2023-01-10"}`, "body\n")
+	site.page("c.md", `{"Title": "C", "Date": "2024-01-01"}`, "body\n")
+	site.page("d.md", `{"Title": "D"}`, "body\n")
+	outputs := func() map[string]string {
+		files := map[string]string{}
+		for _, name := range []string{INDEX_FILE_NAME, "index/2024.html", "index/2023.html", "index/undated.html"} {
+			files[name] = site.read(name)
+		}
+		return files
+	}
+	site.mustBuild()
+	first := outputs()
+	want := map[string]string{
+		INDEX_FILE_NAME:      ":2024 2,2023 1,undated 1,:",
+		"index/2024.html":    "2024:2024 2,2023 1,undated 1,:A,C,",
+		"index/2023.html":    "2023:2024 2,2023 1,undated 1,:B,",
+		"index/undated.html": "undated:2024 2,2023 1,undated 1,:D,",
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("shards = %v, want %v", first, want)
+	}
+	site.mustBuild()
+	if second := outputs(); !reflect.DeepEqual(first, second) {
+		t.Errorf("second build = %v, want %v", second, first)
+	}
+	sitemap := site.read(SITEMAP_FILE_NAME)
+	for _, name := range []string{"index/2024.html", "index/2023.html", "index/undated.html"} {
+		if !strings.Contains(sitemap, "https://example.com/"+name) {
+			t.Errorf("sitemap = %q, want %s", sitemap, name)
+		}
+	}
+	var feed jsonFeed
+	err := json.Unmarshal([]byte(site.read(JSON_FEED_FILE_NAME)), &feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Items) != 4 {
+		t.Errorf("feed has %d items, want all 4 posts", len(feed.Items))
+	}
+	if site.exists("index/" + JSON_FEED_FILE_NAME) {
+		t.Error("the feed was written per shard")
+	}
+
+	site.remove("b.md")
+	builder := NewBuilder(site.Configuration)
+	builder.Clean = true
+	if err := builder.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(builder.Summary.Cleaned, []string{"b.html", "index/2023.html"}) {
+		t.Errorf("cleaned = %v, want b.html and the emptied shard", builder.Summary.Cleaned)
+	}
+	if site.exists("index/2023.html") {
+		t.Error("index/2023.html was not removed")
+	}
+	var shards []string
+	for name := range builder.Manifest.Files {
+		if strings.HasPrefix(name, "index/") {
+			shards = append(shards, name)
+		}
+	}
+	sort.Strings(shards)
+	if !reflect.DeepEqual(shards, []string{"index/2024.html", "index/undated.html"}) {
+		t.Errorf("manifest shards = %v, want index/2024.html and index/undated.html", shards)
+	}
+}
+
+func TestIndexShardOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		shards IndexShards
+		mode   string
+		err    string
+	}{
+		{"by title", IndexShards{By: SHARD_BY_TITLE}, "", ""},
+		{"by month in a directory", IndexShards{By: SHARD_BY_MONTH, Directory: "archive"}, "", ""},
+		{"unknown order", IndexShards{By: "author"}, "", "IndexShards By must be"},
+		{"directory outside the output", IndexShards{By: SHARD_BY_YEAR, Directory: "../up"}, "", "IndexShards Directory"},
+		{"book mode", IndexShards{By: SHARD_BY_YEAR}, OUTPUT_MODE_BOOK, "not sharded in book output mode"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := Configuration{IndexShards: &test.shards, OutputMode: test.mode}
+			err := checkIndexShards(configuration)
+			if len(test.err) == 0 && err != nil {
+				t.Fatalf("checkIndexShards = %v, want no error", err)
+			}
+			if len(test.err) > 0 && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("checkIndexShards = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const SHORTCODE_START = "{{<"
+const SHORTCODE_END = ">}}"
+const SHORTCODE_FILE_ENDING = ".html"
+const SHORTCODE_PLACEHOLDER = "SHORTCODE%dPLACEHOLDER"
+
+type Shortcode struct {
+	Name   string
+	Args   []string
+	Params map[string]string
+}
+
+func checkShortcodes(configuration Configuration) error {
+	if len(configuration.Shortcodes) == 0 {
+		return nil
+	}
+	return CheckPathError(configuration.Shortcodes)
+}
+
+func shortcodeFiles(configuration Configuration) ([]string, error) {
+	if len(configuration.Shortcodes) == 0 {
+		return nil, nil
+	}
+	return filepath.Glob(filepath.Join(configuration.Shortcodes, "*"+SHORTCODE_FILE_ENDING))
+}
+
+func fenceMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return ""
+	}
+	for _, character := range []string{"`", "~"} {
+		marker := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, character))]
+		if len(marker) >= 3 {
+			return marker
+		}
+	}
+	return ""
+}
+
+func shortcodeError(name string, line int, column int, detail string) error {
+	msg := fmt.Sprintf("shortcode error: %s:%d:%d: %s", name, line, column, detail)
+	return errors.New(msg)
+}
+
+type shortcodeArgument struct {
+	key   string
+	value string
+	named bool
+}
+
+func parseShortcodeArguments(text string) ([]shortcodeArgument, error) {
+	var arguments []shortcodeArgument
+	var current shortcodeArgument
+	var value strings.Builder
+	quoted := false
+	started := false
+	for index := 0; index < len(text); index++ {
+		character := text[index]
+		switch {
+		case quoted && character == '\\' && index+1 < len(text):
+			index++
+			value.WriteByte(text[index])
+		case character == '"':
+			quoted = !quoted
+			started = true
+		case !quoted && character == '=' && started && !current.named:
+			current.key = value.String()
+			current.named = true
+			value.Reset()
+		case !quoted && (character == ' ' || character == '\t'):
+			if started {
+				current.value = value.String()
+				arguments = append(arguments, current)
+				current = shortcodeArgument{}
+				value.Reset()
+				started = false
+			}
+		default:
+			value.WriteByte(character)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quoted argument")
+	}
+	if started {
+		current.value = value.String()
+		arguments = append(arguments, current)
+	}
+	return arguments, nil
+}
+
+func parseShortcode(text string) (Shortcode, error) {
+	shortcode := Shortcode{Params: map[string]string{}}
+	if strings.Contains(text, SHORTCODE_START) {
+		return shortcode, errors.New("nested shortcodes are not supported")
+	}
+	text = strings.TrimSpace(text)
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return shortcode, errors.New("shortcode has no name")
+	}
+	shortcode.Name = fields[0]
+	if strings.HasPrefix(shortcode.Name, "/") {
+		return shortcode, errors.New("closing shortcodes are not supported")
+	}
+	arguments, err := parseShortcodeArguments(text[len(shortcode.Name):])
+	for _, argument := range arguments {
+		if argument.named {
+			shortcode.Params[argument.key] = argument.value
+		} else {
+			shortcode.Args = append(shortcode.Args, argument.value)
+		}
+	}
+	return shortcode, err
+}
+
+func inlineCodeEnd(line string, position int) int {
+	offset := 0
+	for {
+		start := strings.IndexByte(line[offset:], '`')
+		if start == -1 || offset+start > position {
+			return -1
+		}
+		start += offset
+		run := len(line[start:]) - len(strings.TrimLeft(line[start:], "`"))
+		closing := strings.Index(line[start+run:], line[start:start+run])
+		if closing == -1 {
+			return -1
+		}
+		end := start + run + closing + run
+		if end > position {
+			return end
+		}
+		offset = end
+	}
+}
+
+func renderShortcode(shortcode Shortcode, configuration Configuration, site *Site) (string, error) {
+	templatePath := filepath.Join(configuration.Shortcodes, shortcode.Name+SHORTCODE_FILE_ENDING)
+	if strings.ContainsAny(shortcode.Name, `/\`) || len(configuration.Shortcodes) == 0 || CheckPathError(templatePath) != nil {
+		msg := fmt.Sprintf("unknown shortcode '%s'", shortcode.Name)
+		return "", errors.New(msg)
+	}
+	var buffer bytes.Buffer
+	templateObj := template.New(filepath.Base(templatePath)).Funcs(templateFuncs(configuration, site, ""))
+	templateObj, err := templateObj.ParseFiles(templatePath)
+	if err == nil {
+		err = templateObj.Execute(&buffer, shortcode)
+	}
+	return strings.TrimSpace(buffer.String()), err
+}
+
+func expandShortcodes(md []byte, configuration Configuration, site *Site, name string, firstLine int) ([]byte, []string, error) {
+	var snippets []string
+	if !bytes.Contains(md, []byte(SHORTCODE_START)) {
+		return md, snippets, nil
+	}
+	var result strings.Builder
+	fence := ""
+	lines := strings.SplitAfter(string(md), "\n")
+	for number, line := range lines {
+		marker := fenceMarker(line)
+		if len(fence) > 0 {
+			if strings.HasPrefix(marker, fence) && len(strings.TrimSpace(line)) == len(marker) {
+				fence = ""
+			}
+			result.WriteString(line)
+			continue
+		}
+		if len(marker) > 0 {
+			fence = marker
+			result.WriteString(line)
+			continue
+		}
+		offset := 0
+		for {
+			start := strings.Index(line[offset:], SHORTCODE_START)
+			if start == -1 {
+				result.WriteString(line[offset:])
+				break
+			}
+			start += offset
+			codeEnd := inlineCodeEnd(line, start)
+			if codeEnd != -1 {
+				result.WriteString(line[offset:codeEnd])
+				offset = codeEnd
+				continue
+			}
+			column := start + 1
+			end := strings.Index(line[start:], SHORTCODE_END)
+			if end == -1 {
+				return nil, nil, shortcodeError(name, firstLine+number, column, "shortcode is not closed with "+SHORTCODE_END+" on the same line")
+			}
+			end += start
+			shortcode, err := parseShortcode(line[start+len(SHORTCODE_START) : end])
+			var snippet string
+			if err == nil {
+				snippet, err = renderShortcode(shortcode, configuration, site)
+			}
+			if err != nil {
+				return nil, nil, shortcodeError(name, firstLine+number, column, err.Error())
+			}
+			result.WriteString(line[offset:start])
+			fmt.Fprintf(&result, SHORTCODE_PLACEHOLDER, len(snippets))
+			snippets = append(snippets, snippet)
+			offset = end + len(SHORTCODE_END)
+		}
+	}
+	return []byte(result.String()), snippets, nil
+}
+
+func restoreShortcodes(content string, snippets []string) string {
+	for index := range snippets {
+		placeholder := fmt.Sprintf(SHORTCODE_PLACEHOLDER, index)
+		content = strings.Replace(content, PARAGRAPH_START+placeholder+PARAGRAPH_END, snippets[index], -1)
+		content = strings.Replace(content, placeholder, snippets[index], -1)
+	}
+	return content
+}
+
+func shortcodeFingerprint(configuration Configuration) ([]byte, error) {
+	var data []byte
+	files, err := shortcodeFiles(configuration)
+	for _, file := range files {
+		var content []byte
+		content, err = ioutil.ReadFile(file)
+		if err != nil {
+			break
+		}
+		data = append(data, file...)
+		data = append(data, content...)
+	}
+	return data, err
+}
@@ -0,0 +1,246 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const DEFAULT_RECENT_COUNT = 10
+
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+type AuthorCount struct {
+	Author     Author
+	Count      int
+	LatestDate string
+	LatestUrl  string
+}
+
+type Site struct {
+	Recent  []Link
+	Tags    []TagCount
+	Authors []AuthorCount
+	Total   int
+	Oldest  string
+	Newest  string
+	Pages   []Link
+
+	Calendar map[string]int
+
+	Taxonomies map[string][]TaxonomyTerm
+
+	Generator string
+	BuildTime string
+	Title     string
+	BaseURL   string
+	BuildMeta map[string]string
+	Icons     []IconLink
+	Language  string
+
+	pageUrls      map[string]string
+	pageSlugs     map[string][]string
+	embeds        *embedTable
+	generated     []GeneratedPage
+	letters       []AZLetter
+	inlined       *inlineFiles
+	abbreviations map[string]string
+
+	translations map[string][]Translation
+}
+
+func recentLinks(links []Link, count int) []Link {
+	if count <= 0 {
+		count = DEFAULT_RECENT_COUNT
+	}
+	recent := make([]Link, len(links))
+	copy(recent, links)
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].Date > recent[j].Date
+	})
+	if len(recent) > count {
+		recent = recent[:count]
+	}
+	return recent
+}
+
+func countTags(links []Link, collator *titleCollator) []TagCount {
+	var tags []TagCount
+	positions := map[string]int{}
+	for _, link := range links {
+		for _, tag := range link.Tags {
+			position, found := positions[tag]
+			if !found {
+				position = len(tags)
+				positions[tag] = position
+				tags = append(tags, TagCount{Name: tag})
+			}
+			tags[position].Count++
+		}
+	}
+	names := collator.order()
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return names.compare(tags[i].Name, tags[j].Name) < 0
+	})
+	return tags
+}
+
+func countAuthors(links []Link, collator *titleCollator) []AuthorCount {
+	var authors []AuthorCount
+	positions := map[string]int{}
+	for _, link := range links {
+		for _, author := range link.Authors {
+			key := authorKey(author)
+			position, found := positions[key]
+			if !found {
+				position = len(authors)
+				positions[key] = position
+				authors = append(authors, AuthorCount{Author: author})
+			}
+			authors[position].Count++
+			if link.Date > authors[position].LatestDate {
+				authors[position].LatestDate = link.Date
+				authors[position].LatestUrl = link.Url
+			}
+		}
+	}
+	names := collator.order()
+	sort.SliceStable(authors, func(i, j int) bool {
+		if authors[i].Count != authors[j].Count {
+			return authors[i].Count > authors[j].Count
+		}
+		return names.compare(authors[i].Author.Name, authors[j].Author.Name) < 0
+	})
+	return authors
+}
+
+func dateRange(links []Link) (string, string) {
+	var oldest string
+	var newest string
+	for _, link := range links {
+		if len(link.Date) == 0 || link.Date == ZERO_DATE {
+			continue
+		}
+		if len(oldest) == 0 || link.Date < oldest {
+			oldest = link.Date
+		}
+		if link.Date > newest {
+			newest = link.Date
+		}
+	}
+	return oldest, newest
+}
+
+func buildSite(sources []Source, configuration Configuration) *Site {
+	var links []Link
+	pageUrls := map[string]string{}
+	pageSlugs := map[string][]string{}
+	var pages []Link
+	for _, source := range sources {
+		if isListed(source) {
+			links = append(links, source.Link)
+		} else if isPublic(source) {
+			pages = append(pages, source.Link)
+		}
+		pageUrls[source.Name] = source.Link.Url
+		slug := strings.TrimSuffix(source.OutputPath, ".html")
+		if _, bundled := bundleDirectory(configuration, source.Name); bundled && path.Base(slug) == "index" {
+			slug = path.Dir(slug)
+		}
+		pageSlugs[slug] = append(pageSlugs[slug], source.Name)
+		if base := path.Base(slug); base != slug {
+			pageSlugs[base] = append(pageSlugs[base], source.Name)
+		}
+	}
+	oldest, newest := dateRange(links)
+	built := buildTaxonomies(sources, configuration)
+	letters := azLetters(configuration, links)
+	return &Site{
+		Recent:    recentLinks(links, configuration.RecentCount),
+		Tags:      countTags(links, collatorFor(configuration)),
+		Authors:   countAuthors(links, collatorFor(configuration)),
+		Total:     len(links),
+		Oldest:    oldest,
+		Newest:    newest,
+		Pages:     pages,
+		Title:     configuration.Title,
+		BaseURL:   configuration.BaseURL,
+		Icons:     iconLinks(configuration),
+		BuildMeta: configuration.BuildMeta,
+		Calendar:  buildCalendar(links, configuration.Calendar),
+		pageUrls:  pageUrls,
+		pageSlugs: pageSlugs,
+		embeds:    newEmbedTable(sources, configuration),
+		generated: generatedPages(configuration, built, letters),
+		letters:   letters,
+		inlined:   newInlineFiles(),
+		Language:  configuration.Language,
+
+		translations: buildTranslations(configuration, sources),
+
+		Taxonomies: built,
+	}
+}
+
+func (site *Site) inlineFiles() *inlineFiles {
+	if site == nil {
+		return nil
+	}
+	return site.inlined
+}
+
+func (site *Site) worker() *Site {
+	worker := *site
+	worker.embeds = site.embeds.worker()
+	return &worker
+}
+
+func (site *Site) pageUrl(name string) string {
+	url, found := site.pageUrls[name]
+	if !found {
+		url = outputUrl(sourceOutputPath(name))
+	}
+	return url
+}
+
+func (site *Site) resolvePage(function string, reference string) (string, error) {
+	name, err := site.resolveName(function, reference)
+	if err != nil {
+		return "", err
+	}
+	return site.pageUrls[name], nil
+}
+
+func (site *Site) resolveName(function string, reference string) (string, error) {
+	if site == nil {
+		msg := fmt.Sprintf("%s '%s': pages are not known outside of a site build", function, reference)
+		return "", errors.New(msg)
+	}
+	name := strings.TrimPrefix(reference, "/")
+	if strings.HasSuffix(name, MARKDOWN_FILE_ENDING) {
+		if _, found := site.pageUrls[name]; found {
+			return name, nil
+		}
+	} else {
+		names := site.pageSlugs[strings.TrimSuffix(name, ".html")]
+		if len(names) == 1 {
+			return names[0], nil
+		}
+		if len(names) > 1 {
+			names = append([]string(nil), names...)
+			sort.Strings(names)
+			msg := fmt.Sprintf("%s '%s': the slug matches %s, use the source path", function, reference, strings.Join(names, ", "))
+			return "", errors.New(msg)
+		}
+	}
+	msg := fmt.Sprintf("%s '%s': no page has this source path or slug", function, reference)
+	return "", errors.New(msg)
+}
@@ -0,0 +1,59 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const SITEMAP_FILE_NAME = "sitemap.xml"
+const SITEMAP_NAMESPACE = "http://www.sitemaps.org/schemas/sitemap/0.9"
+const ZERO_DATE = "0001-01-01"
+
+type sitemapUrl struct {
+	Location     string             `xml:"loc"`
+	LastModified string             `xml:"lastmod,omitempty"`
+	Alternates   []sitemapAlternate `xml:",omitempty"`
+}
+
+type sitemapUrlSet struct {
+	XMLName        xml.Name     `xml:"urlset"`
+	Namespace      string       `xml:"xmlns,attr"`
+	XhtmlNamespace string       `xml:"xmlns:xhtml,attr,omitempty"`
+	Urls           []sitemapUrl `xml:"url"`
+}
+
+func absoluteUrl(baseUrl string, path string) string {
+	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func indexedLinks(sources []Source) []Link {
+	var links []Link
+	for _, source := range sources {
+		if isIndexed(source) {
+			links = append(links, source.Link)
+		}
+	}
+	return links
+}
+
+func renderSitemap(baseUrl string, links []Link, translations map[string][]Translation) ([]byte, error) {
+	urlSet := sitemapUrlSet{Namespace: SITEMAP_NAMESPACE}
+	urlSet.Urls = append(urlSet.Urls, sitemapUrl{Location: absoluteUrl(baseUrl, "/")})
+	for _, link := range links {
+		entry := sitemapUrl{Location: absoluteUrl(baseUrl, link.Url)}
+		if link.Date != ZERO_DATE {
+			entry.LastModified = link.Date
+		}
+		entry.Alternates = sitemapAlternates(baseUrl, translations[link.Url])
+		if len(entry.Alternates) > 0 {
+			urlSet.XhtmlNamespace = SITEMAP_XHTML_NAMESPACE
+		}
+		urlSet.Urls = append(urlSet.Urls, entry)
+	}
+	data, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err == nil {
+		data = append([]byte(xml.Header), data...)
+		data = append(data, '\n')
+	}
+	return data, err
+}
@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+)
+
+type OversizedOutput struct {
+	Path  string
+	Kind  string
+	Size  int64
+	Limit int64
+}
+
+func checkOutputSizes(configuration Configuration) error {
+	if configuration.MaxPageBytes < 0 {
+		msg := fmt.Sprintf("MaxPageBytes must not be negative, not %d", configuration.MaxPageBytes)
+		return errors.New(msg)
+	}
+	if configuration.MaxAssetBytes < 0 {
+		msg := fmt.Sprintf("MaxAssetBytes must not be negative, not %d", configuration.MaxAssetBytes)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func limitsSizes(configuration Configuration) bool {
+	return configuration.MaxPageBytes > 0 || configuration.MaxAssetBytes > 0
+}
+
+func pageSizeLimits(configuration Configuration, sources []Source) map[string]int64 {
+	limits := map[string]int64{}
+	for output := range knownOutputs(configuration, sources) {
+		limits[output] = configuration.MaxPageBytes
+	}
+	for _, source := range sources {
+		if source.Meta.MaxPageBytes > 0 {
+			for _, output := range variantPaths(source) {
+				limits[output] = source.Meta.MaxPageBytes
+			}
+		}
+	}
+	return limits
+}
+
+func oversizedOutputs(configuration Configuration, sizes map[string]int64, sources []Source) []OversizedOutput {
+	var oversized []OversizedOutput
+	limits := pageSizeLimits(configuration, sources)
+	for output, size := range sizes {
+		kind := "page"
+		limit, known := limits[output]
+		if !known && path.Ext(output) == ".html" {
+			limit, known = configuration.MaxPageBytes, true
+		}
+		if !known {
+			kind, limit = "asset", configuration.MaxAssetBytes
+		}
+		if limit > 0 && size > limit {
+			oversized = append(oversized, OversizedOutput{Path: output, Kind: kind, Size: size, Limit: limit})
+		}
+	}
+	sort.Slice(oversized, func(i, j int) bool {
+		return oversized[i].Path < oversized[j].Path
+	})
+	return oversized
+}
+
+func outputSizes(builder *Builder, output *manifestOutput, sources []Source) map[string]int64 {
+	sizes := map[string]int64{}
+	output.mutex.Lock()
+	for path, entry := range output.manifest.Files {
+		sizes[path] = entry.Size
+	}
+	output.mutex.Unlock()
+	for _, source := range sources {
+		for _, path := range variantPaths(source) {
+			if _, found := sizes[path]; found {
+				continue
+			}
+			if entry, found := builder.PreviousManifest.Files[path]; found {
+				sizes[path] = entry.Size
+			}
+		}
+	}
+	return sizes
+}
+
+func checkSizes(builder *Builder, output *manifestOutput, sources []Source) {
+	if !limitsSizes(builder.Configuration) {
+		return
+	}
+	oversized := oversizedOutputs(builder.Configuration, outputSizes(builder, output, sources), sources)
+	for _, output := range oversized {
+		detail := fmt.Sprintf("%s is %d bytes, over the limit of %d bytes", output.Kind, output.Size, output.Limit)
+		builder.report(CONDITION_OUTPUT_SIZE, output.Path, detail)
+	}
+	builder.Summary.Oversized = oversized
+}
@@ -0,0 +1,76 @@
+package renderer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+const SLUG_SEPARATOR = '-'
+const SLUG_FALLBACK_PREFIX = "n-"
+const SLUG_FALLBACK_LENGTH = 8
+const SLUG_DROPPED = "'’"
+
+var SLUG_TRANSLITERATIONS = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+	'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+	'ĥ': "h", 'ħ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'ĳ': "ij",
+	'ĵ': "j",
+	'ķ': "k",
+	'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'œ': "oe",
+	'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ș': "s",
+	'ß': "ss",
+	'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t",
+	'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ŵ': "w",
+	'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+func slugFallback(text string) string {
+	sum := sha1.Sum([]byte(text))
+	return SLUG_FALLBACK_PREFIX + hex.EncodeToString(sum[:])[:SLUG_FALLBACK_LENGTH]
+}
+
+func Slugify(text string) string {
+	var builder strings.Builder
+	pendingSeparator := false
+	previousLatin := false
+	for _, character := range strings.ToLower(normalizeName(text)) {
+		if (unicode.Is(unicode.Mn, character) && previousLatin) || strings.ContainsRune(SLUG_DROPPED, character) {
+			continue
+		}
+		replacement, transliterated := SLUG_TRANSLITERATIONS[character]
+		if !transliterated && (unicode.IsLetter(character) || unicode.IsDigit(character) || unicode.Is(unicode.Mn, character) || unicode.Is(unicode.Mc, character)) {
+			replacement = string(character)
+		}
+		if len(replacement) == 0 {
+			pendingSeparator = builder.Len() > 0
+			previousLatin = false
+			continue
+		}
+		if pendingSeparator {
+			builder.WriteRune(SLUG_SEPARATOR)
+			pendingSeparator = false
+		}
+		builder.WriteString(replacement)
+		previousLatin = transliterated || unicode.Is(unicode.Latin, character)
+	}
+	slug := builder.String()
+	if len(slug) == 0 {
+		slug = slugFallback(text)
+	}
+	return slug
+}
@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Hello,   World!  ", "hello-world"},
+		{"already-a-slug", "already-a-slug"},
+		{"snake_case_name", "snake-case-name"},
+		{"Go 1.16 Release", "go-1-16-release"},
+		{"--Leading and trailing--", "leading-and-trailing"},
+		{"Crème brûlée", "creme-brulee"},
+		{"Cre\u0300me bru\u0302le\u0301e", "creme-brulee"},
+		{"Straße", "strasse"},
+		{"Œuvre Æsthetics", "oeuvre-aesthetics"},
+		{"Łódź", "lodz"},
+		{"Don't stop", "dont-stop"},
+		{"Rock’n’roll", "rocknroll"},
+		{"日本語のタイトル", "日本語のタイトル"},
+		{"Go 言語 入門", "go-言語-入門"},
+		{"Привет мир", "привет-мир"},
+		{"Γειά σου", "γειά-σου"},
+		{"नमस्ते दुनिया", "नमस्ते-दुनिया"},
+		{"I ❤ Go 🚀", "i-go"},
+		{"C++ & C#", "c-c"},
+		{"tab\tand\nnewline", "tab-and-newline"},
+	}
+	for _, test := range tests {
+		got := Slugify(test.text)
+		if got != test.want {
+			t.Errorf("Slugify(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSlugifyFallback(t *testing.T) {
+	tests := []string{"", "   ", "!!!", "🚀🚀", "---", "’"}
+	seen := map[string]string{}
+	for _, text := range tests {
+		got := Slugify(text)
+		if !strings.HasPrefix(got, SLUG_FALLBACK_PREFIX) || len(got) != len(SLUG_FALLBACK_PREFIX)+SLUG_FALLBACK_LENGTH {
+			t.Errorf("Slugify(%q) = %q, want a %s fallback hash", text, got, SLUG_FALLBACK_PREFIX)
+		}
+		if got != Slugify(text) {
+			t.Errorf("Slugify(%q) is not stable", text)
+		}
+		if other, taken := seen[got]; taken {
+			t.Errorf("Slugify(%q) and Slugify(%q) share the fallback %q", text, other, got)
+		}
+		seen[got] = text
+	}
+}
+
+func TestSlugifyIsIdempotent(t *testing.T) {
+	for _, text := range []string{"Hello World", "Crème brûlée", "Go 言語 入門", "I ❤ Go 🚀"} {
+		slug := Slugify(text)
+		if again := Slugify(slug); again != slug {
+			t.Errorf("Slugify(%q) = %q, but Slugify(%q) = %q", text, slug, slug, again)
+		}
+	}
+}
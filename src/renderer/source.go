@@ -0,0 +1,170 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const EDIT_URL_PATH_PLACEHOLDER = "{path}"
+
+func editUrl(pattern string, sourcePath string) string {
+	if len(pattern) == 0 {
+		return ""
+	}
+	escaped := (&url.URL{Path: sourcePath}).EscapedPath()
+	return strings.ReplaceAll(pattern, EDIT_URL_PATH_PLACEHOLDER, escaped)
+}
+
+func applySource(page *Page, name string, configuration Configuration) {
+	page.SourcePath = name
+	page.EditURL = editUrl(configuration.EditURLPattern, name)
+}
+
+func outputUrl(outputPath string) string {
+	return (&url.URL{Path: "/" + outputPath}).EscapedPath()
+}
+
+func relativeUrl(outputPath string, target string) (string, error) {
+	if len(outputPath) == 0 {
+		msg := fmt.Sprintf("relURL '%s': the output location is not known here", target)
+		return "", errors.New(msg)
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.IsAbs() || len(parsed.Host) > 0 || strings.HasPrefix(target, "#") {
+		return target, nil
+	}
+	prefix := strings.Repeat("../", strings.Count(outputPath, "/"))
+	relative := prefix + strings.TrimPrefix(target, "/")
+	if len(relative) == 0 {
+		relative = "./"
+	}
+	return relative, nil
+}
+
+func externalUrl(target string) bool {
+	parsed, err := url.Parse(target)
+	return err == nil && (parsed.IsAbs() || len(parsed.Host) > 0 || strings.HasPrefix(target, "#"))
+}
+
+func joinUrlPath(function string, baseUrl string, target string) (*url.URL, error) {
+	base, err := url.Parse(baseUrl)
+	if err != nil {
+		msg := fmt.Sprintf("%s: BaseURL '%s' is not a URL: %s", function, baseUrl, err)
+		return nil, errors.New(msg)
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		msg := fmt.Sprintf("%s '%s': %s", function, target, err)
+		return nil, errors.New(msg)
+	}
+	joined := *base
+	escaped := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(parsed.EscapedPath(), "/")
+	joined.Path, _ = url.PathUnescape(escaped)
+	joined.RawPath = escaped
+	joined.RawQuery = parsed.RawQuery
+	joined.Fragment = parsed.Fragment
+	return &joined, nil
+}
+
+func absoluteSiteUrl(baseUrl string, target string) (string, error) {
+	if externalUrl(target) {
+		return target, nil
+	}
+	if len(baseUrl) == 0 {
+		msg := fmt.Sprintf("absURL '%s': no BaseURL is configured", target)
+		return "", errors.New(msg)
+	}
+	joined, err := joinUrlPath("absURL", baseUrl, target)
+	if err != nil {
+		return "", err
+	}
+	return joined.String(), nil
+}
+
+func rootRelativeUrl(baseUrl string, target string) (string, error) {
+	if externalUrl(target) {
+		return target, nil
+	}
+	joined, err := joinUrlPath("rootURL", baseUrl, target)
+	if err != nil {
+		return "", err
+	}
+	joined.Scheme = ""
+	joined.User = nil
+	joined.Host = ""
+	return joined.String(), nil
+}
+
+func slugOutputPath(outputPath string) string {
+	segments := strings.Split(strings.TrimSuffix(outputPath, ".html"), "/")
+	for index, segment := range segments {
+		if outputUrl(segment) != "/"+segment {
+			segments[index] = Slugify(segment)
+		}
+	}
+	return strings.Join(segments, "/") + ".html"
+}
+
+func metaOutputPath(value string) (string, error) {
+	var err error
+	outputPath := normalizeName(strings.TrimPrefix(value, "/"))
+	cleaned := path.Clean(outputPath)
+	if strings.Contains(value, "\\") {
+		msg := fmt.Sprintf("Path '%s' must use forward slashes", value)
+		err = errors.New(msg)
+	} else if strings.HasPrefix(value, "//") || len(filepath.VolumeName(value)) > 0 {
+		msg := fmt.Sprintf("Path '%s' must be relative to the output root", value)
+		err = errors.New(msg)
+	} else if cleaned == "." {
+		msg := fmt.Sprintf("Path '%s' does not name a file", value)
+		err = errors.New(msg)
+	} else if cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.Contains("/"+outputPath+"/", "/../") {
+		msg := fmt.Sprintf("Path '%s' leaves the output root", value)
+		err = errors.New(msg)
+	} else {
+		outputPath = cleaned
+		if strings.HasSuffix(value, "/") {
+			outputPath = path.Join(outputPath, INDEX_FILE_NAME)
+		} else if len(path.Ext(outputPath)) == 0 {
+			outputPath += ".html"
+		}
+	}
+	return outputPath, err
+}
+
+func checkSourceText(data []byte) error {
+	if bytes.IndexByte(data, 0) != -1 {
+		return errors.New("file contains NUL bytes, it looks like a binary file")
+	}
+	if !utf8.Valid(data) {
+		return errors.New("file is not valid UTF-8")
+	}
+	return nil
+}
+
+func checkReadable(path string) error {
+	file, err := os.Open(path)
+	if err == nil {
+		err = file.Close()
+	}
+	return err
+}
+
+func unreadableReason(err error) string {
+	if pathErr, isPathErr := err.(*os.PathError); isPathErr {
+		return fmt.Sprintf("file cannot be read: %s", pathErr.Err)
+	}
+	return err.Error()
+}
+
+func (builder *Builder) skipUnreadable(path string, err error) {
+	builder.Summary.Unreadable++
+	builder.report(CONDITION_UNREADABLE_FILE, path, unreadableReason(err)+", skipping it")
+}
@@ -0,0 +1,241 @@
+package renderer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSiteUrls(t *testing.T) {
+	tests := []struct {
+		baseUrl string
+		target  string
+		abs     string
+		root    string
+	}{
+		{"https://example.com", "posts/a.html", "https://example.com/posts/a.html", "/posts/a.html"},
+		{"https://example.com", "/posts/a.html", "https://example.com/posts/a.html", "/posts/a.html"},
+		{"https://example.com/", "posts/a.html", "https://example.com/posts/a.html", "/posts/a.html"},
+		{"https://example.com/", "/posts/a.html", "https://example.com/posts/a.html", "/posts/a.html"},
+		{"https://example.com/blog", "posts/a.html", "https://example.com/blog/posts/a.html", "/blog/posts/a.html"},
+		{"https://example.com/blog", "/posts/a.html", "https://example.com/blog/posts/a.html", "/blog/posts/a.html"},
+		{"https://example.com/blog/", "posts/a.html", "https://example.com/blog/posts/a.html", "/blog/posts/a.html"},
+		{"https://example.com/blog/", "/posts/a.html", "https://example.com/blog/posts/a.html", "/blog/posts/a.html"},
+		{"https://example.com/blog/", "", "https://example.com/blog/", "/blog/"},
+		{"https://example.com", "/", "https://example.com/", "/"},
+		{"", "posts/a.html", "", "/posts/a.html"},
+		{"https://example.com/blog/", "search.html?q=go&page=2", "https://example.com/blog/search.html?q=go&page=2", "/blog/search.html?q=go&page=2"},
+		{"https://example.com/blog/", "/posts/a.html#intro", "https://example.com/blog/posts/a.html#intro", "/blog/posts/a.html#intro"},
+		{"https://example.com/blog/", "tags/c%23.html?x=1#top", "https://example.com/blog/tags/c%23.html?x=1#top", "/blog/tags/c%23.html?x=1#top"},
+		{"https://example.com/my%20blog/", "a%20b.html", "https://example.com/my%20blog/a%20b.html", "/my%20blog/a%20b.html"},
+		{"https://example.com/", "https://other.org/page?x=1", "https://other.org/page?x=1", "https://other.org/page?x=1"},
+		{"https://example.com/", "mailto:me@example.com", "mailto:me@example.com", "mailto:me@example.com"},
+		{"https://example.com/", "//cdn.example.org/app.js", "//cdn.example.org/app.js", "//cdn.example.org/app.js"},
+		{"https://example.com/", "#top", "#top", "#top"},
+		{"", "https://other.org/", "https://other.org/", "https://other.org/"},
+	}
+	for _, test := range tests {
+		abs, err := absoluteSiteUrl(test.baseUrl, test.target)
+		if len(test.abs) == 0 {
+			if err == nil || !strings.Contains(err.Error(), "no BaseURL is configured") {
+				t.Errorf("absURL(%q) with BaseURL %q = %q, %v, want the BaseURL error", test.target, test.baseUrl, abs, err)
+			}
+		} else if err != nil || abs != test.abs {
+			t.Errorf("absURL(%q) with BaseURL %q = %q, %v, want %q", test.target, test.baseUrl, abs, err, test.abs)
+		}
+		root, err := rootRelativeUrl(test.baseUrl, test.target)
+		if err != nil || root != test.root {
+			t.Errorf("rootURL(%q) with BaseURL %q = %q, %v, want %q", test.target, test.baseUrl, root, err, test.root)
+		}
+	}
+}
+
+func TestSiteUrlsRejectBadInput(t *testing.T) {
+	tests := []struct {
+		baseUrl string
+		target  string
+		err     string
+	}{
+		{"https://example.com/%zz", "a.html", "BaseURL 'https://example.com/%zz' is not a URL"},
+		{"https://example.com/", "a%zz.html", "'a%zz.html'"},
+	}
+	for _, test := range tests {
+		_, absErr := absoluteSiteUrl(test.baseUrl, test.target)
+		_, rootErr := rootRelativeUrl(test.baseUrl, test.target)
+		for _, err := range []error{absErr, rootErr} {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%q with BaseURL %q = %v, want %q", test.target, test.baseUrl, err, test.err)
+			}
+		}
+	}
+}
+
+func TestUrlFunctionsInTemplates(t *testing.T) {
+	const urls = `<p>{{absURL "/posts/a.html?x=1#top"}}|{{rootURL "tags/"}}|<a href="{{safeURL "javascript:void(0)"}}">x</a></p>`
+	const want = `<p>https://example.com/blog/posts/a.html?x=1#top|/blog/tags/|<a href="javascript:void(0)">x</a></p>`
+	site := newTestSite(t)
+	site.Configuration.BaseURL = "https://example.com/blog"
+	site.writeFile(site.Configuration.TemplatePage, "<html><body>"+urls+"{{.Content}}</body></html>\n")
+	site.writeFile(site.Configuration.TemplateIndex, "<html><body>"+urls+"</body></html>\n")
+	site.writeFile(site.path("extra.html"), "<html><body>"+urls+"</body></html>\n")
+	site.Configuration.ExtraOutputs = []ExtraOutput{{Template: site.path("extra.html"), Output: "extra.html"}}
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	site.mustBuild()
+	for _, name := range []string{"a.html", INDEX_FILE_NAME, "extra.html"} {
+		if output := site.read(name); !strings.Contains(output, want) {
+			t.Errorf("%s = %q, want %q", name, output, want)
+		}
+	}
+}
+
+func TestAbsURLWithoutBaseURLFailsTheBuild(t *testing.T) {
+	site := newTestSite(t)
+	site.writeFile(site.Configuration.TemplateIndex, `<html><body>{{absURL "feed.xml"}}</body></html>`+"\n")
+	site.page("a.md", `{"Title": "A"}`, "body\n")
+	err := site.build()
+	if err == nil || !strings.Contains(err.Error(), "absURL 'feed.xml': no BaseURL is configured") {
+		t.Errorf("build error = %v, want the absURL error", err)
+	}
+}
+
+func TestSpecialFileNames(t *testing.T) {
+	names := []string{"my post (draft 2).md", "a&b.md", "café.md", "plain.md"}
+	urls := map[string]string{
+		"my post (draft 2).html": "/my%20post%20%28draft%202%29.html",
+		"a&b.html":               "/a&b.html",
+		"café.html":              "/caf%C3%A9.html",
+		"plain.html":             "/plain.html",
+	}
+	var indexes []string
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}} {
+		site := newTestSite(t)
+		site.Configuration.BaseURL = "https://example.com/"
+		for _, position := range order {
+			site.page(names[position], `{"Title": "`+names[position]+`"}`, "body\n")
+		}
+		site.mustBuild()
+		index := site.read(INDEX_FILE_NAME)
+		sitemap := site.read(SITEMAP_FILE_NAME)
+		for file, url := range urls {
+			if !site.exists(file) {
+				t.Errorf("%s was not written", file)
+			}
+			if !strings.Contains(index, `href="`+url+`"`) {
+				t.Errorf("index = %q, want %s", index, url)
+			}
+			if !strings.Contains(sitemap, "<loc>https://example.com"+strings.Replace(url, "&", "&amp;", -1)+"</loc>") {
+				t.Errorf("sitemap = %q, want %s", sitemap, url)
+			}
+		}
+		indexes = append(indexes, index)
+	}
+	for _, index := range indexes[1:] {
+		if index != indexes[0] {
+			t.Errorf("index depends on the order the files were created:\n%s\n%s", indexes[0], index)
+		}
+	}
+}
+
+func TestSlugifiedFileNameCollisions(t *testing.T) {
+	var messages []string
+	for _, reversed := range []bool{false, true} {
+		site := newTestSite(t)
+		site.Configuration.SlugifyFileNames = true
+		names := []string{"my post (draft 2).md", "my-post-draft-2.md"}
+		if reversed {
+			names[0], names[1] = names[1], names[0]
+		}
+		for _, name := range names {
+			site.page(name, `{"Title": "`+name+`"}`, "body\n")
+		}
+		err := site.build()
+		if err == nil || !strings.Contains(err.Error(), "is written by both") {
+			t.Fatalf("build = %v, want the collision of both files", err)
+		}
+		messages = append(messages, strings.Replace(err.Error(), site.Configuration.Input, "content", -1))
+	}
+	if messages[0] != messages[1] {
+		t.Errorf("collision error depends on the order the files were created:\n%s\n%s", messages[0], messages[1])
+	}
+}
+
+func TestUnreadableSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(site *testSite)
+		reason string
+	}{
+		{"binary file", func(site *testSite) { site.write("b.md", "PNG\x00\x01\x02binary\n") }, "file contains NUL bytes"},
+		{"invalid utf-8", func(site *testSite) { site.write("b.md", "```json\n{\"Title\": \"B\"}\n```\ncaf\xe9\n") }, "file is not valid UTF-8"},
+		{"permission denied", func(site *testSite) {
+			if os.Geteuid() == 0 {
+				site.t.Skip("permissions are not enforced for root")
+			}
+			site.page("b.md", `{"Title": "B"}`, "body\n")
+			if err := os.Chmod(site.path("content/b.md"), 0); err != nil {
+				site.t.Skip("cannot remove the read permission: ", err)
+			}
+			site.t.Cleanup(func() { os.Chmod(site.path("content/b.md"), 0644) })
+		}, "file cannot be read: permission denied"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, policy := range []string{POLICY_WARN, POLICY_ERROR} {
+				site := newTestSite(t)
+				site.Configuration.Policies = map[string]string{CONDITION_UNREADABLE_FILE: policy}
+				site.page("a.md", `{"Title": "A"}`, "body\n")
+				test.setup(site)
+				builder := NewBuilder(site.Configuration)
+				err := builder.Build()
+				if policy == POLICY_ERROR {
+					if err == nil || !strings.Contains(err.Error(), test.reason) || !strings.Contains(err.Error(), site.path("content/b.md")) {
+						t.Fatalf("build = %v, want %q naming b.md", err, test.reason)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if builder.Summary.Unreadable != 1 || builder.Summary.Failed != 0 {
+					t.Errorf("summary = %+v, want one unreadable file and no failures", builder.Summary)
+				}
+				findings := builder.Findings()
+				if len(findings) != 1 || findings[0].Condition != CONDITION_UNREADABLE_FILE || !strings.Contains(findings[0].Message, test.reason) {
+					t.Errorf("findings = %v, want the %s warning", findings, CONDITION_UNREADABLE_FILE)
+				}
+				if !site.exists("a.html") || site.exists("b.html") {
+					t.Error("want a.html written and b.md skipped")
+				}
+			}
+		})
+	}
+}
+
+func TestRelativeAssetUrls(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		meta   string
+		output string
+		want   string
+	}{
+		{"depth 0", "a.md", `{"Title": "A"}`, "a.html", "style.css|./|tags/go.html|#top|https://cdn.example.org/x.css"},
+		{"depth 1", "posts/a.md", `{"Title": "A"}`, "posts/a.html", "../style.css|../|../tags/go.html|#top|https://cdn.example.org/x.css"},
+		{"depth 3", "deep/nested/more/a.md", `{"Title": "A"}`, "deep/nested/more/a.html", "../../../style.css|../../../|../../../tags/go.html|#top|https://cdn.example.org/x.css"},
+		{"pretty directory at depth 0", "a.md", `{"Title": "A", "Path": "about/"}`, "about/index.html", "../style.css|../|../tags/go.html|#top|https://cdn.example.org/x.css"},
+		{"pretty directory at depth 3", "a.md", `{"Title": "A", "Path": "deep/nested/about/"}`, "deep/nested/about/index.html", "../../../style.css|../../../|../../../tags/go.html|#top|https://cdn.example.org/x.css"},
+		{"explicit file at depth 1", "deep/nested/a.md", `{"Title": "A", "Path": "posts/a.html"}`, "posts/a.html", "../style.css|../|../tags/go.html|#top|https://cdn.example.org/x.css"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.Configuration.Recursive = true
+			site.writeFile(site.Configuration.TemplatePage, `{{relURL "/style.css"}}|{{relURL "/"}}|{{relURL "tags/go.html"}}|{{relURL "#top"}}|{{relURL "https://cdn.example.org/x.css"}}`)
+			site.page(test.file, test.meta, "body\n")
+			site.mustBuild()
+			if got := site.read(test.output); got != test.want {
+				t.Errorf("%s = %q, want %q", test.output, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const FAILED_PAGES_STOP = "stop"
+const FAILED_PAGES_KEEP = "keep"
+const FAILED_PAGES_EXCLUDE = "exclude"
+
+func failedPagesPolicy(configuration Configuration) string {
+	if len(configuration.FailedPages) == 0 {
+		return FAILED_PAGES_STOP
+	}
+	return configuration.FailedPages
+}
+
+func checkFailedPages(configuration Configuration) error {
+	policy := failedPagesPolicy(configuration)
+	if policy != FAILED_PAGES_STOP && policy != FAILED_PAGES_KEEP && policy != FAILED_PAGES_EXCLUDE {
+		msg := fmt.Sprintf("FailedPages must be '%s', '%s' or '%s', not '%s'", FAILED_PAGES_STOP, FAILED_PAGES_KEEP, FAILED_PAGES_EXCLUDE, policy)
+		return errors.New(msg)
+	}
+	if policy != FAILED_PAGES_STOP && configuration.OutputMode == OUTPUT_MODE_BOOK {
+		return errors.New("book output is always rebuilt as a whole, FailedPages can only stop the build")
+	}
+	return nil
+}
+
+func (builder *Builder) failPage(sources []Source, category string, path string, name string, cached map[string]Source, err error) []Source {
+	policy := failedPagesPolicy(builder.Configuration)
+	if policy == FAILED_PAGES_STOP {
+		builder.fail(category, path, err)
+		return sources
+	}
+	builder.Summary.Failed++
+	last, known := cached[name]
+	if policy == FAILED_PAGES_KEEP && known {
+		builder.warn(WARNING_FAILED_PAGE, path, "%s: %s: %s, serving the output of the previous build", category, path, err)
+		last.Stale = true
+		builder.Summary.Stale = append(builder.Summary.Stale, path)
+		return append(sources, last)
+	}
+	builder.warn(WARNING_FAILED_PAGE, path, "%s: %s: %s, leaving the page out", category, path, err)
+	if known {
+		builder.excluded = append(builder.excluded, last)
+	}
+	return sources
+}
+
+func removeExcluded(builder *Builder, sources []Source) error {
+	written := map[string]bool{}
+	for _, source := range sources {
+		for _, path := range variantPaths(source) {
+			written[path] = true
+		}
+	}
+	for _, source := range builder.excluded {
+		for _, path := range variantPaths(source) {
+			if written[path] {
+				continue
+			}
+			err := builder.Output.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
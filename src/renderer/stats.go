@@ -0,0 +1,150 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const CONTENT_STATS_FILE_NAME = "content-stats.json"
+
+type MonthStat struct {
+	Month string
+	Posts int
+	Words int
+}
+
+type ContentStat struct {
+	Name          string
+	Posts         int
+	Words         int
+	LastPublished string
+	Months        []MonthStat
+}
+
+type ContentStats struct {
+	Posts   int
+	Words   int
+	Authors []ContentStat
+	Tags    []ContentStat
+	Months  []MonthStat
+
+	Validation map[string]int    `json:",omitempty"`
+	Oversized  []OversizedOutput `json:",omitempty"`
+	Warnings   []Finding         `json:",omitempty"`
+	Failures   []*FileError      `json:",omitempty"`
+}
+
+type statCounter struct {
+	stat   ContentStat
+	months map[string]*MonthStat
+}
+
+func WordCount(markup string) int {
+	return len(strings.Fields(StripHTML(markup)))
+}
+
+func (counter *statCounter) add(link Link, words int) {
+	counter.stat.Posts++
+	counter.stat.Words += words
+	if len(link.Date) < len(DATE_DISPLAY_FORMAT) || link.Date == ZERO_DATE {
+		return
+	}
+	if link.Date > counter.stat.LastPublished {
+		counter.stat.LastPublished = link.Date
+	}
+	month := link.Date[:7]
+	if counter.months[month] == nil {
+		counter.months[month] = &MonthStat{Month: month}
+	}
+	counter.months[month].Posts++
+	counter.months[month].Words += words
+}
+
+func (counter *statCounter) result() ContentStat {
+	stat := counter.stat
+	stat.Months = []MonthStat{}
+	for _, month := range counter.months {
+		stat.Months = append(stat.Months, *month)
+	}
+	sort.Slice(stat.Months, func(i, j int) bool {
+		return stat.Months[i].Month < stat.Months[j].Month
+	})
+	return stat
+}
+
+func newStatCounter(name string) *statCounter {
+	return &statCounter{stat: ContentStat{Name: name}, months: map[string]*MonthStat{}}
+}
+
+func sortedStats(counters map[string]*statCounter, collator *titleCollator) []ContentStat {
+	stats := []ContentStat{}
+	for _, counter := range counters {
+		stats = append(stats, counter.result())
+	}
+	names := collator.order()
+	sort.Slice(stats, func(i, j int) bool {
+		return names.compare(stats[i].Name, stats[j].Name) < 0
+	})
+	return stats
+}
+
+func buildContentStats(sources []Source, today string, collator *titleCollator) ContentStats {
+	total := newStatCounter("")
+	authors := map[string]*statCounter{}
+	tags := map[string]*statCounter{}
+	for _, source := range sources {
+		link := source.Link
+		if !isListed(source) || (link.Date != ZERO_DATE && link.Date > today) {
+			continue
+		}
+		total.add(link, source.Words)
+		for _, author := range link.Authors {
+			if authors[author.Name] == nil {
+				authors[author.Name] = newStatCounter(author.Name)
+			}
+			authors[author.Name].add(link, source.Words)
+		}
+		for _, tag := range link.Tags {
+			if tags[tag] == nil {
+				tags[tag] = newStatCounter(tag)
+			}
+			tags[tag].add(link, source.Words)
+		}
+	}
+	overall := total.result()
+	return ContentStats{
+		Posts:   overall.Posts,
+		Words:   overall.Words,
+		Authors: sortedStats(authors, collator),
+		Tags:    sortedStats(tags, collator),
+		Months:  overall.Months,
+	}
+}
+
+func writeFailedStats(builder *Builder, err error) error {
+	var buildError *BuildError
+	_, isFileOutput := builder.Output.(*fileOutput)
+	if !builder.Configuration.ContentStats || builder.DryRun || !isFileOutput || !errors.As(err, &buildError) {
+		return nil
+	}
+	stats := ContentStats{Authors: []ContentStat{}, Tags: []ContentStat{}, Months: []MonthStat{}}
+	if builder.stats != nil {
+		stats = *builder.stats
+	}
+	stats.Validation = validationCounts(builder.Summary.Conditions)
+	stats.Oversized = builder.Summary.Oversized
+	stats.Warnings = builder.warnings.all()
+	stats.Failures = buildError.Errors
+	data, err := renderContentStats(stats)
+	if err == nil {
+		err = writeFile(builder.Output, CONTENT_STATS_FILE_NAME, data)
+	}
+	return err
+}
+
+func renderContentStats(stats ContentStats) ([]byte, error) {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	return append(data, '\n'), err
+}
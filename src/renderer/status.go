@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var TEMPLATE_ERROR_POSITION = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?:`)
+
+type BuildStatus struct {
+	Running  bool
+	Success  bool
+	Error    string
+	File     string
+	Line     int
+	Started  time.Time
+	Finished time.Time
+}
+
+type statusBoard struct {
+	mutex  sync.Mutex
+	status BuildStatus
+}
+
+func templateErrorPosition(message string) (string, int) {
+	match := TEMPLATE_ERROR_POSITION.FindStringSubmatch(message)
+	if match == nil {
+		return "", 0
+	}
+	line, _ := strconv.Atoi(match[2])
+	return match[1], line
+}
+
+func (board *statusBoard) start(now time.Time) {
+	board.mutex.Lock()
+	defer board.mutex.Unlock()
+	board.status.Running = true
+	board.status.Started = now
+}
+
+func (board *statusBoard) finish(now time.Time, err error) {
+	board.mutex.Lock()
+	defer board.mutex.Unlock()
+	status := BuildStatus{Success: err == nil, Started: board.status.Started, Finished: now}
+	if err != nil {
+		status.Error = err.Error()
+		status.File, status.Line = templateErrorPosition(status.Error)
+	}
+	board.status = status
+}
+
+func (builder *Builder) Status() BuildStatus {
+	builder.status.mutex.Lock()
+	defer builder.status.mutex.Unlock()
+	return builder.status.status
+}
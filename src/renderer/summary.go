@@ -0,0 +1,38 @@
+package renderer
+
+import (
+	"log"
+	"strings"
+)
+
+type Summary struct {
+	Rendered   int
+	Unchanged  int
+	Excluded   int
+	Unreadable int
+	Failed     int
+	Errors     int
+	Conditions map[string]int
+	Stale      []string
+	Cleaned    []string
+	Oversized  []OversizedOutput
+}
+
+func logSummary(summary Summary) {
+	log.Printf("summary: %d pages rendered, %d unchanged, %d paths excluded", summary.Rendered, summary.Unchanged, summary.Excluded)
+	if summary.Unreadable > 0 {
+		log.Printf("skipped: %d unreadable files", summary.Unreadable)
+	}
+	if summary.Failed > 0 {
+		log.Printf("failed: %d pages", summary.Failed)
+	}
+	if len(summary.Stale) > 0 {
+		log.Printf("stale: %d pages are served from the previous build: %s", len(summary.Stale), strings.Join(summary.Stale, ", "))
+	}
+	if len(summary.Cleaned) > 0 {
+		log.Printf("cleaned: %d outputs of the previous build are no longer written: %s", len(summary.Cleaned), strings.Join(summary.Cleaned, ", "))
+	}
+	if len(summary.Conditions) > 0 {
+		log.Printf("conditions: %s", formatConditions(summary.Conditions))
+	}
+}
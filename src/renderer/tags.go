@@ -0,0 +1,121 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const TAGS_META_KEY = "tags"
+
+func tagSpaces(tag string) string {
+	return strings.Join(strings.Fields(tag), " ")
+}
+
+func tagKey(tag string) string {
+	return strings.ToLower(normalizeName(tagSpaces(tag)))
+}
+
+func checkTagAliases(configuration Configuration) error {
+	var names []string
+	for alias := range configuration.TagAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	targets := map[string]string{}
+	for _, alias := range names {
+		canonical := configuration.TagAliases[alias]
+		if len(tagKey(alias)) == 0 || len(tagKey(canonical)) == 0 {
+			msg := fmt.Sprintf("tag alias '%s' -> '%s' must name two tags", alias, canonical)
+			return errors.New(msg)
+		}
+		other, found := targets[tagKey(alias)]
+		if found && other != tagSpaces(canonical) {
+			msg := fmt.Sprintf("tag alias '%s' maps to both '%s' and '%s'", alias, other, tagSpaces(canonical))
+			return errors.New(msg)
+		}
+		targets[tagKey(alias)] = tagSpaces(canonical)
+	}
+	for _, alias := range names {
+		canonical := targets[tagKey(alias)]
+		chained, found := targets[tagKey(canonical)]
+		if found && chained != canonical {
+			msg := fmt.Sprintf("tag alias target '%s' is itself an alias of '%s'", canonical, chained)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func tagAliases(configuration Configuration) map[string]string {
+	aliases := map[string]string{}
+	for alias, canonical := range configuration.TagAliases {
+		aliases[tagKey(alias)] = tagSpaces(canonical)
+	}
+	return aliases
+}
+
+func normalizeTags(tags []string, aliases map[string]string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = tagSpaces(tag)
+		canonical, found := aliases[tagKey(tag)]
+		if found {
+			tag = canonical
+		}
+		if len(tag) > 0 && !seen[tagKey(tag)] {
+			seen[tagKey(tag)] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+func tagDisplayNames(sources []Source, configuration Configuration) map[string]string {
+	names := map[string]string{}
+	for _, canonical := range tagAliases(configuration) {
+		names[tagKey(canonical)] = canonical
+	}
+	for _, source := range sources {
+		for _, tag := range source.Link.Tags {
+			name, found := names[tagKey(tag)]
+			if !found {
+				names[tagKey(tag)] = tag
+			} else if name != tag {
+				log.Printf("tags: '%s' in %s is merged into '%s'", tag, source.Path, name)
+			}
+		}
+	}
+	return names
+}
+
+func displayTags(tags []string, names map[string]string) []string {
+	if len(tags) == 0 {
+		return tags
+	}
+	display := make([]string, len(tags))
+	for index, tag := range tags {
+		display[index] = tag
+		name, found := names[tagKey(tag)]
+		if found {
+			display[index] = name
+		}
+	}
+	return display
+}
+
+func applyTagNames(sources []Source, names map[string]string, configuration Configuration) {
+	for index := range sources {
+		source := &sources[index]
+		source.Link.Tags = displayTags(source.Link.Tags, names)
+		source.Page.Tags = source.Link.Tags
+		for name, key := range taxonomies(configuration) {
+			if strings.ToLower(key) == TAGS_META_KEY && len(source.Terms[name]) > 0 {
+				source.Terms[name] = source.Link.Tags
+			}
+		}
+	}
+}
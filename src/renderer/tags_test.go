@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	aliases := tagAliases(Configuration{TagAliases: map[string]string{"golang": "Go", "JS": "JavaScript"}})
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"alias", []string{"golang"}, []string{"Go"}},
+		{"alias in another case", []string{"GoLang", "js"}, []string{"Go", "JavaScript"}},
+		{"case folding", []string{"Hiking", "hiking", "HIKING"}, []string{"Hiking"}},
+		{"trailing space", []string{"hiking ", "hiking"}, []string{"hiking"}},
+		{"inner spaces", []string{"New  York", "new york"}, []string{"New York"}},
+		{"alias and its target", []string{"Go", "golang"}, []string{"Go"}},
+		{"empty tags", []string{"", "  ", "alps"}, []string{"alps"}},
+	}
+	for _, test := range tests {
+		if got := normalizeTags(test.tags, aliases); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: normalizeTags(%q) = %q, want %q", test.name, test.tags, got, test.want)
+		}
+	}
+}
+
+func TestCheckTagAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		err     string
+	}{
+		{"valid", map[string]string{"golang": "Go", "Go Lang": "Go"}, ""},
+		{"empty alias", map[string]string{" ": "Go"}, "must name two tags"},
+		{"two targets", map[string]string{"golang": "Go", "GoLang ": "Golang Language"}, "maps to both"},
+		{"chained", map[string]string{"golang": "Go", "go": "Golang"}, "is itself an alias"},
+	}
+	for _, test := range tests {
+		err := checkTagAliases(Configuration{TagAliases: test.aliases})
+		if len(test.err) == 0 && err != nil {
+			t.Errorf("%s: checkTagAliases = %v, want no error", test.name, err)
+		}
+		if len(test.err) > 0 && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: checkTagAliases = %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestTagPagesMerge(t *testing.T) {
+	site := newTestSite(t)
+	site.Configuration.Taxonomies = map[string]string{"tags": "tags"}
+	site.Configuration.TagAliases = map[string]string{"golang": "Go"}
+	site.writeFile(site.Configuration.TemplateIndex, "{{range .Links}}{{.Title}}:{{range .Tags}}{{.}};{{end}}|{{end}}")
+	site.page("a.md", `{"Title": "A", "Tags": ["go "]}`, "body\n")
+	site.page("b.md", `{"Title": "B", "Tags": ["GO", "golang"]}`, "body\n")
+	site.page("c.md", `{"Title": "C", "Tags": ["golang"]}`, "body\n")
+	site.mustBuild()
+	if index := site.read(INDEX_FILE_NAME); index != "A:Go;|B:Go;|C:Go;|" {
+		t.Errorf("index = %q, want every page tagged Go once", index)
+	}
+	terms, err := filepath.Glob(filepath.Join(site.Configuration.Output, "tags", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, term := range terms {
+		if info, err := os.Stat(term); err == nil && !info.IsDir() {
+			names = append(names, filepath.Base(term))
+		}
+	}
+	if len(names) != 1 {
+		t.Errorf("tag pages = %v, want one page for Go", names)
+	}
+}
@@ -0,0 +1,244 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var DEFAULT_TAXONOMIES = map[string]string{
+	"tags":       "tags",
+	"categories": "category",
+}
+
+type Term struct {
+	Name string
+	Slug string
+	Url  string
+}
+
+type TaxonomyTerm struct {
+	Term
+	Count int
+	Links []Link
+}
+
+func taxonomies(configuration Configuration) map[string]string {
+	if configuration.Taxonomies == nil {
+		return DEFAULT_TAXONOMIES
+	}
+	return configuration.Taxonomies
+}
+
+func termPagesEnabled(configuration Configuration) bool {
+	return configuration.Taxonomies != nil || len(configuration.TemplateTerm) > 0
+}
+
+func checkTaxonomies(configuration Configuration) error {
+	for name, key := range configuration.Taxonomies {
+		if len(name) == 0 || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			msg := fmt.Sprintf("taxonomy name '%s' must be a single path segment", name)
+			return errors.New(msg)
+		}
+		if len(key) == 0 {
+			msg := fmt.Sprintf("taxonomy '%s' has no meta key", name)
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func metaFieldNames() map[string]bool {
+	names := map[string]bool{}
+	kind := reflect.TypeOf(MetaBlock{})
+	for index := 0; index < kind.NumField(); index++ {
+		name, exported := jsonFieldName(kind.Field(index))
+		if exported {
+			names[strings.ToLower(name)] = true
+		}
+	}
+	return names
+}
+
+func metaFields(data []byte) map[string][]string {
+	var raw map[string]interface{}
+	if json.Unmarshal(data, &raw) != nil {
+		return nil
+	}
+	known := metaFieldNames()
+	var fields map[string][]string
+	for key, value := range raw {
+		key = strings.ToLower(key)
+		if known[key] {
+			continue
+		}
+		var values []string
+		switch typed := value.(type) {
+		case string:
+			values = []string{typed}
+		case []interface{}:
+			for _, item := range typed {
+				text, isString := item.(string)
+				if !isString {
+					values = nil
+					break
+				}
+				values = append(values, text)
+			}
+		}
+		if len(values) > 0 {
+			if fields == nil {
+				fields = map[string][]string{}
+			}
+			fields[key] = values
+		}
+	}
+	return fields
+}
+
+func metaValues(metaBlock MetaBlock, key string) []string {
+	key = strings.ToLower(key)
+	value := reflect.ValueOf(metaBlock)
+	for index := 0; index < value.NumField(); index++ {
+		name, exported := jsonFieldName(value.Type().Field(index))
+		if !exported || strings.ToLower(name) != key {
+			continue
+		}
+		field := value.Field(index)
+		switch {
+		case field.Kind() == reflect.String && len(field.String()) > 0:
+			return []string{field.String()}
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			var values []string
+			for item := 0; item < field.Len(); item++ {
+				values = append(values, field.Index(item).String())
+			}
+			return values
+		}
+		return nil
+	}
+	return metaBlock.Fields[key]
+}
+
+func metaTerms(metaBlock MetaBlock, configuration Configuration) map[string][]string {
+	var terms map[string][]string
+	for name, key := range taxonomies(configuration) {
+		var values []string
+		seen := map[string]bool{}
+		for _, value := range metaValues(metaBlock, key) {
+			value = strings.TrimSpace(value)
+			if len(value) > 0 && !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 {
+			if terms == nil {
+				terms = map[string][]string{}
+			}
+			terms[name] = values
+		}
+	}
+	return terms
+}
+
+func termOutputPath(taxonomy string, slug string) string {
+	return path.Join(taxonomy, slug+".html")
+}
+
+func buildTaxonomies(sources []Source, configuration Configuration) map[string][]TaxonomyTerm {
+	built := map[string][]TaxonomyTerm{}
+	for name := range taxonomies(configuration) {
+		positions := map[string]int{}
+		var terms []TaxonomyTerm
+		for _, source := range sources {
+			if !isListed(source) {
+				continue
+			}
+			for _, value := range source.Terms[name] {
+				position, found := positions[value]
+				if !found {
+					position = len(terms)
+					positions[value] = position
+					terms = append(terms, TaxonomyTerm{Term: Term{Name: value}})
+				}
+				terms[position].Count++
+				terms[position].Links = append(terms[position].Links, source.Link)
+			}
+		}
+		names := collatorFor(configuration).order()
+		sort.SliceStable(terms, func(i, j int) bool {
+			return names.compare(terms[i].Name, terms[j].Name) < 0
+		})
+		registry := newIdRegistry()
+		for index := range terms {
+			terms[index].Slug = registry.allocate(truncateSlug(configuration, Slugify(terms[index].Name)))
+			terms[index].Url = outputUrl(termOutputPath(name, terms[index].Slug))
+		}
+		built[name] = terms
+	}
+	return built
+}
+
+func pageTaxonomies(source Source, site *Site) map[string][]Term {
+	var assigned map[string][]Term
+	for name, values := range source.Terms {
+		byName := map[string]Term{}
+		for _, term := range site.Taxonomies[name] {
+			byName[term.Name] = term.Term
+		}
+		for _, value := range values {
+			term, found := byName[value]
+			if !found {
+				continue
+			}
+			if assigned == nil {
+				assigned = map[string][]Term{}
+			}
+			assigned[name] = append(assigned[name], term)
+		}
+	}
+	return assigned
+}
+
+func renderTerms(configuration Configuration, output OutputWriter, site *Site) error {
+	if !termPagesEnabled(configuration) {
+		return nil
+	}
+	templatePath := configuration.TemplateTerm
+	if len(templatePath) == 0 {
+		templatePath = configuration.TemplateIndex
+	}
+	var names []string
+	for name := range site.Taxonomies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, term := range site.Taxonomies[name] {
+			oldest, newest := dateRange(term.Links)
+			listing := Index{
+				Links:    term.Links,
+				Groups:   groupLinks(term.Links, configuration.GroupOrder, configuration.DefaultGroup),
+				Site:     site,
+				Menu:     resolveMenu(configuration.Menu, term.Url, site),
+				Recent:   recentLinks(term.Links, configuration.RecentCount),
+				Total:    len(term.Links),
+				Oldest:   oldest,
+				Newest:   newest,
+				Taxonomy: name,
+				Term:     term.Term,
+			}
+			err := doIndex(output, termOutputPath(name, term.Slug), templatePath, listing, configuration)
+			if err != nil {
+				msg := fmt.Sprintf("%s listing of '%s': %s", name, term.Name, err)
+				return errors.New(msg)
+			}
+		}
+	}
+	return nil
+}
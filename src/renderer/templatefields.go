@@ -0,0 +1,291 @@
+package renderer
+
+import (
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+const SUGGESTION_LENGTH_PER_EDIT = 4
+
+type templateRoot struct {
+	name string
+	path string
+	data reflect.Type
+}
+
+type fieldProblem struct {
+	location string
+	field    string
+	owner    string
+	guess    string
+}
+
+type fieldChecker struct {
+	tree     *parse.Tree
+	root     reflect.Type
+	funcs    template.FuncMap
+	problems []fieldProblem
+}
+
+func templateRoots(configuration Configuration) []templateRoot {
+	page := reflect.TypeOf(Page{})
+	index := reflect.TypeOf(Index{})
+	roots := []templateRoot{
+		{"TemplatePage", configuration.TemplatePage, page},
+		{"TemplateIndex", configuration.TemplateIndex, index},
+		{"Template404", configuration.Template404, page},
+		{"TemplateBook", configuration.TemplateBook, reflect.TypeOf(Book{})},
+		{"TemplateTerm", configuration.TemplateTerm, index},
+		{"TemplateAZ", configuration.TemplateAZ, index},
+	}
+	for _, rule := range configuration.TemplateRules {
+		roots = append(roots, templateRoot{"TemplateRules", rule.Template, page})
+	}
+	for _, extra := range configuration.ExtraOutputs {
+		roots = append(roots, templateRoot{"ExtraOutputs", extra.Template, index})
+	}
+	files, _ := shortcodeFiles(configuration)
+	for _, file := range files {
+		roots = append(roots, templateRoot{"shortcode", file, reflect.TypeOf(Shortcode{})})
+	}
+	return roots
+}
+
+func editDistance(left string, right string) int {
+	distances := make([][]int, len(left)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(right)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+	for i := 1; i <= len(left); i++ {
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			best := distances[i-1][j-1] + cost
+			if distances[i-1][j]+1 < best {
+				best = distances[i-1][j] + 1
+			}
+			if distances[i][j-1]+1 < best {
+				best = distances[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && left[i-1] == right[j-2] && left[i-2] == right[j-1] && distances[i-2][j-2]+1 < best {
+				best = distances[i-2][j-2] + 1
+			}
+			distances[i][j] = best
+		}
+	}
+	return distances[len(left)][len(right)]
+}
+
+func memberNames(kind reflect.Type) []string {
+	var names []string
+	for index := 0; index < kind.NumField(); index++ {
+		if len(kind.Field(index).PkgPath) == 0 {
+			names = append(names, kind.Field(index).Name)
+		}
+	}
+	pointer := reflect.PtrTo(kind)
+	for index := 0; index < pointer.NumMethod(); index++ {
+		names = append(names, pointer.Method(index).Name)
+	}
+	return names
+}
+
+func suggestMember(kind reflect.Type, name string) string {
+	limit := len(name) / SUGGESTION_LENGTH_PER_EDIT
+	if limit < 1 {
+		limit = 1
+	}
+	best, distance := "", limit+1
+	for _, candidate := range memberNames(kind) {
+		candidateDistance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if candidateDistance < distance {
+			best, distance = candidate, candidateDistance
+		}
+	}
+	return best
+}
+
+func memberType(kind reflect.Type, name string) (reflect.Type, bool) {
+	for kind != nil && kind.Kind() == reflect.Ptr {
+		kind = kind.Elem()
+	}
+	if kind == nil {
+		return nil, true
+	}
+	switch kind.Kind() {
+	case reflect.Struct:
+		if field, found := kind.FieldByName(name); found && len(field.PkgPath) == 0 {
+			return field.Type, true
+		}
+		if method, found := reflect.PtrTo(kind).MethodByName(name); found {
+			if method.Type.NumOut() == 0 {
+				return nil, true
+			}
+			return method.Type.Out(0), true
+		}
+		return nil, false
+	case reflect.Map:
+		return kind.Elem(), true
+	}
+	return nil, true
+}
+
+func knownType(kind reflect.Type) reflect.Type {
+	if kind == nil || kind.Kind() == reflect.Interface {
+		return nil
+	}
+	return kind
+}
+
+func (checker *fieldChecker) fields(node parse.Node, dot reflect.Type, path string, names []string) reflect.Type {
+	kind := dot
+	for _, name := range names {
+		if kind == nil {
+			return nil
+		}
+		owner := kind
+		for owner.Kind() == reflect.Ptr {
+			owner = owner.Elem()
+		}
+		next, found := memberType(kind, name)
+		if !found {
+			location, _ := checker.tree.ErrorContext(node)
+			problem := fieldProblem{location: location, field: path + "." + name, owner: owner.Name()}
+			if guess := suggestMember(owner, name); len(guess) > 0 {
+				problem.guess = path + "." + guess
+			}
+			checker.problems = append(checker.problems, problem)
+			return nil
+		}
+		path += "." + name
+		kind = knownType(next)
+	}
+	return kind
+}
+
+func (checker *fieldChecker) argument(node parse.Node, dot reflect.Type) reflect.Type {
+	switch typed := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return checker.fields(typed, dot, "", typed.Ident)
+	case *parse.VariableNode:
+		if typed.Ident[0] == "$" {
+			return checker.fields(typed, checker.root, "$", typed.Ident[1:])
+		}
+	case *parse.ChainNode:
+		checker.argument(typed.Node, dot)
+	case *parse.PipeNode:
+		checker.pipe(typed, dot)
+	case *parse.IdentifierNode:
+		function, found := checker.funcs[typed.Ident]
+		if found {
+			kind := reflect.TypeOf(function)
+			if kind.Kind() == reflect.Func && kind.NumOut() > 0 {
+				return knownType(kind.Out(0))
+			}
+		}
+	}
+	return nil
+}
+
+func (checker *fieldChecker) pipe(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return dot
+	}
+	var result reflect.Type
+	for _, command := range pipe.Cmds {
+		result = nil
+		for index, argument := range command.Args {
+			kind := checker.argument(argument, dot)
+			if index == 0 {
+				result = kind
+			}
+		}
+	}
+	return result
+}
+
+func elementType(kind reflect.Type) reflect.Type {
+	for kind != nil && kind.Kind() == reflect.Ptr {
+		kind = kind.Elem()
+	}
+	if kind == nil {
+		return nil
+	}
+	switch kind.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return knownType(kind.Elem())
+	}
+	return nil
+}
+
+func (checker *fieldChecker) list(list *parse.ListNode, dot reflect.Type) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		checker.node(node, dot)
+	}
+}
+
+func (checker *fieldChecker) node(node parse.Node, dot reflect.Type) {
+	switch typed := node.(type) {
+	case *parse.ActionNode:
+		checker.pipe(typed.Pipe, dot)
+	case *parse.IfNode:
+		checker.pipe(typed.Pipe, dot)
+		checker.list(typed.List, dot)
+		checker.list(typed.ElseList, dot)
+	case *parse.RangeNode:
+		checker.list(typed.List, elementType(checker.pipe(typed.Pipe, dot)))
+		checker.list(typed.ElseList, dot)
+	case *parse.WithNode:
+		checker.list(typed.List, checker.pipe(typed.Pipe, dot))
+		checker.list(typed.ElseList, dot)
+	case *parse.TemplateNode:
+		checker.pipe(typed.Pipe, dot)
+	case *parse.ListNode:
+		checker.list(typed, dot)
+	}
+}
+
+func templateFieldProblems(root templateRoot, configuration Configuration) []fieldProblem {
+	funcs := templateFuncs(configuration, nil, "")
+	templateObj, err := template.New(filepath.Base(root.path)).Funcs(funcs).ParseFiles(root.path)
+	if err != nil {
+		return nil
+	}
+	main := templateObj.Lookup(filepath.Base(root.path))
+	if main == nil || main.Tree == nil {
+		return nil
+	}
+	checker := &fieldChecker{tree: main.Tree, root: root.data, funcs: funcs}
+	checker.list(main.Tree.Root, root.data)
+	return checker.problems
+}
+
+func warnTemplateFields(configuration Configuration) {
+	for _, root := range templateRoots(configuration) {
+		if len(root.path) == 0 {
+			continue
+		}
+		for _, problem := range templateFieldProblems(root, configuration) {
+			suggestion := ""
+			if len(problem.guess) > 0 {
+				suggestion = ", did you mean " + problem.guess + "?"
+			}
+			log.Printf("warning: %s %s: %s is not a field of %s%s", root.name, problem.location, problem.field, problem.owner, suggestion)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateFieldProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     reflect.Type
+		template string
+		want     []string
+	}{
+		{"valid page", reflect.TypeOf(Page{}), TEST_PAGE_TEMPLATE, nil},
+		{"valid index", reflect.TypeOf(Index{}), TEST_INDEX_TEMPLATE, nil},
+		{"valid nesting", reflect.TypeOf(Index{}), `{{range .Links}}{{.Title}}{{range .Authors}}{{.Name}}{{end}}{{end}}{{with .Site}}{{.Title}}{{end}}{{.Site.Title}}`, nil},
+		{"removed field", reflect.TypeOf(Page{}), `<title>{{.Titel}}</title>{{.Body}}`, []string{".Titel of Page, did you mean .Title", ".Body of Page"}},
+		{"field in range", reflect.TypeOf(Index{}), `{{range .Links}}{{.Url}}{{.Titel}}{{end}}`, []string{".Titel of Link, did you mean .Title"}},
+		{"field in nested range and with", reflect.TypeOf(Page{}), `{{with .Authors}}{{range .}}{{.Nmae}}{{end}}{{end}}`, []string{".Nmae of Author, did you mean .Name"}},
+		{"root variable in range", reflect.TypeOf(Index{}), `{{range .Links}}{{$.Totl}}{{end}}`, []string{"$.Totl of Index, did you mean $.Total"}},
+		{"nested field", reflect.TypeOf(Index{}), `{{.Site.Titel}}`, []string{".Site.Titel of Site, did you mean .Site.Title"}},
+		{"function result", reflect.TypeOf(Index{}), `{{range sortBy "Title" "asc" .Links}}{{.Dat}}{{end}}`, []string{".Dat of Link, did you mean .Date"}},
+		{"params are free form", reflect.TypeOf(Page{}), `{{.Params.anything.goes}}`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.writeFile(site.path("check.html"), test.template)
+			problems := templateFieldProblems(templateRoot{"TemplatePage", site.path("check.html"), test.root}, site.Configuration)
+			var got []string
+			for _, problem := range problems {
+				text := problem.field + " of " + problem.owner
+				if len(problem.guess) > 0 {
+					text += ", did you mean " + problem.guess
+				}
+				got = append(got, text)
+			}
+			if strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Errorf("problems = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const SORT_ASCENDING = "asc"
+const SORT_DESCENDING = "desc"
+
+var LINK_FIELDS = []string{"Title", "Date", "Year", "Month", "Url", "Category", "Kind", "Tags"}
+
+func templateFuncs(configuration Configuration, site *Site, outputPath string) template.FuncMap {
+	return template.FuncMap{
+		"markdownify": func(text string) htmlTemplate.HTML {
+			return renderMarkdownify(text, configuration, site.abbreviations)
+		},
+		"sortBy": func(field string, order string, links []Link) ([]Link, error) {
+			return sortLinks(field, order, links, collatorFor(configuration))
+		},
+		"groupBy": groupLinksBy,
+		"where":   whereLinks,
+		"first":   firstLinks,
+		"last":    lastLinks,
+		"after":   afterLinks,
+		"relref": func(reference string) (string, error) {
+			return site.resolvePage("relref", reference)
+		},
+		"absref": func(reference string) (string, error) {
+			url, err := site.resolvePage("absref", reference)
+			if err == nil && len(configuration.BaseURL) == 0 {
+				msg := fmt.Sprintf("absref '%s': no BaseURL is configured", reference)
+				err = errors.New(msg)
+			}
+			if err == nil {
+				url = absoluteUrl(configuration.BaseURL, url)
+			}
+			return url, err
+		},
+		"relURL": func(target string) (string, error) {
+			return relativeUrl(outputPath, target)
+		},
+		"absURL": func(target string) (string, error) {
+			return absoluteSiteUrl(configuration.BaseURL, target)
+		},
+		"rootURL": func(target string) (string, error) {
+			return rootRelativeUrl(configuration.BaseURL, target)
+		},
+		"safeURL": func(target string) string {
+			return target
+		},
+		"pageByPath": func(reference string) (*EmbeddedPage, error) {
+			return site.pageByPath(reference, outputPath)
+		},
+		"paginate": paginationUnavailable,
+		"iconLinks": func() htmlTemplate.HTML {
+			return iconTags(configuration)
+		},
+		"inlineFile": func(name string) (interface{}, error) {
+			return site.inlineFiles().read(configuration, name)
+		},
+		"formatAuthors": func(authors []Author) string {
+			return formatAuthors(authors, authorDisplay(configuration))
+		},
+	}
+}
+
+func unknownLinkField(field string) error {
+	msg := fmt.Sprintf("unknown field '%s', use one of %s", field, strings.Join(LINK_FIELDS, ", "))
+	return errors.New(msg)
+}
+
+func linkValues(link Link, field string) ([]string, bool) {
+	switch field {
+	case "Title":
+		return []string{link.Title}, true
+	case "Date":
+		return []string{link.Date}, true
+	case "Year":
+		if len(link.Date) < 4 || link.Date == ZERO_DATE {
+			return []string{""}, true
+		}
+		return []string{link.Date[:4]}, true
+	case "Month":
+		if len(link.Date) < 7 || link.Date == ZERO_DATE {
+			return []string{""}, true
+		}
+		return []string{link.Date[:7]}, true
+	case "Url":
+		return []string{link.Url}, true
+	case "Category":
+		return []string{link.Category}, true
+	case "Kind":
+		return []string{link.Kind}, true
+	case "Tags":
+		return link.Tags, true
+	}
+	return nil, false
+}
+
+func linkSortKey(link Link, field string) string {
+	values, _ := linkValues(link, field)
+	return strings.Join(values, "\x00")
+}
+
+func sortLinks(field string, order string, links []Link, collator *titleCollator) ([]Link, error) {
+	if _, known := linkValues(Link{}, field); !known {
+		return nil, unknownLinkField(field)
+	}
+	if order != SORT_ASCENDING && order != SORT_DESCENDING {
+		msg := fmt.Sprintf("order must be '%s' or '%s', not '%s'", SORT_ASCENDING, SORT_DESCENDING, order)
+		return nil, errors.New(msg)
+	}
+	sorted := append([]Link(nil), links...)
+	titles := collator.order()
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left := linkSortKey(sorted[i], field)
+		right := linkSortKey(sorted[j], field)
+		compared := strings.Compare(left, right)
+		if field == "Title" {
+			compared = titles.compare(left, right)
+		}
+		if compared == 0 {
+			return sorted[i].Url < sorted[j].Url
+		}
+		if order == SORT_DESCENDING {
+			return compared > 0
+		}
+		return compared < 0
+	})
+	return sorted, nil
+}
+
+func groupLinksBy(field string, links []Link) ([]Group, error) {
+	if _, known := linkValues(Link{}, field); !known {
+		return nil, unknownLinkField(field)
+	}
+	var groups []Group
+	positions := map[string]int{}
+	for _, link := range links {
+		values, _ := linkValues(link, field)
+		for _, value := range values {
+			position, found := positions[value]
+			if !found {
+				position = len(groups)
+				positions[value] = position
+				groups = append(groups, Group{Name: value})
+			}
+			groups[position].Links = append(groups[position].Links, link)
+		}
+	}
+	return groups, nil
+}
+
+func whereLinks(field string, value string, links []Link) ([]Link, error) {
+	if _, known := linkValues(Link{}, field); !known {
+		return nil, unknownLinkField(field)
+	}
+	var matching []Link
+	for _, link := range links {
+		values, _ := linkValues(link, field)
+		for _, candidate := range values {
+			if candidate == value {
+				matching = append(matching, link)
+				break
+			}
+		}
+	}
+	return matching, nil
+}
+
+func checkCount(count int) error {
+	if count < 0 {
+		msg := fmt.Sprintf("count must not be negative, not %d", count)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func firstLinks(count int, links []Link) ([]Link, error) {
+	err := checkCount(count)
+	if err == nil && count < len(links) {
+		links = links[:count]
+	}
+	return links, err
+}
+
+func lastLinks(count int, links []Link) ([]Link, error) {
+	err := checkCount(count)
+	if err == nil && count < len(links) {
+		links = links[len(links)-count:]
+	}
+	return links, err
+}
+
+func afterLinks(count int, links []Link) ([]Link, error) {
+	err := checkCount(count)
+	if err == nil {
+		if count > len(links) {
+			count = len(links)
+		}
+		links = links[count:]
+	}
+	return links, err
+}
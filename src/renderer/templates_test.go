@@ -0,0 +1,53 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkTemplateFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		err      string
+	}{
+		{"sortBy", `{{range sortBy "Date" "desc" .Links}}{{.Title}},{{end}}`, "C,A,B,", ""},
+		{"sortBy title", `{{range sortBy "Title" "asc" .Links}}{{.Title}},{{end}}`, "A,B,C,", ""},
+		{"sortBy unknown field", `{{sortBy "Author" "asc" .Links}}`, "", "unknown field 'Author'"},
+		{"sortBy unknown order", `{{sortBy "Title" "up" .Links}}`, "", "order must be 'asc' or 'desc', not 'up'"},
+		{"groupBy", `{{range groupBy "Year" .Links}}{{.Name}}:{{range .Links}}{{.Title}}{{end}};{{end}}`, "2023:AB;2024:C;", ""},
+		{"groupBy tags", `{{range groupBy "Tags" .Links}}{{.Name}}:{{len .Links}};{{end}}`, "go:2;web:2;", ""},
+		{"where", `{{range where "Category" "Notes" .Links}}{{.Title}},{{end}}`, "B,C,", ""},
+		{"where tag", `{{range where "Tags" "web" .Links}}{{.Title}},{{end}}`, "B,C,", ""},
+		{"first", `{{range first 2 .Links}}{{.Title}},{{end}}`, "A,B,", ""},
+		{"first more than all", `{{range first 5 .Links}}{{.Title}},{{end}}`, "A,B,C,", ""},
+		{"last", `{{range last 1 .Links}}{{.Title}},{{end}}`, "C,", ""},
+		{"after", `{{range after 1 .Links}}{{.Title}},{{end}}`, "B,C,", ""},
+		{"after all", `{{range after 5 .Links}}{{.Title}},{{end}}`, "", ""},
+		{"negative count", `{{first -1 .Links}}`, "", "count must not be negative, not -1"},
+		{"chained", `{{range first 1 (sortBy "Title" "desc" (where "Tags" "go" .Links))}}{{.Title}}{{end}}`, "B", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := newTestSite(t)
+			site.writeFile(site.Configuration.TemplateIndex, test.template)
+			site.page("a.md", `{"Title": "A", "Date": "2023-03-01", "Category": "Travel", "Tags": ["go"]}`, "body\n")
+			site.page("b.md", `{"Title": "B", "Date": "2023-01-01", "Category": "Notes", "Tags": ["go", "web"]}`, "body\n")
+			site.page("c.md", `{"Title": "C", "Date": "2024-01-01", "Category": "Notes", "Tags": ["web"]}`, "body\n")
+			err := site.build()
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("build = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := site.read(INDEX_FILE_NAME); got != test.want {
+				t.Errorf("index = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package renderer
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+const TEXT_FILE_ENDING = ".txt"
+
+var BLOCK_TAGS = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true,
+	"div": true, "dl": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+var LINE_TAGS = map[string]bool{
+	"br": true, "dd": true, "dt": true, "li": true, "tr": true, "summary": true,
+}
+
+var SKIPPED_TAGS = map[string]bool{
+	"script": true, "style": true, "template": true,
+}
+
+var whitespacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+var trailingSpacePattern = regexp.MustCompile(`[ \t]+\n`)
+var blankLinesPattern = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+
+type textStripper struct {
+	builder strings.Builder
+	pre     int
+	lists   int
+	skip    string
+}
+
+func (stripper *textStripper) text(raw string) {
+	if len(stripper.skip) > 0 {
+		return
+	}
+	text := html.UnescapeString(raw)
+	if stripper.pre == 0 {
+		text = whitespacePattern.ReplaceAllString(text, " ")
+		current := stripper.builder.String()
+		if strings.HasPrefix(text, " ") && (len(current) == 0 || strings.HasSuffix(current, " ") || strings.HasSuffix(current, "\n") || strings.HasSuffix(current, "\t")) {
+			text = text[1:]
+		}
+	}
+	stripper.builder.WriteString(text)
+}
+
+func (stripper *textStripper) breakLine(lines int) {
+	current := stripper.builder.String()
+	if len(current) == 0 {
+		return
+	}
+	trailing := len(current) - len(strings.TrimRight(current, "\n"))
+	for ; trailing < lines; trailing++ {
+		stripper.builder.WriteString("\n")
+	}
+}
+
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	name := strings.TrimPrefix(tag, "/")
+	end := strings.IndexAny(name, " \t\r\n/>")
+	if end != -1 {
+		name = name[:end]
+	}
+	return strings.ToLower(name), closing
+}
+
+func (stripper *textStripper) tag(tag string) {
+	name, closing := tagName(tag)
+	if len(stripper.skip) > 0 {
+		if closing && name == stripper.skip {
+			stripper.skip = ""
+		}
+		return
+	}
+	if SKIPPED_TAGS[name] && !closing {
+		stripper.skip = name
+		return
+	}
+	if name == "pre" {
+		if closing && stripper.pre > 0 {
+			stripper.pre--
+		} else if !closing {
+			stripper.pre++
+		}
+	}
+	isList := name == "ul" || name == "ol"
+	if isList && closing && stripper.lists > 0 {
+		stripper.lists--
+	}
+	insideList := stripper.lists > 0
+	if isList && !closing {
+		stripper.lists++
+	}
+	if name == "br" {
+		stripper.builder.WriteString("\n")
+	} else if BLOCK_TAGS[name] && !(insideList && (isList || name == "p")) {
+		stripper.breakLine(2)
+	} else if BLOCK_TAGS[name] || LINE_TAGS[name] {
+		stripper.breakLine(1)
+	} else if name == "td" || name == "th" {
+		if closing {
+			stripper.builder.WriteString("\t")
+		}
+	}
+}
+
+func tagEnd(markup string, start int) int {
+	var quote byte
+	for index := start; index < len(markup); index++ {
+		character := markup[index]
+		if quote != 0 {
+			if character == quote {
+				quote = 0
+			}
+		} else if character == '"' || character == '\'' {
+			quote = character
+		} else if character == '>' {
+			return index
+		}
+	}
+	end := strings.IndexByte(markup[start:], '>')
+	if end != -1 {
+		end += start
+	}
+	return end
+}
+
+func StripHTML(markup string) string {
+	var stripper textStripper
+	offset := 0
+	for offset < len(markup) {
+		start := strings.IndexByte(markup[offset:], '<')
+		if start == -1 {
+			stripper.text(markup[offset:])
+			break
+		}
+		start += offset
+		stripper.text(markup[offset:start])
+		if strings.HasPrefix(markup[start:], HTML_COMMENT_START) {
+			end := strings.Index(markup[start:], HTML_COMMENT_END)
+			if end == -1 {
+				break
+			}
+			offset = start + end + len(HTML_COMMENT_END)
+			continue
+		}
+		next := start + 1
+		if next >= len(markup) || !(isTagStart(markup[next])) {
+			stripper.text("<")
+			offset = next
+			continue
+		}
+		end := tagEnd(markup, next)
+		if end == -1 {
+			break
+		}
+		stripper.tag(markup[next:end])
+		offset = end + 1
+	}
+	text := trailingSpacePattern.ReplaceAllString(stripper.builder.String(), "\n")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+func isTagStart(character byte) bool {
+	return character == '/' || character == '!' || character == '?' ||
+		(character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
+
+func textOutputPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, ".html") + TEXT_FILE_ENDING
+}
+
+func renderText(page Page) []byte {
+	var builder strings.Builder
+	builder.WriteString(page.Title)
+	builder.WriteString("\n")
+	if len(page.Date) > 0 && page.Date != ZERO_DATE {
+		builder.WriteString(page.Date)
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\n")
+	builder.WriteString(StripHTML(page.Content))
+	builder.WriteString("\n")
+	return []byte(builder.String())
+}
@@ -0,0 +1,139 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DIFF_CONTEXT_LINES = 3
+const MAX_DIFF_CELLS = 4000000
+
+type diffLine struct {
+	kind byte
+	text string
+}
+
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func diffLines(old []string, new []string) []diffLine {
+	var lines []diffLine
+	if len(old)*len(new) > MAX_DIFF_CELLS {
+		for _, line := range old {
+			lines = append(lines, diffLine{'-', line})
+		}
+		for _, line := range new {
+			lines = append(lines, diffLine{'+', line})
+		}
+		return lines
+	}
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			lines = append(lines, diffLine{' ', old[i]})
+			i++
+			j++
+		case j == len(new) || i < len(old) && common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, diffLine{'-', old[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', new[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func unifiedDiff(oldName string, newName string, old string, new string) string {
+	lines := diffLines(splitLines(old), splitLines(new))
+	var builder strings.Builder
+	builder.WriteString("--- " + oldName + "\n")
+	builder.WriteString("+++ " + newName + "\n")
+	oldLine, newLine := 0, 0
+	for index := 0; index < len(lines); {
+		if lines[index].kind == ' ' {
+			index++
+			oldLine++
+			newLine++
+			continue
+		}
+		start := index - DIFF_CONTEXT_LINES
+		if start < 0 {
+			start = 0
+		}
+		end := index
+		for unchanged := 0; end < len(lines) && unchanged <= 2*DIFF_CONTEXT_LINES; end++ {
+			if lines[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > index && lines[end-1].kind == ' ' {
+			end--
+		}
+		end += DIFF_CONTEXT_LINES
+		if end > len(lines) {
+			end = len(lines)
+		}
+		oldStart, newStart := oldLine-(index-start), newLine-(index-start)
+		oldCount, newCount := 0, 0
+		var body strings.Builder
+		for _, line := range lines[start:end] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+			body.WriteByte(line.kind)
+			body.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		builder.WriteString(body.String())
+		for _, line := range lines[index:end] {
+			if line.kind != '+' {
+				oldLine++
+			}
+			if line.kind != '-' {
+				newLine++
+			}
+		}
+		index = end
+	}
+	return builder.String()
+}
@@ -0,0 +1,236 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+var VOID_ELEMENTS = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+var OPTIONAL_END_ELEMENTS = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true,
+	"option": true, "optgroup": true, "colgroup": true, "thead": true, "tbody": true, "tfoot": true,
+	"tr": true, "td": true, "th": true, "rb": true, "rt": true, "rtc": true, "rp": true,
+}
+
+var LIST_ELEMENTS = map[string]bool{
+	"ul":   true,
+	"ol":   true,
+	"menu": true,
+}
+
+type validationFinding struct {
+	Condition string
+	Detail    string
+}
+
+type validationOutput struct {
+	output     OutputWriter
+	duplicates bool
+	slots      chan bool
+	budget     *byteBudget
+	group      sync.WaitGroup
+	mutex      sync.Mutex
+	findings   map[string][]validationFinding
+}
+
+type validationWriter struct {
+	io.WriteCloser
+	path   string
+	buffer bytes.Buffer
+	parent *validationOutput
+}
+
+func newValidationOutput(output OutputWriter, duplicates bool, configuration Configuration) *validationOutput {
+	return &validationOutput{
+		output:     output,
+		duplicates: duplicates,
+		slots:      make(chan bool, validationWorkers(configuration)),
+		budget:     newByteBudget(maxInFlightBytes(configuration)),
+		findings:   map[string][]validationFinding{},
+	}
+}
+
+func (output *validationOutput) Create(path string) (io.WriteCloser, error) {
+	file, err := output.output.Create(path)
+	if err != nil || !strings.HasSuffix(path, ".html") {
+		return file, err
+	}
+	return &validationWriter{WriteCloser: file, path: filepath.ToSlash(path), parent: output}, nil
+}
+
+func (output *validationOutput) Finish() error {
+	return output.output.Finish()
+}
+
+func (writer *validationWriter) Write(data []byte) (int, error) {
+	written, err := writer.WriteCloser.Write(data)
+	writer.buffer.Write(data[:written])
+	return written, err
+}
+
+func (writer *validationWriter) Close() error {
+	err := writer.WriteCloser.Close()
+	if err == nil {
+		parent := writer.parent
+		path := writer.path
+		markup := writer.buffer.Bytes()
+		reserved := parent.budget.acquire(int64(len(markup)))
+		parent.group.Add(1)
+		go func() {
+			defer parent.group.Done()
+			parent.slots <- true
+			findings := validateHTML(markup, parent.duplicates)
+			<-parent.slots
+			parent.budget.release(reserved)
+			parent.mutex.Lock()
+			if len(findings) > 0 {
+				parent.findings[path] = findings
+			} else {
+				delete(parent.findings, path)
+			}
+			parent.mutex.Unlock()
+		}()
+	}
+	return err
+}
+
+func openElements(stack []string, from int) []string {
+	var open []string
+	for index := len(stack) - 1; index >= from; index-- {
+		if !OPTIONAL_END_ELEMENTS[stack[index]] {
+			open = append(open, stack[index])
+		}
+	}
+	return open
+}
+
+func insideList(stack []string) (string, bool) {
+	for index := len(stack) - 1; index >= 0; index-- {
+		if stack[index] != "li" {
+			return stack[index], LIST_ELEMENTS[stack[index]]
+		}
+	}
+	return "", false
+}
+
+func validateHTML(markup []byte, duplicates bool) []validationFinding {
+	var findings []validationFinding
+	structure := func(line int, format string, arguments ...interface{}) {
+		detail := fmt.Sprintf("line %d: ", line) + fmt.Sprintf(format, arguments...)
+		findings = append(findings, validationFinding{Condition: CONDITION_HTML_STRUCTURE, Detail: detail})
+	}
+	var stack []string
+	ids := map[string]int{}
+	line := 1
+	tokenizer := html.NewTokenizer(bytes.NewReader(markup))
+	for {
+		kind := tokenizer.Next()
+		if kind == html.ErrorToken {
+			break
+		}
+		raw := tokenizer.Raw()
+		position := line
+		line += bytes.Count(raw, []byte("\n"))
+		if kind != html.StartTagToken && kind != html.SelfClosingTagToken && kind != html.EndTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		name := token.Data
+		if kind == html.EndTagToken {
+			if VOID_ELEMENTS[name] {
+				continue
+			}
+			match := -1
+			for index := len(stack) - 1; index >= 0; index-- {
+				if stack[index] == name {
+					match = index
+					break
+				}
+			}
+			if match == -1 {
+				structure(position, "</%s> has no matching start tag", name)
+				continue
+			}
+			for _, open := range openElements(stack, match+1) {
+				structure(position, "<%s> is not closed before </%s>", open, name)
+			}
+			stack = stack[:match]
+			continue
+		}
+		hasAlt := false
+		for _, attribute := range token.Attr {
+			switch attribute.Key {
+			case "id":
+				ids[attribute.Val]++
+				if duplicates && ids[attribute.Val] == 2 {
+					structure(position, "id '%s' is used more than once", attribute.Val)
+				}
+			case "alt":
+				hasAlt = true
+			}
+		}
+		if name == "li" {
+			if parent, listed := insideList(stack); !listed {
+				if len(parent) == 0 {
+					parent = "the document"
+				} else {
+					parent = "<" + parent + ">"
+				}
+				structure(position, "<li> is not inside a list but inside %s", parent)
+			}
+		}
+		if name == "img" && !hasAlt {
+			detail := fmt.Sprintf("line %d: <img> has no alt attribute", position)
+			findings = append(findings, validationFinding{Condition: CONDITION_A11Y, Detail: detail})
+		}
+		if kind == html.StartTagToken && !VOID_ELEMENTS[name] {
+			stack = append(stack, name)
+		}
+	}
+	for _, open := range openElements(stack, 0) {
+		structure(line, "<%s> is never closed", open)
+	}
+	return findings
+}
+
+func checkValidation(builder *Builder, validations *validationOutput) {
+	if validations == nil {
+		return
+	}
+	validations.group.Wait()
+	var paths []string
+	for path := range validations.findings {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, finding := range validations.findings[path] {
+			builder.report(finding.Condition, path, finding.Detail)
+		}
+	}
+	validations.findings = map[string][]validationFinding{}
+}
+
+func validationCounts(conditions map[string]int) map[string]int {
+	var counts map[string]int
+	for _, condition := range []string{CONDITION_HTML_STRUCTURE, CONDITION_A11Y} {
+		if conditions[condition] > 0 {
+			if counts == nil {
+				counts = map[string]int{}
+			}
+			counts[condition] = conditions[condition]
+		}
+	}
+	return counts
+}
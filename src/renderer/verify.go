@@ -0,0 +1,141 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+)
+
+const VERIFY_ADDED = "added"
+const VERIFY_REMOVED = "removed"
+const VERIFY_CHANGED = "changed"
+
+var DEFAULT_VERIFY_IGNORE = []string{MANIFEST_FILE_NAME}
+
+type VerifyDifference struct {
+	Path   string
+	Change string
+	Detail string
+}
+
+func NewVerifyBuilder(configuration Configuration) (*Builder, *MemoryOutput) {
+	if len(os.Getenv(SOURCE_DATE_EPOCH_VARIABLE)) == 0 {
+		log.Printf("warning: %s is not set, build times in the output differ from run to run", SOURCE_DATE_EPOCH_VARIABLE)
+	}
+	configuration.Output = ""
+	configuration.CacheFile = ""
+	output := NewMemoryOutput()
+	builder := NewBuilder(configuration)
+	builder.Output = output
+	builder.NoCache = true
+	return builder, output
+}
+
+func verifyIgnored(configuration Configuration, path string) bool {
+	if path == CACHE_FILE_NAME || path == LOCK_FILE_NAME {
+		return true
+	}
+	ignore := configuration.VerifyIgnore
+	if ignore == nil {
+		ignore = DEFAULT_VERIFY_IGNORE
+	}
+	return matchAny(ignore, path, false)
+}
+
+func goldenFiles(configuration Configuration, directory string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(directory, path)
+		if err == nil && !verifyIgnored(configuration, filepath.ToSlash(relative)) {
+			paths = append(paths, filepath.ToSlash(relative))
+		}
+		return err
+	})
+	return paths, err
+}
+
+func isTextFile(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) == -1
+}
+
+func compareOutput(path string, golden []byte, built []byte) (VerifyDifference, bool) {
+	if bytes.Equal(golden, built) {
+		return VerifyDifference{}, false
+	}
+	difference := VerifyDifference{Path: path, Change: VERIFY_CHANGED}
+	if isTextFile(golden) && isTextFile(built) {
+		difference.Detail = unifiedDiff("golden/"+path, "built/"+path, string(golden), string(built))
+	} else {
+		difference.Detail = fmt.Sprintf("binary file differs: %d bytes, sha256 %s, is now %d bytes, sha256 %s",
+			len(golden), hashContent(golden)[:12], len(built), hashContent(built)[:12])
+	}
+	return difference, true
+}
+
+func VerifyOutput(configuration Configuration, output *MemoryOutput, golden string) ([]VerifyDifference, error) {
+	var differences []VerifyDifference
+	existing, err := goldenFiles(configuration, golden)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, path := range existing {
+		seen[path] = true
+		built, found := output.File(path)
+		if !found {
+			differences = append(differences, VerifyDifference{Path: path, Change: VERIFY_REMOVED})
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(golden, filepath.FromSlash(path)))
+		if err != nil {
+			return nil, err
+		}
+		if difference, differs := compareOutput(path, data, built); differs {
+			differences = append(differences, difference)
+		}
+	}
+	for _, path := range output.Paths() {
+		if !seen[path] && !verifyIgnored(configuration, path) {
+			differences = append(differences, VerifyDifference{Path: path, Change: VERIFY_ADDED})
+		}
+	}
+	sort.SliceStable(differences, func(i, j int) bool {
+		return differences[i].Path < differences[j].Path
+	})
+	return differences, nil
+}
+
+func UpdateGolden(configuration Configuration, output *MemoryOutput, golden string) error {
+	existing, err := goldenFiles(configuration, golden)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, path := range existing {
+		if _, found := output.File(path); !found {
+			err = os.Remove(filepath.Join(golden, filepath.FromSlash(path)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	files := NewOutputWriter(golden)
+	for _, path := range output.Paths() {
+		if verifyIgnored(configuration, path) {
+			continue
+		}
+		data, _ := output.File(path)
+		err = writeFile(files, path, data)
+		if err != nil {
+			return err
+		}
+	}
+	return files.Finish()
+}
@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const GENERATOR_NAME = "go-markdown-to-html"
+const SOURCE_DATE_EPOCH_VARIABLE = "SOURCE_DATE_EPOCH"
+
+var Version = "dev"
+
+func Generator() string {
+	version := Version
+	if len(version) > 0 && version[0] >= '0' && version[0] <= '9' {
+		version = "v" + version
+	}
+	return GENERATOR_NAME + " " + version
+}
+
+func (builder *Builder) buildTime() (time.Time, error) {
+	epoch := os.Getenv(SOURCE_DATE_EPOCH_VARIABLE)
+	if len(epoch) == 0 {
+		return builder.now()
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(epoch), 10, 64)
+	if err != nil {
+		msg := fmt.Sprintf("%s must be a unix timestamp, not '%s'", SOURCE_DATE_EPOCH_VARIABLE, epoch)
+		return time.Time{}, errors.New(msg)
+	}
+	buildTime := time.Unix(seconds, 0).UTC()
+	location, err := loadLocation(builder.Configuration)
+	if err == nil && location != nil {
+		buildTime = buildTime.In(location)
+	}
+	return buildTime, err
+}
+
+func generatorComment(html []byte, site *Site) []byte {
+	comment := fmt.Sprintf("<!-- built by %s at %s -->", site.Generator, site.BuildTime)
+	end := strings.LastIndex(strings.ToLower(string(html)), "</html>")
+	if end == -1 {
+		return append(append(html, comment...), '\n')
+	}
+	end += len("</html>")
+	var result []byte
+	result = append(result, html[:end]...)
+	result = append(result, '\n')
+	result = append(result, comment...)
+	if end == len(html) {
+		result = append(result, '\n')
+	}
+	return append(result, html[end:]...)
+}
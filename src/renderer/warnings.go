@@ -0,0 +1,128 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const DEFAULT_WARNING_LIMIT = 20
+const UNLIMITED_WARNINGS = -1
+
+const WARNING_FAILED_PAGE = "failed-page"
+const WARNING_SHADOWED_ASSET = "shadowed-asset"
+const WARNING_UNSAFE_FILE_NAME = "unsafe-file-name"
+const WARNING_IMAGE_VARIANTS = "image-variants"
+
+type warningEntry struct {
+	finding Finding
+	line    string
+}
+
+type warningLog struct {
+	mutex   sync.Mutex
+	limit   int
+	stats   bool
+	entries []warningEntry
+	flushed bool
+}
+
+func warningLimit(configuration Configuration) int {
+	if configuration.WarningLimit == 0 {
+		return DEFAULT_WARNING_LIMIT
+	}
+	return configuration.WarningLimit
+}
+
+func checkWarningLimit(configuration Configuration) error {
+	if configuration.WarningLimit < UNLIMITED_WARNINGS {
+		msg := fmt.Sprintf("WarningLimit must be %d for no limit or a positive number, not %d", UNLIMITED_WARNINGS, configuration.WarningLimit)
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func newWarningLog(configuration Configuration) *warningLog {
+	return &warningLog{limit: warningLimit(configuration), stats: configuration.ContentStats}
+}
+
+func (warnings *warningLog) add(category string, path string, message string, line string) {
+	if warnings == nil {
+		log.Print(line)
+		return
+	}
+	warnings.mutex.Lock()
+	defer warnings.mutex.Unlock()
+	finding := newFinding(POLICY_WARN, category, path, message)
+	warnings.entries = append(warnings.entries, warningEntry{finding: finding, line: line})
+}
+
+func (builder *Builder) warn(category string, path string, format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+	builder.warnings.add(category, path, message, "warning: "+message)
+}
+
+func (warnings *warningLog) sorted() []warningEntry {
+	warnings.mutex.Lock()
+	entries := append([]warningEntry(nil), warnings.entries...)
+	warnings.mutex.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].finding.Condition != entries[j].finding.Condition {
+			return entries[i].finding.Condition < entries[j].finding.Condition
+		}
+		return entries[i].finding.Path < entries[j].finding.Path
+	})
+	return entries
+}
+
+func (warnings *warningLog) all() []Finding {
+	if warnings == nil {
+		return nil
+	}
+	var findings []Finding
+	for _, entry := range warnings.sorted() {
+		findings = append(findings, entry.finding)
+	}
+	return findings
+}
+
+func formatCount(count int) string {
+	digits := strconv.Itoa(count)
+	for index := len(digits) - 3; index > 0; index -= 3 {
+		digits = digits[:index] + "," + digits[index:]
+	}
+	return digits
+}
+
+func (warnings *warningLog) flush() {
+	if warnings == nil || warnings.flushed {
+		return
+	}
+	warnings.flushed = true
+	entries := warnings.sorted()
+	for start := 0; start < len(entries); {
+		category := entries[start].finding.Condition
+		end := start
+		for end < len(entries) && entries[end].finding.Condition == category {
+			end++
+		}
+		shown := end
+		if warnings.limit != UNLIMITED_WARNINGS && end-start > warnings.limit {
+			shown = start + warnings.limit
+		}
+		for _, entry := range entries[start:shown] {
+			log.Print(entry.line)
+		}
+		if hidden := end - shown; hidden > 0 {
+			where := "set ContentStats for the full list in " + CONTENT_STATS_FILE_NAME
+			if warnings.stats {
+				where = "see " + CONTENT_STATS_FILE_NAME + " for the full list"
+			}
+			log.Printf("…and %s more %s warnings (%s)", formatCount(hidden), category, where)
+		}
+		start = end
+	}
+}
@@ -0,0 +1,63 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestWarningLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		stats bool
+		count int
+		want  []string
+	}{
+		{"under the limit", 3, false, 2, []string{"warning: x b.md", "warning: w0 a.md", "warning: w1 a.md"}},
+		{"at the limit", 2, false, 2, []string{"warning: x b.md", "warning: w0 a.md", "warning: w1 a.md"}},
+		{"over the limit", 2, false, 5, []string{"warning: x b.md", "warning: w0 a.md", "warning: w1 a.md",
+			"…and 3 more unsafe-file-name warnings (set ContentStats for the full list in " + CONTENT_STATS_FILE_NAME + ")"}},
+		{"over the limit with stats", 1, true, 3, []string{"warning: x b.md", "warning: w0 a.md",
+			"…and 2 more unsafe-file-name warnings (see " + CONTENT_STATS_FILE_NAME + " for the full list)"}},
+		{"thousands", 1, false, 1235, []string{"warning: x b.md", "warning: w0 a.md",
+			"…and 1,234 more unsafe-file-name warnings (set ContentStats for the full list in " + CONTENT_STATS_FILE_NAME + ")"}},
+		{"unlimited", UNLIMITED_WARNINGS, false, 3, []string{"warning: x b.md", "warning: w0 a.md", "warning: w1 a.md", "warning: w2 a.md"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			log.SetOutput(&buffer)
+			log.SetFlags(0)
+			defer log.SetFlags(log.LstdFlags)
+			defer log.SetOutput(ioutil.Discard)
+			warnings := newWarningLog(Configuration{WarningLimit: test.limit, ContentStats: test.stats})
+			warnings.add(WARNING_SHADOWED_ASSET, "b.md", "x b.md", "warning: x b.md")
+			for index := 0; index < test.count; index++ {
+				message := fmt.Sprintf("w%d a.md", index)
+				warnings.add(WARNING_UNSAFE_FILE_NAME, "a.md", message, "warning: "+message)
+			}
+			warnings.flush()
+			warnings.flush()
+			got := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("warnings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+			if findings := warnings.all(); len(findings) != test.count+1 {
+				t.Errorf("findings = %d, want all %d", len(findings), test.count+1)
+			}
+		})
+	}
+}
+
+func TestDefaultWarningLimit(t *testing.T) {
+	if limit := warningLimit(Configuration{}); limit != DEFAULT_WARNING_LIMIT {
+		t.Errorf("warningLimit = %d, want %d", limit, DEFAULT_WARNING_LIMIT)
+	}
+	if err := checkWarningLimit(Configuration{WarningLimit: -2}); err == nil {
+		t.Error("checkWarningLimit(-2) succeeded, want an error")
+	}
+}